@@ -180,6 +180,8 @@ func SortResponseActions(actions []*ResponseAction) {
 
 // GetActionIndex 获取行动的索引值
 // 这个方法主要用于处理Red Dora的特殊情况
+//
+// Deprecated: 编号不连续，不能用作网络输出，请使用 SelfActionIndex / ResponseActionIndex
 func (a *Action) GetActionIndex() int {
 	// 如果没有对应的牌，返回行动类型的索引
 	if len(a.CorrespondTiles) == 0 {
@@ -0,0 +1,280 @@
+package mahjong
+
+// 固定大小的动作空间，与 pymahjong（MahjongEnv）的54维动作编码一致：
+//
+//	0-33   打出该种牌（非赤）
+//	34-36  打出赤5m、赤5p、赤5s
+//	37-39  吃（被吃的牌在顺子的左、中、右），不使用赤宝牌
+//	40-42  吃（同上），使用赤宝牌
+//	43, 44 碰，不使用/使用赤宝牌
+//	45     暗杠
+//	46     大明杠
+//	47     加杠
+//	48     立直（两步立直的第二步）
+//	49     荣和（含抢杠、抢暗杠）
+//	50     自摸
+//	51     九种九牌
+//	52     不立直（两步立直的第二步）
+//	53     Pass
+//
+// 立直与 pymahjong 一样分两步：第一步的掩码中没有立直，打出的牌可以立直时（见 CanRiichiWithActionIndex），
+// 第二步在 ActionRiichi 与 ActionPassRiichi 之间选择（见 RiichiActionMask、MakeRiichiSelectionFromActionIndex）。
+// 暗杠、加杠在编号上不区分牌；可以杠的牌有多种时同样分两步（见 KanNeedsTileChoice），
+// 第二步用 0-33 的编号选择杠哪一种牌（见 KanTileActionMask、MakeKanSelectionFromActionIndex）
+const (
+	ActionDiscardRed5m = NBaseTiles + iota
+	ActionDiscardRed5p
+	ActionDiscardRed5s
+	ActionChiLeft
+	ActionChiMiddle
+	ActionChiRight
+	ActionChiLeftRed
+	ActionChiMiddleRed
+	ActionChiRightRed
+	ActionPon
+	ActionPonRed
+	ActionAnKan
+	ActionKan
+	ActionKaKan
+	ActionRiichi
+	ActionRon
+	ActionTsumo
+	ActionKyushukyuhai
+	ActionPassRiichi
+	ActionPass
+
+	// NActionSpace 动作空间大小
+	NActionSpace
+)
+
+// SelfActionIndex 返回自主行动在动作空间中的编号，无法编码时返回 -1
+func SelfActionIndex(a *SelfAction) int {
+	switch a.GetAction() {
+	case Discard:
+		return DiscardActionIndex(a.CorrespondTiles[0])
+	case AnKan:
+		return ActionAnKan
+	case KaKan:
+		return ActionKaKan
+	case Riichi:
+		return ActionRiichi
+	case Tsumo:
+		return ActionTsumo
+	case Kyushukyuhai:
+		return ActionKyushukyuhai
+	}
+	return -1
+}
+
+// DiscardActionIndex 返回打出 tile 在动作空间中的编号
+func DiscardActionIndex(tile *Tile) int {
+	if tile.RedDora {
+		return ActionDiscardRed5m + int(tile.Tile)/9
+	}
+	return int(tile.Tile)
+}
+
+// ResponseActionIndex 返回对 tile 的响应在动作空间中的编号，无法编码时返回 -1
+func ResponseActionIndex(a *ResponseAction, tile BaseTile) int {
+	red := CountRedDora(a.CorrespondTiles) > 0
+	switch a.GetAction() {
+	case Pass:
+		return ActionPass
+	case Chi:
+		index := ActionChiLeft
+		switch {
+		case tile > a.CorrespondTiles[1].Tile:
+			index = ActionChiRight
+		case tile > a.CorrespondTiles[0].Tile:
+			index = ActionChiMiddle
+		}
+		if red {
+			index += ActionChiLeftRed - ActionChiLeft
+		}
+		return index
+	case Pon:
+		if red {
+			return ActionPonRed
+		}
+		return ActionPon
+	case Kan:
+		return ActionKan
+	case Ron, ChanKan, ChanAnKan:
+		return ActionRon
+	}
+	return -1
+}
+
+// SelfActionsForIndex 返回编号对应的自主行动在 actions 中的下标
+func SelfActionsForIndex(actions []*SelfAction, index int) []int {
+	var selections []int
+	for i, a := range actions {
+		if SelfActionIndex(a) == index {
+			selections = append(selections, i)
+		}
+	}
+	return selections
+}
+
+// ResponseActionsForIndex 返回编号对应的响应在 actions 中的下标
+func ResponseActionsForIndex(actions []*ResponseAction, tile BaseTile, index int) []int {
+	var selections []int
+	for i, a := range actions {
+		if ResponseActionIndex(a, tile) == index {
+			selections = append(selections, i)
+		}
+	}
+	return selections
+}
+
+// ActionIndices 返回当前选择的每个选项在动作空间中的编号（与 SelfActions 或 ResponseActions 一一对应）
+func (t *Table) ActionIndices() []int {
+	switch {
+	case t.Phase <= P4Action:
+		indices := make([]int, len(t.SelfActions))
+		for i, a := range t.SelfActions {
+			indices[i] = SelfActionIndex(a)
+		}
+		return indices
+	case t.Phase < GameOver:
+		indices := make([]int, len(t.ResponseActions))
+		for i, a := range t.ResponseActions {
+			indices[i] = ResponseActionIndex(a, t.SelectedTile.Tile)
+		}
+		return indices
+	}
+	return nil
+}
+
+// ActionMask 返回当前选择（两步立直的第一步）的合法动作掩码，本局结束时全部为 false
+// 立直不在掩码中，由可以立直的打牌进入第二步
+func (t *Table) ActionMask() [NActionSpace]bool {
+	var mask [NActionSpace]bool
+	for _, index := range t.ActionIndices() {
+		if index >= 0 {
+			mask[index] = true
+		}
+	}
+	mask[ActionRiichi] = false
+	return mask
+}
+
+// SelectionsForActionIndex 返回编号对应的当前选项下标，编号不合法时返回空
+func (t *Table) SelectionsForActionIndex(index int) []int {
+	var selections []int
+	for i, got := range t.ActionIndices() {
+		if got == index {
+			selections = append(selections, i)
+		}
+	}
+	return selections
+}
+
+// MakeSelectionFromActionIndex 按两步立直第一步的动作编号做出选择，打牌编号只打牌不立直
+// 编号不合法或对应多个行动（需要第二步选择杠的牌）时返回 false
+func (t *Table) MakeSelectionFromActionIndex(index int) bool {
+	if index == ActionRiichi {
+		return false
+	}
+	selections := t.SelectionsForActionIndex(index)
+	if len(selections) != 1 {
+		return false
+	}
+	return t.MakeSelection(selections[0])
+}
+
+// CanRiichiWithActionIndex 打出编号为 discard 的牌时能否立直，能则需要第二步
+func (t *Table) CanRiichiWithActionIndex(discard int) bool {
+	return t.RiichiSelection(discard, ActionRiichi) >= 0
+}
+
+// RiichiActionMask 返回打出编号为 discard 的牌之后第二步的掩码，只有 ActionRiichi 与 ActionPassRiichi
+// 该牌不能立直时全部为 false
+func (t *Table) RiichiActionMask(discard int) [NActionSpace]bool {
+	var mask [NActionSpace]bool
+	if t.CanRiichiWithActionIndex(discard) {
+		mask[ActionRiichi] = true
+		mask[ActionPassRiichi] = true
+	}
+	return mask
+}
+
+// RiichiSelection 返回第一步的打牌编号 discard 与第二步的编号 decision 对应的唯一选项下标
+// decision 为 ActionRiichi 时是打出该牌立直，为 ActionPassRiichi 时是只打出该牌；不合法时返回 -1
+func (t *Table) RiichiSelection(discard, decision int) int {
+	if t.Phase > P4Action {
+		return -1
+	}
+	want := Discard
+	switch decision {
+	case ActionRiichi:
+		want = Riichi
+	case ActionPassRiichi:
+	default:
+		return -1
+	}
+	for i, a := range t.SelfActions {
+		if a.GetAction() == want && DiscardActionIndex(a.CorrespondTiles[0]) == discard {
+			return i
+		}
+	}
+	return -1
+}
+
+// MakeRiichiSelectionFromActionIndex 按两步立直的两个编号做出选择，不合法时返回 false
+func (t *Table) MakeRiichiSelectionFromActionIndex(discard, decision int) bool {
+	if !t.CanRiichiWithActionIndex(discard) {
+		return false
+	}
+	selection := t.RiichiSelection(discard, decision)
+	if selection < 0 {
+		return false
+	}
+	return t.MakeSelection(selection)
+}
+
+// KanNeedsTileChoice 编号为 kan（ActionAnKan 或 ActionKaKan）的杠有多种牌可选时返回 true，此时需要第二步
+func (t *Table) KanNeedsTileChoice(kan int) bool {
+	if kan != ActionAnKan && kan != ActionKaKan {
+		return false
+	}
+	return len(t.SelectionsForActionIndex(kan)) > 1
+}
+
+// KanTileActionMask 返回选择编号为 kan 的杠之后第二步的掩码，可以杠的牌种（0-33）为 true
+// 不需要第二步时全部为 false
+func (t *Table) KanTileActionMask(kan int) [NActionSpace]bool {
+	var mask [NActionSpace]bool
+	if !t.KanNeedsTileChoice(kan) {
+		return mask
+	}
+	for _, i := range t.SelectionsForActionIndex(kan) {
+		mask[t.SelfActions[i].CorrespondTiles[0].Tile] = true
+	}
+	return mask
+}
+
+// KanSelection 返回第一步的杠编号 kan 与第二步的牌种编号 tile 对应的选项下标，不合法时返回 -1
+func (t *Table) KanSelection(kan, tile int) int {
+	if kan != ActionAnKan && kan != ActionKaKan {
+		return -1
+	}
+	for _, i := range t.SelectionsForActionIndex(kan) {
+		if int(t.SelfActions[i].CorrespondTiles[0].Tile) == tile {
+			return i
+		}
+	}
+	return -1
+}
+
+// MakeKanSelectionFromActionIndex 按杠的两个编号做出选择，不合法时返回 false
+func (t *Table) MakeKanSelectionFromActionIndex(kan, tile int) bool {
+	if !t.KanNeedsTileChoice(kan) {
+		return false
+	}
+	selection := t.KanSelection(kan, tile)
+	if selection < 0 {
+		return false
+	}
+	return t.MakeSelection(selection)
+}
@@ -0,0 +1,224 @@
+package mahjong
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestActionSpaceLayout(t *testing.T) {
+	if NActionSpace != 54 || ActionChiLeft != 37 || ActionPon != 43 || ActionRiichi != 48 || ActionPass != 53 {
+		t.Fatalf("action space must match the pymahjong encoding")
+	}
+}
+
+func TestActionMaskRedDiscard(t *testing.T) {
+	p := NewPlayer(East, true)
+	p.Hand = physicalTiles([]BaseTile{_5m, _5m, _1p, _2p, _3p, _4s, _5s, _6s, _7z, _7z, _7z, _9m, _9m, _1z}, _5m)
+	table := NewTable()
+	table.Players[0] = p
+	table.Phase = P1Action
+	table.SelfActions = p.GetDiscard(false)
+
+	mask := table.ActionMask()
+	if !mask[_5m] || !mask[ActionDiscardRed5m] || mask[ActionDiscardRed5p] {
+		t.Fatalf("expected separate discards for 5m and red 5m")
+	}
+	for _, index := range []int{int(_5m), ActionDiscardRed5m} {
+		sel := table.SelectionsForActionIndex(index)
+		if len(sel) != 1 {
+			t.Fatalf("index %d should map to exactly one discard, got %v", index, sel)
+		}
+		tile := table.SelfActions[sel[0]].CorrespondTiles[0]
+		if tile.Tile != _5m || tile.RedDora != (index == ActionDiscardRed5m) {
+			t.Fatalf("index %d decoded to %s", index, tile)
+		}
+	}
+}
+
+func TestActionIndexChi(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.Hand = physicalTiles([]BaseTile{_3p, _4p, _5p, _5p, _6p, _7p, _2m, _3m, _9s, _9s, _9s, _1z, _1z}, _5p)
+	called := &Tile{Tile: _5p, ID: int(_5p)*4 + 1}
+
+	got := make(map[int]bool)
+	for _, a := range p.GetChi(called) {
+		got[ResponseActionIndex(a, called.Tile)] = true
+	}
+	for _, index := range []int{ActionChiLeft, ActionChiMiddle, ActionChiRight} {
+		if !got[index] {
+			t.Fatalf("missing chi index %d, got %v", index, got)
+		}
+	}
+	if got[ActionChiLeftRed] || got[ActionChiMiddleRed] || got[ActionChiRightRed] {
+		t.Fatalf("the called tile is not red, no red chi expected, got %v", got)
+	}
+
+	// 吃6p时可以选择是否用赤5p
+	got = make(map[int]bool)
+	called = &Tile{Tile: _6p, ID: int(_6p)*4 + 1}
+	for _, a := range p.GetChi(called) {
+		got[ResponseActionIndex(a, called.Tile)] = true
+	}
+	if len(got) != 4 || !got[ActionChiMiddle] || !got[ActionChiMiddleRed] || !got[ActionChiRight] || !got[ActionChiRightRed] {
+		t.Fatalf("expected red chi variants when holding red 5p, got %v", got)
+	}
+}
+
+func TestActionMaskRandomGames(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for game := 0; game < 20; game++ {
+		table := NewTable()
+		table.GameInitWithConfig(GameConfig{HasSeed: true, Seed: int64(game + 1)})
+		for !table.IsGameOver() {
+			indices := table.ActionIndices()
+			mask := table.ActionMask()
+			legal := make([]int, 0, len(indices))
+			for i, index := range indices {
+				if index < 0 {
+					t.Fatalf("game %d: option %d has no action index", game, i)
+				}
+				if index == ActionRiichi {
+					// 立直在第二步：打出的牌必须在第一步的掩码中
+					discard := DiscardActionIndex(table.SelfActions[i].CorrespondTiles[0])
+					if !mask[discard] || !table.CanRiichiWithActionIndex(discard) {
+						t.Fatalf("game %d: riichi discard %d not reachable in two steps", game, discard)
+					}
+					continue
+				}
+				if !mask[index] {
+					t.Fatalf("game %d: index %d missing from mask", game, index)
+				}
+			}
+			for index, ok := range mask {
+				if ok {
+					legal = append(legal, index)
+				}
+			}
+			if len(legal) == 0 {
+				t.Fatalf("game %d: no legal action in phase %d", game, table.Phase)
+			}
+			if mask[ActionRiichi] || mask[ActionPassRiichi] {
+				t.Fatalf("riichi and pass-riichi belong to the second step")
+			}
+			action := legal[rng.Intn(len(legal))]
+			if table.CanRiichiWithActionIndex(action) {
+				decision := []int{ActionRiichi, ActionPassRiichi}[rng.Intn(2)]
+				if !table.MakeRiichiSelectionFromActionIndex(action, decision) {
+					t.Fatalf("game %d: riichi step rejected", game)
+				}
+				continue
+			}
+			if table.KanNeedsTileChoice(action) {
+				var tiles []int
+				for tile, ok := range table.KanTileActionMask(action) {
+					if ok {
+						tiles = append(tiles, tile)
+					}
+				}
+				if len(tiles) < 2 || !table.MakeKanSelectionFromActionIndex(action, tiles[rng.Intn(len(tiles))]) {
+					t.Fatalf("game %d: kan step rejected, tiles %v", game, tiles)
+				}
+				continue
+			}
+			if !table.MakeSelectionFromActionIndex(action) {
+				t.Fatalf("game %d: legal index rejected", game)
+			}
+		}
+		if table.MakeSelectionFromActionIndex(ActionPass) {
+			t.Fatalf("no selection should be accepted after the game is over")
+		}
+	}
+}
+
+func TestTwoStepRiichiSelection(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+	table := newScenarioTable(t, hands, []BaseTile{_9s, _9p}, nil, 0)
+
+	mask := table.ActionMask()
+	if mask[ActionRiichi] || mask[ActionPassRiichi] || !mask[_9s] || !mask[_5z] {
+		t.Fatalf("the first step should only offer discards")
+	}
+	if table.MakeSelectionFromActionIndex(ActionRiichi) {
+		t.Fatalf("riichi must not be selected without a discard")
+	}
+	// 打 5z 或 9s 都能立直，立直选项需要由打牌编号区分
+	if n := len(table.SelectionsForActionIndex(ActionRiichi)); n < 2 {
+		t.Fatalf("expected several riichi discards, got %d", n)
+	}
+	if !table.CanRiichiWithActionIndex(int(_5z)) || table.CanRiichiWithActionIndex(int(_1m)) {
+		t.Fatalf("only discards that keep tenpai allow riichi")
+	}
+	if table.RiichiActionMask(int(_1m)) != ([NActionSpace]bool{}) {
+		t.Fatalf("no second step after a discard that cannot riichi")
+	}
+	for index, ok := range table.RiichiActionMask(int(_5z)) {
+		if ok != (index == ActionRiichi || index == ActionPassRiichi) {
+			t.Fatalf("second step mask should be riichi or not, got index %d=%v", index, ok)
+		}
+	}
+	for _, decision := range []int{ActionRiichi, ActionPassRiichi} {
+		sel := table.RiichiSelection(int(_5z), decision)
+		if sel < 0 {
+			t.Fatalf("no selection for 5z with %d", decision)
+		}
+		a := table.SelfActions[sel]
+		want := Discard
+		if decision == ActionRiichi {
+			want = Riichi
+		}
+		if a.GetAction() != want || a.CorrespondTiles[0].Tile != _5z {
+			t.Fatalf("decision %d decoded to %v %s", decision, a.GetAction(), a.CorrespondTiles[0])
+		}
+	}
+	if table.RiichiSelection(int(_5z), ActionPass) >= 0 {
+		t.Fatalf("only riichi or pass-riichi is a second step")
+	}
+
+	if !table.MakeRiichiSelectionFromActionIndex(int(_5z), ActionRiichi) {
+		t.Fatalf("riichi with 5z rejected")
+	}
+	passResponses(table)
+	p0 := table.Players[0]
+	if !p0.IsRiichi() || p0.River.River[0].Tile.Tile != _5z {
+		t.Fatalf("expected riichi with 5z, riichi=%v river=%v", p0.IsRiichi(), p0.River.River)
+	}
+}
+
+// twoAnKanHands 庄家可以暗杠 1m 或 1p
+func twoAnKanHands() [NPlayers][]BaseTile {
+	return riichiScenarioHands([]BaseTile{_1m, _1m, _1m, _1m, _1p, _1p, _1p, _1p, _3m, _4m, _5m, _6m, _5z})
+}
+
+func TestTwoStepKanSelection(t *testing.T) {
+	table := newScenarioTable(t, twoAnKanHands(), []BaseTile{_9s}, nil, 0)
+
+	if !table.ActionMask()[ActionAnKan] || !table.KanNeedsTileChoice(ActionAnKan) {
+		t.Fatalf("two ankan options should need a second step")
+	}
+	if table.MakeSelectionFromActionIndex(ActionAnKan) {
+		t.Fatalf("an ambiguous ankan index must not pick a tile silently")
+	}
+	for index, ok := range table.KanTileActionMask(ActionAnKan) {
+		if ok != (index == int(_1m) || index == int(_1p)) {
+			t.Fatalf("second step mask should be 1m or 1p, got index %d=%v", index, ok)
+		}
+	}
+	if table.KanSelection(ActionAnKan, int(_3m)) >= 0 || table.KanSelection(ActionKaKan, int(_1p)) >= 0 {
+		t.Fatalf("only the offered kan tiles are second steps")
+	}
+	if table.MakeKanSelectionFromActionIndex(ActionAnKan, int(_3m)) {
+		t.Fatalf("ankan of 3m accepted")
+	}
+
+	if !table.MakeKanSelectionFromActionIndex(ActionAnKan, int(_1p)) {
+		t.Fatalf("ankan of 1p rejected")
+	}
+	passResponses(table)
+	groups := table.Players[0].CallGroups
+	if len(groups) != 1 || groups[0].Type != Kantsu || groups[0].Tiles[0] != _1p {
+		t.Fatalf("expected an ankan of 1p, got %v", groups)
+	}
+	if table.KanNeedsTileChoice(ActionAnKan) {
+		t.Fatalf("a single ankan option needs no second step")
+	}
+}
@@ -0,0 +1,234 @@
+package mahjong
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// 和牌查表
+// 数牌按花色编码，编码方式与 Syanten.HandToCode 相同（每种牌占3位，共9种）
+// 表中记录每个编码本身能否拆成面子（最多一个雀头），以及加入哪些牌之后可以
+// 判断和牌与听牌只需要查表，不分配内存；需要具体拆分（算分）时仍使用 TileSplitter
+
+// agariComplete 表示该花色本身可以拆成面子（最多一个雀头），低9位表示加入对应的牌后可以
+const agariComplete = 1 << 9
+
+var (
+	agariTable     map[uint32]uint16
+	agariTableOnce sync.Once
+)
+
+// getAgariTable 返回和牌查表，第一次调用时生成，之后只读，可以在多个 goroutine 中同时使用
+func getAgariTable() map[uint32]uint16 {
+	agariTableOnce.Do(func() {
+		complete := make(map[uint32]struct{})
+		var counts [9]uint8
+		// 面子 0~8 为刻子，9~15 为顺子，按下标不减的顺序加入，避免重复枚举
+		var search func(start, mentsu int)
+		search = func(start, mentsu int) {
+			complete[suitCode(&counts)] = struct{}{}
+			for pair := range counts {
+				if counts[pair] <= 2 {
+					counts[pair] += 2
+					complete[suitCode(&counts)] = struct{}{}
+					counts[pair] -= 2
+				}
+			}
+			if mentsu == 4 {
+				return
+			}
+			for m := start; m < 16; m++ {
+				if m < 9 {
+					if counts[m] > 1 {
+						continue
+					}
+					counts[m] += 3
+					search(m, mentsu+1)
+					counts[m] -= 3
+					continue
+				}
+				pos := m - 9
+				if counts[pos] == 4 || counts[pos+1] == 4 || counts[pos+2] == 4 {
+					continue
+				}
+				counts[pos]++
+				counts[pos+1]++
+				counts[pos+2]++
+				search(m, mentsu+1)
+				counts[pos]--
+				counts[pos+1]--
+				counts[pos+2]--
+			}
+		}
+		search(0, 0)
+
+		table := make(map[uint32]uint16, 2*len(complete))
+		for code := range complete {
+			table[code] |= agariComplete
+			for pos := 0; pos < 9; pos++ {
+				if bit := uint32(1) << (3 * pos); code&(7*bit) != 0 {
+					table[code-bit] |= 1 << pos
+				}
+			}
+		}
+		agariTable = table
+	})
+	return agariTable
+}
+
+// suitCode 将一种数牌的9个张数编码为 uint32
+func suitCode(counts *[9]uint8) uint32 {
+	var code uint32
+	for i := 8; i >= 0; i-- {
+		code = code<<3 | uint32(counts[i])
+	}
+	return code
+}
+
+// honorAgari 返回字牌的查表值，含义与 agariTable 相同
+// 字牌只能组成刻子或雀头，张数全部为0、2或3且最多一个2时本身成立
+func honorAgari(counts *[NBaseTiles]uint8) uint16 {
+	pairs, other := 0, 0
+	for tile := _1z; tile <= _7z; tile++ {
+		switch counts[tile] {
+		case 0, 3:
+		case 2:
+			pairs++
+		default:
+			other++
+		}
+	}
+	var value uint16
+	if other == 0 && pairs <= 1 {
+		value |= agariComplete
+	}
+	for i := 0; i < 7; i++ {
+		switch counts[_1z+BaseTile(i)] {
+		case 1: // 成为雀头
+			if other == 1 && pairs == 0 {
+				value |= 1 << i
+			}
+		case 2: // 成为刻子，其余最多一个雀头
+			if other == 0 && pairs <= 2 {
+				value |= 1 << i
+			}
+		}
+	}
+	return value
+}
+
+// agariValues 返回四类牌（万、筒、索、字）的查表值与张数除以3的余数
+func (h *Hand34) agariValues() (values [4]uint16, mods [4]int) {
+	table := getAgariTable()
+	for suit := 0; suit < 3; suit++ {
+		counts := (*[9]uint8)(h.Counts[suit*9 : suit*9+9])
+		sum := 0
+		for _, c := range counts {
+			sum += int(c)
+		}
+		values[suit] = table[suitCode(counts)]
+		mods[suit] = sum % 3
+	}
+	sum := 0
+	for _, c := range h.Counts[_1z:] {
+		sum += int(c)
+	}
+	values[3] = honorAgari(&h.Counts)
+	mods[3] = sum % 3
+	return values, mods
+}
+
+// IsAgari 判断是否为和牌形（3n+2张），14张时包含七对子与国士无双，不分配内存
+func (h *Hand34) IsAgari() bool {
+	n := h.Len()
+	if n%3 != 2 {
+		return false
+	}
+	if n == 14 && (h.ChiitoiShanten() == -1 || h.KokushiShanten() == -1) {
+		return true
+	}
+	values, mods := h.agariValues()
+	pairs := 0
+	for suit := range values {
+		if values[suit]&agariComplete == 0 {
+			return false
+		}
+		if mods[suit] == 2 {
+			pairs++
+		}
+	}
+	return pairs == 1
+}
+
+// AgariMask 返回加入后成为和牌形的牌（手牌为 3n+1 张时有效），第 i 位表示 BaseTile(i)
+// 13张时包含七对子与国士无双的听牌；已有4张的牌不算听牌。不分配内存
+func (h *Hand34) AgariMask() uint64 {
+	n := h.Len()
+	if n%3 != 1 {
+		return 0
+	}
+	mask := h.normalAgariMask()
+	if n == 13 {
+		if h.ChiitoiShanten() == 0 {
+			for tile := range h.Counts {
+				if h.Counts[tile] == 1 {
+					mask |= 1 << tile
+				}
+			}
+		}
+		if h.KokushiShanten() == 0 {
+			missing := false
+			for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+				if IsYaochuhai(tile) && h.Counts[tile] == 0 {
+					mask |= 1 << tile
+					missing = true
+				}
+			}
+			if !missing { // 十三面
+				for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+					if IsYaochuhai(tile) {
+						mask |= 1 << tile
+					}
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// normalAgariMask 返回加入后成为一般形和牌形的牌（手牌为 3n+1 张时有效），已有4张的牌不算
+func (h *Hand34) normalAgariMask() uint64 {
+	if h.Len()%3 != 1 {
+		return 0
+	}
+	var mask uint64
+	values, mods := h.agariValues()
+	for suit := range values {
+		// 其余三类必须本身成立，且加入后整手牌只有一个雀头
+		pairs, ok := 0, true
+		for other := range values {
+			if other == suit {
+				continue
+			}
+			if values[other]&agariComplete == 0 {
+				ok = false
+				break
+			}
+			if mods[other] == 2 {
+				pairs++
+			}
+		}
+		if ok && ((mods[suit] == 1 && pairs == 0) || (mods[suit] == 2 && pairs == 1)) {
+			mask |= uint64(values[suit]&(agariComplete-1)) << (9 * suit)
+		}
+	}
+	return mask
+}
+
+// AppendAgariTiles 将 AgariMask 中的牌按顺序追加到 dst 并返回
+func (h *Hand34) AppendAgariTiles(dst []BaseTile) []BaseTile {
+	for mask := h.AgariMask(); mask != 0; mask &= mask - 1 {
+		dst = append(dst, BaseTile(bits.TrailingZeros64(mask)))
+	}
+	return dst
+}
@@ -0,0 +1,118 @@
+package mahjong
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// splitterAgari 用 TileSplitter 判断和牌，作为查表的对照
+func splitterAgari(h Hand34) bool {
+	tiles := h.BaseTiles()
+	if IsSevenPairPattern(tiles) || IsKokushiPattern(tiles) {
+		return true
+	}
+	return len(NewTileSplitter().GetAllCompletedTiles(tiles)) > 0
+}
+
+func TestAgariTableMatchesSplitter(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 3000; i++ {
+		n := []int{2, 5, 8, 11, 14}[i%5]
+		h := randomHand34(rng, n, i%3 != 0)
+		if got, want := h.IsAgari(), splitterAgari(h); got != want {
+			t.Fatalf("%s: IsAgari %v, expected %v", h.String(), got, want)
+		}
+
+		h = randomHand34(rng, n-1, i%3 != 0)
+		var want []BaseTile
+		for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+			if h.Counts[tile] == 4 {
+				continue
+			}
+			h.Add(tile)
+			if splitterAgari(h) {
+				want = append(want, tile)
+			}
+			h.Remove(tile)
+		}
+		if got := h.AppendAgariTiles(nil); !slices.Equal(got, want) {
+			t.Fatalf("%s: agari tiles %v, expected %v", h.String(), got, want)
+		}
+	}
+}
+
+func TestAgariSpecialHands(t *testing.T) {
+	for _, c := range []struct {
+		hand  string
+		agari bool
+	}{
+		{"11m11p11s11z", false}, // 四个雀头
+		{"112233z", false},
+		{"111222z33z", true},
+		{"1122m3344p5566s77z", true},
+		{"1111m2233p4455s66z", false}, // 七对子不能有相同的两对
+		{"19m19p19s12345677z", true},
+		{"11123444m", true},
+		{"12m", false},
+	} {
+		h := mustHand34(t, c.hand)
+		if h.IsAgari() != c.agari {
+			t.Fatalf("%s: IsAgari should be %v", c.hand, c.agari)
+		}
+	}
+
+	for _, c := range []struct {
+		hand string
+		want string
+	}{
+		{"19m19p19s1234567z", "19m19p19s1234567z"}, // 十三面
+		{"119m9p19s1234567z", "1p"},
+		{"1122m3344p5566s7z", "7z"},
+		{"1111234m", "4m"}, // 第5张1m不算听牌
+		{"11z22z", "12z"},
+		{"11123456789999m", ""}, // 14张不是 3n+1
+	} {
+		h := mustHand34(t, c.hand)
+		want := mustBaseTiles(t, c.want)
+		if got := h.AppendAgariTiles(nil); !slices.Equal(got, want) {
+			t.Fatalf("%s: agari tiles %v, expected %v", c.hand, got, want)
+		}
+	}
+}
+
+func TestAgariNoAllocs(t *testing.T) {
+	h := mustHand34(t, "1112345678999m")
+	buf := make([]BaseTile, 0, NBaseTiles)
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Add(_5m)
+		if !h.IsAgari() {
+			t.Fatalf("chuuren should be complete")
+		}
+		h.Remove(_5m)
+		buf = h.AppendAgariTiles(buf[:0])
+	})
+	if allocs != 0 || len(buf) != 9 {
+		t.Fatalf("%v allocations, %d agari tiles", allocs, len(buf))
+	}
+}
+
+func BenchmarkAgariTiles(b *testing.B) {
+	rng := rand.New(rand.NewSource(6))
+	hands := make([]Hand34, 64)
+	for i := range hands {
+		hands[i] = randomHand34(rng, 13, true)
+	}
+	b.Run("AgariMask", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			h.AgariMask()
+		}
+	})
+	b.Run("TileSplitter", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			FindAllWinTiles(h.BaseTiles())
+		}
+	})
+}
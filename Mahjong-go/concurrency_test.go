@@ -0,0 +1,102 @@
+package mahjong
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// 并发压力测试，建议配合 go test -race 运行
+
+func TestSelfPlayWorkerCountInvariant(t *testing.T) {
+	games := 24
+	if testing.Short() {
+		games = 8
+	}
+	run := func(workers int) map[int]string {
+		logs := make(map[int]string)
+		config := SelfPlayConfig{Games: games, Seed: 99, Workers: workers}
+		err := RunSelfPlay(context.Background(), config, SelfPlaySinkFunc(func(r *SelfPlayResult) error {
+			logs[r.Game] = fmt.Sprintf("%v\n%s", r.Payoffs, r.Log)
+			return nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return logs
+	}
+	serial := run(1)
+	parallel := run(8)
+	for game := 0; game < games; game++ {
+		if serial[game] != parallel[game] {
+			t.Fatalf("game %d differs between 1 and 8 workers", game)
+		}
+	}
+}
+
+func TestVecEnvParallelMatchesSerial(t *testing.T) {
+	run := func(workers int) []int16 {
+		v := NewVecEnv(8, 5)
+		v.Workers = workers
+		v.Reset()
+		rng := rand.New(rand.NewSource(5))
+		actions := make([]int, v.Len())
+		for step := 0; step < 150; step++ {
+			randomVecActions(v, rng, actions)
+			if err := v.Step(actions); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return slices.Clone(v.Obs)
+	}
+	if !slices.Equal(run(1), run(4)) {
+		t.Fatalf("parallel stepping should match serial stepping")
+	}
+}
+
+func TestConcurrentAnalysis(t *testing.T) {
+	hand := []*Tile{
+		{Tile: _1m}, {Tile: _1m}, {Tile: _2m}, {Tile: _3m}, {Tile: _4m},
+		{Tile: _1p}, {Tile: _1p}, {Tile: _1p}, {Tile: _2s}, {Tile: _3s},
+		{Tile: _4s}, {Tile: _5s}, {Tile: _6s}, {Tile: _7s},
+	}
+	tiles := make([]BaseTile, len(hand))
+	for i, tile := range hand {
+		tiles[i] = tile.Tile
+	}
+	const wantSyanten = 0 // 和牌
+	wantSplits := len(NewTileSplitter().GetAllCompletedTiles(tiles))
+
+	profiler := NewProfiler()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			name := fmt.Sprintf("worker%d", g)
+			for i := 0; i < 200; i++ {
+				profiler.Begin(name)
+				if got := CalculateRoundToWin(hand, 0); got != wantSyanten {
+					t.Errorf("syanten %d, expected %d", got, wantSyanten)
+				}
+				if got := len(NewTileSplitter().GetAllCompletedTiles(tiles)); got != wantSplits {
+					t.Errorf("%d decompositions, expected %d", got, wantSplits)
+				}
+				if !CanWinWithTiles(tiles) {
+					t.Errorf("hand should be complete")
+				}
+				profiler.End(name)
+				GetProfiler().GetAllEvents()
+			}
+		}(g)
+	}
+	wg.Wait()
+	for g := 0; g < 8; g++ {
+		if got := profiler.GetCallCount(fmt.Sprintf("worker%d", g)); got != 200 {
+			t.Fatalf("worker %d recorded %d events", g, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package mahjong
+
+import "sort"
+
+// 防守分析
+// 只使用视角玩家能看到的信息：自己的手牌、各家的河（RiverTileView.Riichi 标记立直后的弃牌）、副露与宝牌指示牌
+// 危险度是假设对手已听牌时放铳的大致相对概率（约为百分数），用于比较手中各张牌，不是精确的放铳率
+
+// earlyDiscards 算作早巡的弃牌张数（早外）
+const earlyDiscards = 6
+
+// TileSafety 一张牌对一家对手的安全信息
+type TileSafety struct {
+	Genbutsu     bool    // 现物：对手自己打过，或对手立直后他家打出过（被鸣走的也算）
+	TempGenbutsu bool    // 临时现物：对手上次打牌之后他家打出过，本巡内不能荣和（同巡振听）
+	Suji         bool    // 筋：能听这张牌的两面形所需的筋牌都是现物
+	HalfSuji     bool    // 半筋：4~6 只有一侧的筋牌是现物
+	NoChance     bool    // 壁（No Chance）：组成两面的牌有一种已见4张，对手不可能以两面听这张牌
+	OneChance    bool    // One Chance：组成两面的牌有一种已见3张
+	EarlyOutside bool    // 早外：对手早巡手切过内侧相邻的牌（如早切2m时的1m）
+	Visible      int     // 包括自己手牌在内的已见张数
+	Danger       float64 // 危险度
+}
+
+// TileDanger 手中一种牌的危险度
+type TileDanger struct {
+	Tile      BaseTile
+	Opponents [NPlayers]TileSafety // 下标为座位，自己的座位为空
+	Danger    float64              // 各家危险度的最大值
+}
+
+// AnalyzeDanger 分析视角玩家手中每种牌对各家的危险度，按牌从小到大排列
+// 危险度假设各家都已听牌，按听牌概率加权见 TileDanger.Risk
+func AnalyzeDanger(view *PlayerView) []TileDanger {
+	visible := visibleCounts(view)
+
+	var kinds []BaseTile
+	for _, tile := range view.Hand {
+		if !IsIn(kinds, tile.Tile) {
+			kinds = append(kinds, tile.Tile)
+		}
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	result := make([]TileDanger, len(kinds))
+	for i, tile := range kinds {
+		result[i].Tile = tile
+	}
+	for opponent := range view.Seats {
+		if opponent == view.Seat {
+			continue
+		}
+		safe := newOpponentSafety(view, opponent)
+		for i := range result {
+			info := safe.analyze(result[i].Tile, &visible)
+			result[i].Opponents[opponent] = info
+			result[i].Danger = max(result[i].Danger, info.Danger)
+		}
+	}
+	return result
+}
+
+// visibleCounts 统计视角中能看到的各种牌的张数
+// 被鸣走的牌只在副露中计数，宝牌指示牌只计已翻开的
+func visibleCounts(view *PlayerView) [NBaseTiles]int {
+	var counts [NBaseTiles]int
+	for _, tile := range view.Hand {
+		counts[tile.Tile]++
+	}
+	for _, seat := range view.Seats {
+		for _, r := range seat.River {
+			if r.Remain {
+				counts[r.Tile.Tile]++
+			}
+		}
+		for _, cg := range seat.CallGroups {
+			for _, tile := range cg.Tiles {
+				counts[tile]++
+			}
+		}
+	}
+	for _, tile := range view.DoraIndicators {
+		counts[tile.Tile]++
+	}
+	return counts
+}
+
+// opponentSafety 从一家对手的角度整理出的现物等信息
+type opponentSafety struct {
+	genbutsu     [NBaseTiles]bool
+	tempGenbutsu [NBaseTiles]bool
+	earlyOutside [NBaseTiles]bool
+}
+
+func newOpponentSafety(view *PlayerView, opponent int) *opponentSafety {
+	s := &opponentSafety{}
+	river := view.Seats[opponent].River
+	riichiNumber, lastNumber := -1, -1
+	for i, r := range river {
+		s.genbutsu[r.Tile.Tile] = true
+		if r.Riichi && riichiNumber < 0 {
+			riichiNumber = r.Number
+		}
+		lastNumber = r.Number
+		if i < earlyDiscards && r.FromHand {
+			for _, tile := range outsideTiles(r.Tile.Tile) {
+				s.earlyOutside[tile] = true
+			}
+		}
+	}
+	for seat := range view.Seats {
+		if seat == opponent {
+			continue
+		}
+		for _, r := range view.Seats[seat].River {
+			switch {
+			case riichiNumber >= 0 && r.Number > riichiNumber:
+				s.genbutsu[r.Tile.Tile] = true // 立直后见逃即立直振听
+			case r.Number > lastNumber:
+				s.tempGenbutsu[r.Tile.Tile] = true
+			}
+		}
+	}
+	return s
+}
+
+// outsideTiles 返回数牌外侧（靠近幺九一侧）的两张牌，5和字牌没有外侧
+func outsideTiles(tile BaseTile) []BaseTile {
+	if IsTsuhai(tile) {
+		return nil
+	}
+	rank := int(tile) % 9
+	var result []BaseTile
+	for d := 1; d <= 2; d++ {
+		switch {
+		case rank < 4 && rank-d >= 0:
+			result = append(result, tile-BaseTile(d))
+		case rank > 4 && rank+d <= 8:
+			result = append(result, tile+BaseTile(d))
+		}
+	}
+	return result
+}
+
+func (s *opponentSafety) analyze(tile BaseTile, visible *[NBaseTiles]int) TileSafety {
+	info := TileSafety{
+		Genbutsu:     s.genbutsu[tile],
+		TempGenbutsu: s.tempGenbutsu[tile] && !s.genbutsu[tile],
+		EarlyOutside: s.earlyOutside[tile],
+		Visible:      visible[tile],
+	}
+	if !IsTsuhai(tile) {
+		// 能听这张牌的两面形：下侧为 (r-2, r-1) 筋牌 r-3，上侧为 (r+1, r+2) 筋牌 r+3
+		rank := int(tile) % 9
+		sides, safeSides, noChance, oneChance := 0, 0, 0, 0
+		for _, d := range []int{-1, 1} {
+			if rank+3*d < 0 || rank+3*d > 8 {
+				continue
+			}
+			sides++
+			if s.genbutsu[int(tile)+3*d] {
+				safeSides++
+			}
+			blocked := max(visible[int(tile)+d], visible[int(tile)+2*d])
+			if blocked >= 4 {
+				noChance++
+			} else if blocked == 3 {
+				oneChance++
+			}
+		}
+		info.Suji = safeSides == sides
+		info.HalfSuji = sides == 2 && safeSides == 1
+		info.NoChance = noChance == sides
+		info.OneChance = !info.NoChance && noChance+oneChance == sides
+	}
+	info.Danger = info.danger(tile)
+	return info
+}
+
+// danger 由各项信息估计危险度
+func (info *TileSafety) danger(tile BaseTile) float64 {
+	if info.Genbutsu || info.TempGenbutsu {
+		return 0
+	}
+	var d float64
+	if IsTsuhai(tile) {
+		// 字牌只能单骑或双碰，已见越多越安全
+		d = []float64{6, 3.5, 1.5, 0.5, 0}[min(info.Visible, 4)]
+		return d
+	}
+	rank := int(tile)%9 + 1
+	if rank > 5 {
+		rank = 10 - rank
+	}
+	switch {
+	case info.Suji:
+		d = []float64{0, 1.5, 3, 5, 4, 4}[rank] // 筋的1/9只剩单骑、双碰
+	case info.HalfSuji:
+		d = 8
+	default:
+		d = []float64{0, 6, 8, 9, 12, 12}[rank]
+	}
+	switch {
+	case info.NoChance:
+		d *= 0.3
+	case info.OneChance:
+		d *= 0.6
+	}
+	if info.EarlyOutside {
+		d *= 0.8
+	}
+	return d
+}
@@ -0,0 +1,138 @@
+package mahjong
+
+import "testing"
+
+// dangerTable 按打牌顺序把弃牌放进各家的河，riichi 为立直宣言牌的序号（-1 表示不立直）
+func dangerTable(t *testing.T, hand string, discards []struct {
+	seat int
+	tile string
+}, riichi int) *Table {
+	t.Helper()
+	table := NewTable()
+	table.DoraIndicator = nil
+	table.NActiveDora = 0
+	tiles, err := ParseTiles(hand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Players[0].Hand = tiles
+	for i, d := range discards {
+		tile := mustBaseTiles(t, d.tile)[0]
+		river := &table.Players[d.seat].River
+		river.PushBack(RiverTile{
+			Tile:     &Tile{Tile: tile, ID: 100 + i},
+			Number:   i,
+			Riichi:   riichi >= 0 && i >= riichi && d.seat == discards[riichi].seat,
+			Remain:   true,
+			FromHand: true,
+		})
+	}
+	if riichi >= 0 {
+		table.Players[discards[riichi].seat].Riichi = true
+	}
+	return table
+}
+
+func findDanger(t *testing.T, report []TileDanger, tile string) TileDanger {
+	t.Helper()
+	base := mustBaseTiles(t, tile)[0]
+	for _, d := range report {
+		if d.Tile == base {
+			return d
+		}
+	}
+	t.Fatalf("%s not in report", tile)
+	return TileDanger{}
+}
+
+func TestAnalyzeDangerGenbutsuAndSuji(t *testing.T) {
+	discards := []struct {
+		seat int
+		tile string
+	}{
+		{1, "1z"}, {2, "9s"}, {3, "2z"},
+		{1, "4m"}, {2, "8s"}, {3, "3z"}, // 1家以4m立直
+		{1, "7p"}, {2, "6p"}, {3, "1m"},
+	}
+	table := dangerTable(t, "145679m56p6s777z", discards, 3)
+	report := AnalyzeDanger(NewPlayerView(table, 0))
+
+	for _, c := range []struct {
+		tile                     string
+		genbutsu, suji, halfSuji bool
+	}{
+		{"4m", true, false, true},  // 自己打过，1m 也是现物
+		{"1m", true, true, false},  // 立直后他家打过，也是 4m 的筋
+		{"7m", false, true, false}, // 4m 的筋
+		{"6p", true, false, false}, // 立直后他家打过
+		{"5m", false, false, false},
+		{"9m", false, false, false},
+	} {
+		s := findDanger(t, report, c.tile).Opponents[1]
+		if s.Genbutsu != c.genbutsu || s.Suji != c.suji || s.HalfSuji != c.halfSuji {
+			t.Fatalf("%s against riichi: %+v", c.tile, s)
+		}
+		if s.Genbutsu && s.Danger != 0 {
+			t.Fatalf("genbutsu %s should be safe: %+v", c.tile, s)
+		}
+	}
+	if s := findDanger(t, report, "7m").Opponents[1]; s.Danger >= findDanger(t, report, "5m").Opponents[1].Danger {
+		t.Fatalf("suji 7m should be safer than 5m")
+	}
+	// 对2家而言 9s 是现物但 3s 不是，6s 为半筋
+	if s := findDanger(t, report, "6s").Opponents[2]; !s.HalfSuji || s.Suji {
+		t.Fatalf("6s against seat 2 should be half suji: %+v", s)
+	}
+	// 2家打 6p 之后3家打了 1m，对2家是临时现物
+	if s := findDanger(t, report, "1m").Opponents[2]; !s.TempGenbutsu || s.Danger != 0 {
+		t.Fatalf("1m against seat 2 should be a temporary genbutsu: %+v", s)
+	}
+	if s := findDanger(t, report, "1m").Opponents[3]; s.Genbutsu != true {
+		t.Fatalf("1m is seat 3's own discard: %+v", s)
+	}
+	for _, d := range report {
+		if d.Opponents[0] != (TileSafety{}) {
+			t.Fatalf("own seat should be empty: %+v", d)
+		}
+		want := 0.0
+		for _, s := range d.Opponents {
+			want = max(want, s.Danger)
+		}
+		if d.Danger != want {
+			t.Fatalf("%s: overall danger %v, expected max %v", BaseTileToString(d.Tile), d.Danger, want)
+		}
+	}
+}
+
+func TestAnalyzeDangerKabeAndHonors(t *testing.T) {
+	discards := []struct {
+		seat int
+		tile string
+	}{
+		{1, "2z"}, {2, "8p"}, {3, "8p"}, {1, "8p"},
+	}
+	// 自己有一张8p，河里3张：8p 已见4张，9p 是 No Chance；7z 已见3张
+	table := dangerTable(t, "8p9p7p777z1s2m", discards, -1)
+	report := AnalyzeDanger(NewPlayerView(table, 0))
+	if s := findDanger(t, report, "9p").Opponents[2]; !s.NoChance || s.OneChance {
+		t.Fatalf("9p should be no chance: %+v", s)
+	}
+	// 7p 只能由 56p 两面听（89p 是边张），不受 8p 的壁影响
+	if s := findDanger(t, report, "7p").Opponents[2]; s.NoChance || s.OneChance {
+		t.Fatalf("7p is open on the lower side: %+v", s)
+	}
+	if s := findDanger(t, report, "7z").Opponents[2]; s.Visible != 3 || s.Danger >= findDanger(t, report, "1s").Opponents[2].Danger {
+		t.Fatalf("7z with 3 visible should be nearly safe: %+v", s)
+	}
+	// 1家早巡手切 2z 不影响数牌；2家早巡手切 8p，9p 为早外
+	if s := findDanger(t, report, "9p").Opponents[2]; !s.EarlyOutside {
+		t.Fatalf("9p should be outside seat 2's early 8p: %+v", s)
+	}
+
+	// 被鸣走的牌只在副露中计一次
+	table.Players[3].River.River[0].Remain = false
+	table.Players[0].CallGroups = []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_8p, _8p, _8p}, IsOpen: true}}
+	if visible := visibleCounts(NewPlayerView(table, 0)); visible[_8p] != 6 {
+		t.Fatalf("expected 8p counted 6 times in this synthetic table, got %d", visible[_8p])
+	}
+}
@@ -0,0 +1,183 @@
+package mahjong
+
+import "sync"
+
+// 基于张数的拆分
+// 手牌按万、筒、索、字分成四类，每类的面子拆分只取决于这一类的张数，按类缓存（编码与 HandToCode 相同）
+// 整手牌的拆分是各类拆分的组合，顺序与原来的递归拆分相同：
+// 雀头从小到大，面子从最小的牌开始、先刻子后顺子
+// 返回的拆分是共享的只读数据，不能修改；需要修改时使用 TileSplitter.GetAllCompletedTiles 得到副本
+
+// decomposeCacheSize 整手牌拆分结果的缓存上限，超过后清空重新缓存
+const decomposeCacheSize = 1 << 14
+
+// 所有拆分共用的牌组（Tiles 指向同一份只读数组）
+var (
+	internedToitsu  [NBaseTiles]TileGroup
+	internedKoutsu  [NBaseTiles]TileGroup
+	internedShuntsu [NBaseTiles]TileGroup // 只有能作为顺子第一张的牌有效
+)
+
+func init() {
+	for i := 0; i < NBaseTiles; i++ {
+		tile := BaseTile(i)
+		internedToitsu[i] = TileGroup{Type: Toitsu, Tiles: []BaseTile{tile, tile}}
+		internedKoutsu[i] = TileGroup{Type: Koutsu, Tiles: []BaseTile{tile, tile, tile}}
+		if canStartShuntsu(i) {
+			internedShuntsu[i] = TileGroup{Type: Shuntsu, Tiles: []BaseTile{tile, tile + 1, tile + 2}}
+		}
+	}
+}
+
+// decomposer 缓存各类牌的面子拆分与整手牌的拆分，可以在多个 goroutine 中同时使用
+type decomposer struct {
+	mu    sync.RWMutex
+	suits map[uint32][][]TileGroup               // 能拆分的形，key 为 类别<<27 | 编码
+	hands map[[NBaseTiles]uint8][]CompletedTiles // 整手牌的拆分
+}
+
+var defaultDecomposer = &decomposer{
+	suits: make(map[uint32][][]TileGroup),
+	hands: make(map[[NBaseTiles]uint8][]CompletedTiles),
+}
+
+// DecomposeTiles 返回一般形的所有拆分，与 TileSplitter.GetAllCompletedTiles 的结果及顺序相同
+// 结果是共享的只读数据，不能修改
+func DecomposeTiles(tiles []BaseTile) []CompletedTiles {
+	h := NewHand34FromBaseTiles(tiles)
+	return h.Decompose()
+}
+
+// Decompose 返回一般形的所有拆分，与 TileSplitter.GetAllCompletedTiles 的结果及顺序相同
+// 结果是共享的只读数据，不能修改
+func (h *Hand34) Decompose() []CompletedTiles {
+	return defaultDecomposer.decompose(&h.Counts)
+}
+
+func (d *decomposer) decompose(counts *[NBaseTiles]uint8) []CompletedTiles {
+	d.mu.RLock()
+	result, ok := d.hands[*counts]
+	d.mu.RUnlock()
+	if ok {
+		return result
+	}
+
+	n := 0
+	for _, c := range counts {
+		n += int(c)
+	}
+	switch {
+	case n == 0:
+		result = []CompletedTiles{{}}
+	case n%3 == 0:
+		result = d.combine(counts, TileGroup{})
+	case n%3 == 2:
+		work := *counts
+		for tile := range work {
+			if work[tile] >= 2 {
+				work[tile] -= 2
+				result = append(result, d.combine(&work, internedToitsu[tile])...)
+				work[tile] += 2
+			}
+		}
+	}
+
+	d.mu.Lock()
+	if len(d.hands) >= decomposeCacheSize {
+		clear(d.hands)
+	}
+	d.hands[*counts] = result
+	d.mu.Unlock()
+	return result
+}
+
+// combine 组合四类牌的面子拆分，counts 中已去掉雀头
+func (d *decomposer) combine(counts *[NBaseTiles]uint8, head TileGroup) []CompletedTiles {
+	var parts [4][][]TileGroup
+	total, groups := 1, 0
+	for suit := range parts {
+		parts[suit] = d.suit(suit, counts)
+		if len(parts[suit]) == 0 {
+			return nil
+		}
+		total *= len(parts[suit])
+		groups += len(parts[suit][0])
+	}
+
+	// 万为最外层，依次组合
+	result := make([]CompletedTiles, total)
+	bodies := make([]TileGroup, total*groups)
+	var index [4]int
+	for i := range result {
+		body := bodies[i*groups : i*groups : (i+1)*groups]
+		for suit := range parts {
+			body = append(body, parts[suit][index[suit]]...)
+		}
+		result[i] = CompletedTiles{Head: head, Body: body}
+		for suit := 3; suit >= 0; suit-- {
+			if index[suit]++; index[suit] < len(parts[suit]) {
+				break
+			}
+			index[suit] = 0
+		}
+	}
+	return result
+}
+
+// suit 返回一类牌全部拆成面子的所有方式（按递归拆分的顺序），不能拆分时返回 nil
+func (d *decomposer) suit(suit int, counts *[NBaseTiles]uint8) [][]TileGroup {
+	size := 9
+	if suit == 3 {
+		size = 7
+	}
+	var local [9]uint8
+	copy(local[:], counts[suit*9:suit*9+size])
+	key := uint32(suit)<<27 | suitCode(&local)
+
+	d.mu.RLock()
+	result, ok := d.suits[key]
+	d.mu.RUnlock()
+	if ok {
+		return result
+	}
+
+	var stack []TileGroup
+	var search func(pos int)
+	search = func(pos int) {
+		for pos < size && local[pos] == 0 {
+			pos++
+		}
+		if pos == size {
+			result = append(result, append([]TileGroup(nil), stack...))
+			return
+		}
+		tile := suit*9 + pos
+		if local[pos] >= 3 {
+			local[pos] -= 3
+			stack = append(stack, internedKoutsu[tile])
+			search(pos)
+			stack = stack[:len(stack)-1]
+			local[pos] += 3
+		}
+		if canStartShuntsu(tile) && local[pos+1] > 0 && local[pos+2] > 0 {
+			local[pos]--
+			local[pos+1]--
+			local[pos+2]--
+			stack = append(stack, internedShuntsu[tile])
+			search(pos)
+			stack = stack[:len(stack)-1]
+			local[pos]++
+			local[pos+1]++
+			local[pos+2]++
+		}
+	}
+	search(0)
+
+	// 只缓存能拆分的形（数量有限），不能拆分的形搜索很快就会结束
+	if result != nil {
+		d.mu.Lock()
+		d.suits[key] = result
+		d.mu.Unlock()
+	}
+	return result
+}
@@ -0,0 +1,415 @@
+package mahjong
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// referenceSplit 原来基于切片的递归拆分，作为对照
+func referenceSplit(tiles []BaseTile) []CompletedTiles {
+	sorted := slices.Clone(tiles)
+	slices.Sort(sorted)
+	if len(sorted) == 0 {
+		return []CompletedTiles{{}}
+	}
+	var result []CompletedTiles
+	var body []TileGroup
+	var search func(head TileGroup, rest []BaseTile)
+	search = func(head TileGroup, rest []BaseTile) {
+		if len(rest) == 0 {
+			result = append(result, CompletedTiles{Head: head, Body: slices.Clone(body)})
+			return
+		}
+		tile := rest[0]
+		if len(rest) >= 3 && rest[1] == tile && rest[2] == tile {
+			body = append(body, TileGroup{Type: Koutsu, Tiles: []BaseTile{tile, tile, tile}})
+			search(head, rest[3:])
+			body = body[:len(body)-1]
+		}
+		if canStartShuntsu(int(tile)) && slices.Contains(rest, tile+1) && slices.Contains(rest, tile+2) {
+			next := slices.Clone(rest[1:])
+			next = slices.Delete(next, slices.Index(next, tile+1), slices.Index(next, tile+1)+1)
+			next = slices.Delete(next, slices.Index(next, tile+2), slices.Index(next, tile+2)+1)
+			body = append(body, TileGroup{Type: Shuntsu, Tiles: []BaseTile{tile, tile + 1, tile + 2}})
+			search(head, next)
+			body = body[:len(body)-1]
+		}
+	}
+	if len(sorted)%3 != 2 {
+		search(TileGroup{}, sorted)
+		return result
+	}
+	for i := 0; i+1 < len(sorted); i++ {
+		if sorted[i] != sorted[i+1] || (i > 0 && sorted[i-1] == sorted[i]) {
+			continue
+		}
+		tile := sorted[i]
+		rest := append(slices.Clone(sorted[:i]), sorted[i+2:]...)
+		search(TileGroup{Type: Toitsu, Tiles: []BaseTile{tile, tile}}, rest)
+	}
+	return result
+}
+
+func sameDecompositions(t *testing.T, h Hand34) {
+	t.Helper()
+	got, want := h.Decompose(), referenceSplit(h.BaseTiles())
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d decompositions, expected %d", h.String(), len(got), len(want))
+	}
+	for i := range want {
+		if got[i].String() != want[i].String() || got[i].Head.Type != want[i].Head.Type {
+			t.Fatalf("%s: decomposition %d is %s, expected %s", h.String(), i, got[i].String(), want[i].String())
+		}
+		for j := range want[i].Body {
+			if got[i].Body[j].Type != want[i].Body[j].Type {
+				t.Fatalf("%s: group %d of decomposition %d has type %v", h.String(), j, i, got[i].Body[j].Type)
+			}
+		}
+	}
+}
+
+// forEachCompleteHand 枚举由 tiles 中的牌组成的、不超过 maxMentsu 个面子加一个雀头的所有和牌形（每种牌最多4张）
+func forEachCompleteHand(tiles []BaseTile, maxMentsu int, f func(Hand34)) {
+	seen := make(map[Hand34]bool)
+	var h Hand34
+	visit := func() {
+		for _, pair := range tiles {
+			if h.Counts[pair] <= 2 {
+				h.Counts[pair] += 2
+				if !seen[h] {
+					seen[h] = true
+					f(h)
+				}
+				h.Counts[pair] -= 2
+			}
+		}
+	}
+	var search func(start, mentsu int)
+	search = func(start, mentsu int) {
+		visit()
+		if mentsu == maxMentsu {
+			return
+		}
+		for i := start; i < 2*len(tiles); i++ {
+			tile := tiles[i/2]
+			if i%2 == 0 {
+				if h.Counts[tile] <= 1 {
+					h.Counts[tile] += 3
+					search(i, mentsu+1)
+					h.Counts[tile] -= 3
+				}
+				continue
+			}
+			if !canStartShuntsu(int(tile)) || h.Counts[tile] == 4 || h.Counts[tile+1] == 4 || h.Counts[tile+2] == 4 {
+				continue
+			}
+			h.Counts[tile]++
+			h.Counts[tile+1]++
+			h.Counts[tile+2]++
+			search(i, mentsu+1)
+			h.Counts[tile]--
+			h.Counts[tile+1]--
+			h.Counts[tile+2]--
+		}
+	}
+	search(0, 0)
+}
+
+// suitShape 一种花色能拆完的张数形：mentsu 个面子与 pairs 个雀头
+type suitShape struct {
+	counts []uint8
+	mentsu int
+	pairs  int
+}
+
+// completeSuitShapes 返回 n 种牌能拆成不超过4个面子加至多一个雀头的所有张数形（每种牌最多4张），按张数的字典序排列
+// sequences 为 false 时没有顺子（字牌）
+func completeSuitShapes(n int, sequences bool) []suitShape {
+	seen := make(map[string]bool)
+	var shapes []suitShape
+	counts := make([]uint8, n)
+	var search func(start, mentsu, pairs int)
+	search = func(start, mentsu, pairs int) {
+		if key := string(counts); !seen[key] {
+			seen[key] = true
+			shapes = append(shapes, suitShape{counts: slices.Clone(counts), mentsu: mentsu, pairs: pairs})
+		}
+		if pairs == 0 {
+			for i := range counts {
+				if counts[i] <= 2 {
+					counts[i] += 2
+					search(n, mentsu, 1)
+					counts[i] -= 2
+				}
+			}
+		}
+		if mentsu == 4 {
+			return
+		}
+		// 面子按 (牌, 刻子/顺子) 不减的顺序放入，避免重复
+		for i := start; i < 2*n && i >= 0; i++ {
+			pos := i / 2
+			if i%2 == 0 {
+				if counts[pos] <= 1 {
+					counts[pos] += 3
+					search(i, mentsu+1, pairs)
+					counts[pos] -= 3
+				}
+			} else if sequences && pos+2 < n && counts[pos] < 4 && counts[pos+1] < 4 && counts[pos+2] < 4 {
+				counts[pos]++
+				counts[pos+1]++
+				counts[pos+2]++
+				search(i, mentsu+1, pairs)
+				counts[pos]--
+				counts[pos+1]--
+				counts[pos+2]--
+			}
+		}
+	}
+	search(0, 0, 0)
+	slices.SortFunc(shapes, func(a, b suitShape) int { return slices.Compare(a.counts, b.counts) })
+	return shapes
+}
+
+// complete14GroupSize 快照中每组大约的手牌数
+const complete14GroupSize = 100000
+
+// shapeString 张数形的文字表示，如 "111000000"
+func shapeString(counts []uint8) string {
+	b := make([]byte, len(counts))
+	for i, c := range counts {
+		b[i] = '0' + c
+	}
+	return string(b)
+}
+
+// complete14Snapshot 枚举所有14张一般形和牌形（4个面子加1个雀头，每种牌最多4张），
+// 由各花色与字牌能拆完的张数形组合而成。万子的形按字典序分组，每组一行：
+// 组内第一个万子的形、手牌数、拆分数与各手牌的拆分（按顺序）的 FNV-1a 哈希
+// keep 不为空时只计算 keep 返回 true 的组（其余组的行为空），各组并行计算
+func complete14Snapshot(decompose func(h *Hand34) []CompletedTiles, keep func(group int) bool) []string {
+	suits := completeSuitShapes(9, true)
+	// 按 [面子数][雀头数] 分组，组内保持字典序
+	var bySize, honors [5][2][]suitShape
+	for _, shape := range suits {
+		bySize[shape.mentsu][shape.pairs] = append(bySize[shape.mentsu][shape.pairs], shape)
+	}
+	for _, shape := range completeSuitShapes(7, false) {
+		honors[shape.mentsu][shape.pairs] = append(honors[shape.mentsu][shape.pairs], shape)
+	}
+	forEach := func(mentsu, pairs int, visit func(suitShape)) {
+		for m := 0; m <= mentsu; m++ {
+			for p := 0; p <= pairs; p++ {
+				for _, shape := range bySize[m][p] {
+					visit(shape)
+				}
+			}
+		}
+	}
+	// 万子的形为 (m, p) 时的手牌数
+	handCount := func(mentsu, pairs int) int {
+		n := 0
+		for m2 := 0; m2 <= mentsu; m2++ {
+			for p2 := 0; p2 <= pairs; p2++ {
+				for m3 := 0; m3 <= mentsu-m2; m3++ {
+					for p3 := 0; p3 <= pairs-p2; p3++ {
+						n += len(bySize[m2][p2]) * len(bySize[m3][p3]) * len(honors[mentsu-m2-m3][pairs-p2-p3])
+					}
+				}
+			}
+		}
+		return n
+	}
+	// 按字典序把万子的形依次分组，每组约 complete14GroupSize 手
+	var groups [][]suitShape
+	size := complete14GroupSize
+	for _, shape := range suits {
+		if size >= complete14GroupSize {
+			groups = append(groups, nil)
+			size = 0
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], shape)
+		size += handCount(4-shape.mentsu, 1-shape.pairs)
+	}
+
+	lines := make([]string, len(groups))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for g := range jobs {
+				w := fnv.New64a()
+				hands, decompositions := 0, 0
+				for _, m := range groups[g] {
+					var h Hand34
+					copy(h.Counts[0:9], m.counts)
+					forEach(4-m.mentsu, 1-m.pairs, func(p suitShape) {
+						copy(h.Counts[9:18], p.counts)
+						mentsu, pairs := m.mentsu+p.mentsu, m.pairs+p.pairs
+						forEach(4-mentsu, 1-pairs, func(s suitShape) {
+							copy(h.Counts[18:27], s.counts)
+							for _, z := range honors[4-mentsu-s.mentsu][1-pairs-s.pairs] {
+								copy(h.Counts[27:34], z.counts)
+								result := decompose(&h)
+								hands++
+								decompositions += len(result)
+								buf = hashDecompositions(w, result, buf)
+							}
+						})
+					})
+				}
+				lines[g] = fmt.Sprintf("%s %d %d %016x", shapeString(groups[g][0].counts), hands, decompositions, w.Sum64())
+			}
+		}()
+	}
+	for g := range groups {
+		if keep == nil || keep(g) {
+			jobs <- g
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return lines
+}
+
+// hashDecompositions 把一手牌的全部拆分（按顺序）写入 w
+func hashDecompositions(w hash.Hash64, result []CompletedTiles, buf []byte) []byte {
+	buf = append(buf[:0], byte(len(result)))
+	group := func(g *TileGroup) {
+		buf = append(buf, byte(g.Type), byte(len(g.Tiles)))
+		for _, tile := range g.Tiles {
+			buf = append(buf, byte(tile))
+		}
+	}
+	for i := range result {
+		group(&result[i].Head)
+		buf = append(buf, byte(len(result[i].Body)))
+		for j := range result[i].Body {
+			group(&result[i].Body[j])
+		}
+	}
+	w.Write(buf)
+	return buf
+}
+
+// TestDecomposeExhaustive 所有14张一般形和牌形（11498658种）的拆分与 testdata/decompose_complete14.txt 一致
+// 快照由改为按张数拆分之前的 TileSplitter.GetAllCompletedTiles 生成；-short 时只比较每10组中的1组
+func TestDecomposeExhaustive(t *testing.T) {
+	data, err := os.ReadFile("testdata/decompose_complete14.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Split(strings.TrimSpace(string(data)), "\n")
+	keep := func(group int) bool { return !testing.Short() || group%10 == 1 }
+	got := complete14Snapshot(func(h *Hand34) []CompletedTiles { return h.Decompose() }, keep)
+	if len(got) != len(want) {
+		t.Fatalf("%d groups of complete hands, snapshot has %d", len(got), len(want))
+	}
+	hands := 0
+	for g := range want {
+		if !keep(g) {
+			continue
+		}
+		if got[g] != want[g] {
+			t.Fatalf("group %d of complete hands: got %q, snapshot %q", g, got[g], want[g])
+		}
+		var n int
+		fmt.Sscanf(got[g][10:], "%d", &n)
+		hands += n
+	}
+	t.Logf("compared %d complete hands", hands)
+}
+
+func TestDecomposeMatchesReference(t *testing.T) {
+	// 一种花色的所有和牌形（清一色，拆分方式最多）
+	manzu := []BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m}
+	hands := 0
+	forEachCompleteHand(manzu, 4, func(h Hand34) {
+		hands++
+		sameDecompositions(t, h)
+	})
+	// 所有牌组成的不超过8张的和牌形
+	all := make([]BaseTile, NBaseTiles)
+	for i := range all {
+		all[i] = BaseTile(i)
+	}
+	maxMentsu := 2
+	if testing.Short() {
+		maxMentsu = 1
+	}
+	forEachCompleteHand(all, maxMentsu, func(h Hand34) {
+		hands++
+		sameDecompositions(t, h)
+	})
+	t.Logf("compared %d complete hands", hands)
+}
+
+func TestDecomposeRandomHands(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 2000; i++ {
+		n := []int{0, 3, 12, 13, 14}[i%5]
+		sameDecompositions(t, randomHand34(rng, n, i%4 != 0))
+	}
+
+	// 超过4张的牌也与原来的拆分相同
+	sameDecompositions(t, NewHand34FromBaseTiles([]BaseTile{_1m, _1m, _1m, _1m, _1m}))
+}
+
+func TestDecomposeShared(t *testing.T) {
+	h := mustHand34(t, "11122233344455m")
+	first, second := h.Decompose(), h.Decompose()
+	if len(first) == 0 || &first[0] != &second[0] {
+		t.Fatalf("repeated decompositions should be interned")
+	}
+	// TileSplitter 返回的副本可以修改，不影响共享的结果
+	copied := NewTileSplitter().GetAllCompletedTiles(h.BaseTiles())
+	copied[0].Body[0].Tiles[0] = _9s
+	if first[0].Body[0].Tiles[0] == _9s || internedKoutsu[_1m].Tiles[0] != _1m {
+		t.Fatalf("modifying a copy changed the shared decomposition")
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < 200; i++ {
+				h := randomHand34(rng, 14, true)
+				h.Decompose()
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+}
+
+func BenchmarkDecompose(b *testing.B) {
+	rng := rand.New(rand.NewSource(8))
+	hands := make([]Hand34, 64)
+	for i := range hands {
+		hands[i] = randomHand34(rng, 14, true)
+	}
+	b.Run("Decompose", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			h.Decompose()
+		}
+	})
+	b.Run("Recursive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			referenceSplit(h.BaseTiles())
+		}
+	})
+}
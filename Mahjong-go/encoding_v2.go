@@ -0,0 +1,599 @@
+package mahjong
+
+import "fmt"
+
+// 训练数据编码 V2（与 C++ Mahjong/Encoding/TrainingDataEncodingV2 逐字节一致）
+//
+// 每个玩家有三部分数据：
+//   - SelfInfo：NSelfInfoRows × 34 的牌面信息（手牌、宝牌、风、各家舍牌、可见牌）
+//   - 对局记录：每条日志一行，由牌（含3张赤5）、动作、相对玩家三段 one-hot 拼成
+//   - GlobalInfo：局数、本场、供托、风、点数、一发、剩余牌数
+//
+// 相对位置的约定与 C++ 相同：对玩家 i 而言，玩家 p 是其第 (p-i+4)%4 家（0自家、1下家、2对家、3上家）
+
+// 自身信息的行
+const (
+	SelfInfoHand1 = iota
+	SelfInfoHand2
+	SelfInfoHand3
+	SelfInfoHand4
+	SelfInfoDora1
+	SelfInfoDoraIndicator1
+	SelfInfoAkaDora
+	SelfInfoGameWind
+	SelfInfoSelfWind
+	SelfInfoTsumoTile
+	SelfInfoDiscardedByPlayer1
+	SelfInfoDiscardedByPlayer2
+	SelfInfoDiscardedByPlayer3
+	SelfInfoDiscardedByPlayer4
+	SelfInfoDiscardedNumber1
+	SelfInfoDiscardedNumber2
+	SelfInfoDiscardedNumber3
+	SelfInfoDiscardedNumber4
+
+	// NSelfInfoRows 自身信息的行数
+	NSelfInfoRows
+)
+
+// 对局记录中的动作
+const (
+	RecordDrawNormal = iota
+	RecordDrawRinshan
+	RecordDiscardFromHand
+	RecordDiscardFromTsumo
+	RecordChiLeft
+	RecordChiMiddle
+	RecordChiRight
+	RecordPon
+	RecordKan
+	RecordAnkan
+	RecordKakan
+	RecordRiichiFromHand
+	RecordRiichiFromTsumo
+	RecordRiichiSuccess
+
+	// NRecordActions 动作种类数
+	NRecordActions
+)
+
+// 全局信息的位置
+const (
+	GlobalInfoGameNumber = iota
+	GlobalInfoGameSize
+	GlobalInfoHonba
+	GlobalInfoKyoutaku
+	GlobalInfoSelfWind
+	GlobalInfoGameWind
+	GlobalInfoPlayer0Point // 自家
+	GlobalInfoPlayer1Point // 下家
+	GlobalInfoPlayer2Point // 对家
+	GlobalInfoPlayer3Point // 上家
+	GlobalInfoPlayer0Ippatsu
+	GlobalInfoPlayer1Ippatsu
+	GlobalInfoPlayer2Ippatsu
+	GlobalInfoPlayer3Ippatsu
+	GlobalInfoRemainingTiles
+
+	// NGlobalInfo 全局信息的长度
+	NGlobalInfo
+)
+
+// 对局记录一行的布局
+const (
+	NRecordTiles        = NBaseTiles + 3 // 34种牌与赤5m、赤5p、赤5s
+	RecordOffsetTile    = 0
+	RecordOffsetAction  = RecordOffsetTile + NRecordTiles
+	RecordOffsetPlayer  = RecordOffsetAction + NRecordActions
+	NGameRecord         = RecordOffsetPlayer + NPlayers
+	nVisibleTileEntries = 4 * NBaseTiles
+)
+
+// SelfInfo 自身信息，按行存放
+type SelfInfo [NSelfInfoRows * NBaseTiles]int16
+
+// GameRecord 对局记录中的一行
+type GameRecord [NGameRecord]int16
+
+// GlobalInfo 全局信息
+type GlobalInfo [NGlobalInfo]int16
+
+// locateAttribute 返回 (行, 牌种) 在 SelfInfo 中的下标
+func locateAttribute(row int, tile BaseTile) int {
+	if tile >= NBaseTiles {
+		panic(fmt.Sprintf("Bad access to [%d,%d]", row, tile))
+	}
+	return NBaseTiles*row + int(tile)
+}
+
+// relativePlayer 返回玩家 player 相对于 viewer 的位置
+func relativePlayer(player, viewer int) int {
+	return (player - viewer + NPlayers) % NPlayers
+}
+
+// recordTileIndex 返回牌在对局记录中的下标，赤5排在34种牌之后
+func recordTileIndex(tile *Tile) int {
+	if !tile.RedDora {
+		return int(tile.Tile)
+	}
+	switch tile.Tile {
+	case _5m:
+		return NBaseTiles
+	case _5p:
+		return NBaseTiles + 1
+	case _5s:
+		return NBaseTiles + 2
+	}
+	panic("Bad tile.")
+}
+
+// TableEncoder 从牌桌的日志增量编码四家的训练数据
+// 在 GameStart 之后调用 Init，之后每次需要数据前调用 Update
+type TableEncoder struct {
+	Table       *Table
+	SelfInfos   [NPlayers]SelfInfo
+	Records     [NPlayers][]GameRecord
+	GlobalInfos [NPlayers]GlobalInfo
+
+	visibleTiles      [nVisibleTileEntries]int16
+	visibleTilesCount [NBaseTiles]int16
+	recordCount       int
+	doraRevealed      int // 已编码的宝牌指示牌数
+	skipDoraReveal    int // 已提前编码、之后要跳过的翻开宝牌日志数
+}
+
+// NewTableEncoder 创建编码器
+func NewTableEncoder(t *Table) *TableEncoder {
+	return &TableEncoder{Table: t}
+}
+
+// GetSelfInfo 获取玩家的自身信息
+func (e *TableEncoder) GetSelfInfo(player int) *SelfInfo {
+	return &e.SelfInfos[player]
+}
+
+// GetPlayRecord 获取玩家视角的对局记录
+func (e *TableEncoder) GetPlayRecord(player int) []GameRecord {
+	return e.Records[player]
+}
+
+// GetGlobalInfo 获取玩家的全局信息
+func (e *TableEncoder) GetGlobalInfo(player int) *GlobalInfo {
+	return &e.GlobalInfos[player]
+}
+
+// markVisible 记录一张可见的牌（赤5单独记在第4行且不计数，与 C++ 一致）
+func (e *TableEncoder) markVisible(tile *Tile) {
+	if tile.RedDora {
+		e.visibleTiles[locateAttribute(3, tile.Tile)] = 1
+		return
+	}
+	e.visibleTiles[locateAttribute(int(e.visibleTilesCount[tile.Tile]), tile.Tile)] = 1
+	e.visibleTilesCount[tile.Tile]++
+}
+
+// markAllVisible 某种牌四张全部可见（暗杠、加杠）
+func (e *TableEncoder) markAllVisible(tile BaseTile) {
+	for row := 0; row < 4; row++ {
+		e.visibleTiles[locateAttribute(row, tile)] = 1
+	}
+	e.visibleTilesCount[tile] = 4
+}
+
+// Init 编码开局信息，此时庄家已摸牌，开局前的日志不计入对局记录
+func (e *TableEncoder) Init() {
+	t := e.Table
+	indicator := t.DoraIndicator[0]
+	e.markVisible(indicator)
+
+	for i := 0; i < NPlayers; i++ {
+		info := &e.SelfInfos[i]
+		info[locateAttribute(SelfInfoDoraIndicator1, indicator.Tile)]++
+		info[locateAttribute(SelfInfoDora1, GetDoraNext(indicator.Tile))]++
+		info[locateAttribute(SelfInfoSelfWind, _1z+BaseTile(t.Players[i].Wind-East))] = 1
+		info[locateAttribute(SelfInfoGameWind, _1z+BaseTile(t.GameWind-East))] = 1
+
+		g := &e.GlobalInfos[i]
+		g[GlobalInfoGameNumber] = int16(int(t.GameWind-East)*4 + t.Oya)
+		g[GlobalInfoGameSize] = 7
+		g[GlobalInfoHonba] = int16(t.Honba)
+		g[GlobalInfoKyoutaku] = int16(t.Kyoutaku)
+		g[GlobalInfoSelfWind] = int16(t.Players[i].Wind - East)
+		g[GlobalInfoGameWind] = int16(t.GameWind - East)
+		// 与 C++ 一致：开局点数按逆序排列（1为上家、3为下家），立直扣点则按正序
+		for p := 0; p < NPlayers; p++ {
+			g[GlobalInfoPlayer0Point+relativePlayer(i, p)] = int16(t.Players[p].Score / 100)
+		}
+		g[GlobalInfoRemainingTiles] = int16(t.GetRemainTile())
+	}
+
+	for i := 0; i < NPlayers; i++ {
+		e.updateHand(i)
+	}
+	e.updateVisibleTiles()
+
+	e.recordCount = t.GameLog.GetLogCount()
+	e.doraRevealed = 1
+}
+
+// Update 处理上次调用以来新增的日志，遇到和牌或九种九牌时停止
+// 翻开宝牌的时机按 C++ 编码：
+//   - 明杠、加杠后打牌时翻开的宝牌，这里记在打牌之后，C++ 记在打牌之前
+//   - 暗杠的宝牌，这里在抢暗杠的响应之后翻开，C++ 在暗杠宣言时翻开
+//   - 岭上开花时这里先翻开明杠、加杠的宝牌再自摸，C++ 不翻开
+func (e *TableEncoder) Update() {
+	logs := e.Table.GameLog
+	for e.recordCount < logs.GetLogCount() {
+		log := logs.GetLog(e.recordCount)
+		e.recordCount++
+		var next *BaseGameLog
+		if e.recordCount < logs.GetLogCount() {
+			next = logs.GetLog(e.recordCount)
+		}
+		switch {
+		case isDiscardLog(log.Action) && next != nil && next.Action == LogDoraReveal:
+			e.recordCount++
+			e.updateFromLog(next)
+		case log.Action == LogDoraReveal && next != nil && next.Action == LogTsumo:
+			continue
+		case log.Action == LogDoraReveal && e.skipDoraReveal > 0:
+			e.skipDoraReveal--
+			continue
+		}
+		if !e.updateFromLog(log) {
+			return
+		}
+		if log.Action == LogAnKan && e.doraRevealed < len(e.Table.DoraIndicator) {
+			e.updateFromLog(&BaseGameLog{Player: -1, Player2: -1, Action: LogDoraReveal, Tile: e.Table.DoraIndicator[e.doraRevealed]})
+			e.skipDoraReveal++
+		}
+	}
+}
+
+// isDiscardLog 是否为打牌（包括立直宣言打牌）的日志
+func isDiscardLog(action LogAction) bool {
+	switch action {
+	case LogDiscardFromHand, LogDiscardFromTsumo, LogRiichiDiscardFromHand, LogRiichiDiscardFromTsumo:
+		return true
+	}
+	return false
+}
+
+// updateFromLog 编码一条日志，和牌或九种九牌时返回 false
+func (e *TableEncoder) updateFromLog(log *BaseGameLog) bool {
+	switch log.Action {
+	case LogAnKan:
+		e.updateFromAnkan(log)
+	case LogPon, LogChi, LogKan:
+		e.updateFromCall(log)
+	case LogKaKan:
+		e.updateFromKakan(log)
+	case LogDiscardFromHand, LogDiscardFromTsumo, LogRiichiDiscardFromHand, LogRiichiDiscardFromTsumo:
+		e.updateFromDiscard(log)
+	case LogRiichiSuccess:
+		e.updateFromRiichiSuccess(log)
+	case LogDrawNormal, LogDrawRinshan:
+		e.updateFromDraw(log)
+	case LogDoraReveal:
+		e.updateFromDoraReveal(log)
+	case LogKyushukyuhai, LogRon, LogTsumo:
+		return false
+	default:
+		// 结算时记录的分数日志不参与编码
+		if log.Player < 0 && log.Action == LogInvalidAction {
+			return true
+		}
+		panic("Bad LogAction.")
+	}
+
+	e.updateRecord(log)
+	e.updateIppatsu()
+	return true
+}
+
+func (e *TableEncoder) updateFromAnkan(log *BaseGameLog) {
+	e.markAllVisible(log.CallTiles[0].Tile)
+	e.updateHand(log.Player)
+	e.updateVisibleTiles()
+}
+
+func (e *TableEncoder) updateFromCall(log *BaseGameLog) {
+	for _, tile := range log.CallTiles {
+		e.markVisible(tile)
+	}
+	e.updateHand(log.Player)
+	e.updateVisibleTiles()
+}
+
+func (e *TableEncoder) updateFromKakan(log *BaseGameLog) {
+	e.markAllVisible(log.Tile.Tile)
+	e.updateHand(log.Player)
+	e.updateVisibleTiles()
+}
+
+// updateFromDiscard 打牌与立直宣言牌：记入可见牌与各家视角的舍牌区域
+func (e *TableEncoder) updateFromDiscard(log *BaseGameLog) {
+	e.markVisible(log.Tile)
+	for i := 0; i < NPlayers; i++ {
+		row := SelfInfoDiscardedByPlayer1 + relativePlayer(log.Player, i)
+		e.SelfInfos[i][locateAttribute(row, log.Tile.Tile)] = 1
+	}
+	e.updateHand(log.Player)
+	e.updateVisibleTiles()
+}
+
+func (e *TableEncoder) updateFromRiichiSuccess(log *BaseGameLog) {
+	for i := 0; i < NPlayers; i++ {
+		e.GlobalInfos[i][GlobalInfoPlayer0Point+relativePlayer(log.Player, i)] -= 10
+		e.GlobalInfos[i][GlobalInfoKyoutaku]++
+	}
+}
+
+func (e *TableEncoder) updateFromDraw(log *BaseGameLog) {
+	e.updateHand(log.Player)
+	for i := 0; i < NPlayers; i++ {
+		e.GlobalInfos[i][GlobalInfoRemainingTiles]--
+	}
+}
+
+// updateFromDoraReveal 翻开新宝牌
+// 与 C++ 一致：宝牌本身也记在宝牌指示牌一行
+func (e *TableEncoder) updateFromDoraReveal(log *BaseGameLog) {
+	e.doraRevealed++
+	e.markVisible(log.Tile)
+	e.updateVisibleTiles()
+
+	indicator := log.Tile.Tile
+	dora := GetDoraNext(indicator)
+	for i := 0; i < NPlayers; i++ {
+		e.SelfInfos[i][locateAttribute(SelfInfoDoraIndicator1, indicator)]++
+		e.SelfInfos[i][locateAttribute(SelfInfoDoraIndicator1, dora)]++
+	}
+}
+
+// updateHand 用牌桌上的当前手牌重写玩家的手牌、赤宝牌与摸牌三部分
+func (e *TableEncoder) updateHand(player int) {
+	hand := e.Table.Players[player].Hand
+	encodeHandRows(&e.SelfInfos[player], hand, len(hand)%3 == 2 && e.Table.Phase <= P4Action)
+}
+
+func (e *TableEncoder) updateVisibleTiles() {
+	for i := 0; i < NPlayers; i++ {
+		copy(e.SelfInfos[i][SelfInfoDiscardedNumber1*NBaseTiles:], e.visibleTiles[:])
+	}
+}
+
+func (e *TableEncoder) updateRecord(log *BaseGameLog) {
+	var record GameRecord
+	for _, tile := range log.CallTiles {
+		record[recordTileIndex(tile)] = 1
+	}
+	// 吃只记录手中的两张，摸牌只对摸牌者可见
+	draw := log.Action == LogDrawNormal || log.Action == LogDrawRinshan
+	if log.Action != LogChi && !draw && log.Tile != nil {
+		record[recordTileIndex(log.Tile)] = 1
+	}
+
+	switch log.Action {
+	case LogDrawNormal:
+		record[RecordOffsetAction+RecordDrawNormal] = 1
+	case LogDrawRinshan:
+		record[RecordOffsetAction+RecordDrawRinshan] = 1
+	case LogDiscardFromHand:
+		record[RecordOffsetAction+RecordDiscardFromHand] = 1
+	case LogDiscardFromTsumo:
+		record[RecordOffsetAction+RecordDiscardFromTsumo] = 1
+	case LogChi:
+		chiTile := log.Tile.Tile
+		t1, t2 := log.CallTiles[0].Tile, log.CallTiles[1].Tile
+		if t2 < t1 {
+			panic("An abnormal LogAction object.")
+		}
+		switch {
+		case chiTile < t1:
+			record[RecordOffsetAction+RecordChiLeft] = 1
+		case chiTile > t2:
+			record[RecordOffsetAction+RecordChiRight] = 1
+		default:
+			record[RecordOffsetAction+RecordChiMiddle] = 1
+		}
+	case LogPon:
+		record[RecordOffsetAction+RecordPon] = 1
+	case LogKan:
+		record[RecordOffsetAction+RecordKan] = 1
+	case LogKaKan:
+		record[RecordOffsetAction+RecordKakan] = 1
+	case LogAnKan:
+		record[RecordOffsetAction+RecordAnkan] = 1
+	case LogRiichiDiscardFromHand:
+		record[RecordOffsetAction+RecordRiichiFromHand] = 1
+	case LogRiichiDiscardFromTsumo:
+		record[RecordOffsetAction+RecordRiichiFromTsumo] = 1
+	case LogRiichiSuccess:
+		record[RecordOffsetAction+RecordRiichiSuccess] = 1
+	case LogDoraReveal:
+		// 翻宝牌只更新动作之外的部分
+	default:
+		panic("Bad LogAction (not handled in the updateRecord).")
+	}
+
+	for i := 0; i < NPlayers; i++ {
+		r := record
+		r[RecordOffsetPlayer+relativePlayer(log.Player, i)] = 1
+		if draw && i == log.Player {
+			r[recordTileIndex(log.Tile)] = 1
+		}
+		e.Records[i] = append(e.Records[i], r)
+	}
+}
+
+func (e *TableEncoder) updateIppatsu() {
+	for player := 0; player < NPlayers; player++ {
+		ippatsu := int16(0)
+		if e.Table.Players[player].Ippatsu {
+			ippatsu = 1
+		}
+		for i := 0; i < NPlayers; i++ {
+			e.GlobalInfos[i][GlobalInfoPlayer0Ippatsu+relativePlayer(player, i)] = ippatsu
+		}
+	}
+}
+
+// encodeHandRows 重写手牌（按持有张数分4行）、赤宝牌与摸牌三部分
+func encodeHandRows(info *SelfInfo, hand []*Tile, tsumo bool) {
+	var handRows [4 * NBaseTiles]int16
+	var aka, tsumoRow [NBaseTiles]int16
+	var counts [NBaseTiles]int
+	for _, tile := range hand {
+		handRows[locateAttribute(SelfInfoHand1+counts[tile.Tile], tile.Tile)] = 1
+		counts[tile.Tile]++
+		if tile.RedDora {
+			aka[tile.Tile] = 1
+		}
+	}
+	if tsumo {
+		tsumoRow[hand[len(hand)-1].Tile] = 1
+	}
+	copy(info[:], handRows[:])
+	copy(info[SelfInfoAkaDora*NBaseTiles:], aka[:])
+	copy(info[SelfInfoTsumoTile*NBaseTiles:], tsumoRow[:])
+}
+
+// PassiveTableEncoder 不依赖牌桌，由调用者逐项提供信息（用于外部牌谱或对局平台）
+type PassiveTableEncoder struct {
+	SelfInfo   SelfInfo
+	Records    []GameRecord
+	GlobalInfo GlobalInfo
+
+	visibleTiles      [nVisibleTileEntries]int16
+	visibleTilesCount [NBaseTiles]int16
+}
+
+// NewPassiveTableEncoder 创建被动编码器
+func NewPassiveTableEncoder() *PassiveTableEncoder {
+	return &PassiveTableEncoder{}
+}
+
+// EncodeGameBasic 编码局的基本信息
+// 与 C++ 一致：自风一行也按场风填写
+func (e *PassiveTableEncoder) EncodeGameBasic(gameNumber, gameSize, honba, kyoutaku int, selfWind, gameWind Wind) {
+	e.GlobalInfo[GlobalInfoGameNumber] = int16(gameNumber)
+	e.GlobalInfo[GlobalInfoGameSize] = int16(gameSize)
+	e.GlobalInfo[GlobalInfoHonba] = int16(honba)
+	e.GlobalInfo[GlobalInfoKyoutaku] = int16(kyoutaku)
+	e.GlobalInfo[GlobalInfoSelfWind] = int16(selfWind - East)
+	e.GlobalInfo[GlobalInfoGameWind] = int16(gameWind - East)
+
+	e.SelfInfo[locateAttribute(SelfInfoSelfWind, _1z+BaseTile(gameWind-East))] = 1
+	e.SelfInfo[locateAttribute(SelfInfoGameWind, _1z+BaseTile(gameWind-East))] = 1
+}
+
+// EncodePoints 编码四家点数，points 以自家为0按座位顺序排列
+func (e *PassiveTableEncoder) EncodePoints(points [NPlayers]int) {
+	e.GlobalInfo[GlobalInfoPlayer0Point] = int16(points[0])
+	e.GlobalInfo[GlobalInfoPlayer1Point] = int16(points[3])
+	e.GlobalInfo[GlobalInfoPlayer2Point] = int16(points[2])
+	e.GlobalInfo[GlobalInfoPlayer3Point] = int16(points[1])
+}
+
+// EncodeRemainingTiles 编码牌山剩余张数
+func (e *PassiveTableEncoder) EncodeRemainingTiles(remain int) {
+	e.GlobalInfo[GlobalInfoRemainingTiles] = int16(remain)
+}
+
+// EncodeHand 编码手牌，吃碰后的手牌不标记摸牌
+func (e *PassiveTableEncoder) EncodeHand(hand []Tile, afterChipon bool) {
+	tiles := make([]*Tile, len(hand))
+	for i := range hand {
+		tiles[i] = &hand[i]
+	}
+	encodeHandRows(&e.SelfInfo, tiles, !afterChipon && len(hand)%3 == 2)
+}
+
+// EncodeRiver 编码相对位置为 relativePosition 的玩家的河
+func (e *PassiveTableEncoder) EncodeRiver(river []BaseTile, relativePosition int) {
+	for _, tile := range river {
+		e.visibleTiles[locateAttribute(int(e.visibleTilesCount[tile]), tile)]++
+		e.visibleTilesCount[tile]++
+		e.SelfInfo[locateAttribute(SelfInfoDiscardedByPlayer1+relativePosition, tile)] = 1
+	}
+	e.updateVisibleTiles()
+}
+
+// EncodeSelfRiver 编码自家的河
+func (e *PassiveTableEncoder) EncodeSelfRiver(river []BaseTile) {
+	e.EncodeRiver(river, 0)
+}
+
+// EncodeNextRiver 编码下家的河
+func (e *PassiveTableEncoder) EncodeNextRiver(river []BaseTile) {
+	e.EncodeRiver(river, 1)
+}
+
+// EncodeOppositeRiver 编码对家的河
+func (e *PassiveTableEncoder) EncodeOppositeRiver(river []BaseTile) {
+	e.EncodeRiver(river, 2)
+}
+
+// EncodePreviousRiver 编码上家的河
+func (e *PassiveTableEncoder) EncodePreviousRiver(river []BaseTile) {
+	e.EncodeRiver(river, 3)
+}
+
+// EncodeFuuro 编码副露中的牌为可见牌
+func (e *PassiveTableEncoder) EncodeFuuro(callGroups []CallGroup, relativePosition int) {
+	for _, group := range callGroups {
+		for _, tile := range group.Tiles {
+			e.visibleTiles[locateAttribute(int(e.visibleTilesCount[tile]), tile)]++
+			e.visibleTilesCount[tile]++
+		}
+	}
+	e.updateVisibleTiles()
+}
+
+// EncodeSelfFuuro 编码自家的副露
+func (e *PassiveTableEncoder) EncodeSelfFuuro(callGroups []CallGroup) {
+	e.EncodeFuuro(callGroups, 0)
+}
+
+// EncodeNextFuuro 编码下家的副露
+func (e *PassiveTableEncoder) EncodeNextFuuro(callGroups []CallGroup) {
+	e.EncodeFuuro(callGroups, 1)
+}
+
+// EncodeOppositeFuuro 编码对家的副露
+func (e *PassiveTableEncoder) EncodeOppositeFuuro(callGroups []CallGroup) {
+	e.EncodeFuuro(callGroups, 2)
+}
+
+// EncodePreviousFuuro 编码上家的副露
+func (e *PassiveTableEncoder) EncodePreviousFuuro(callGroups []CallGroup) {
+	e.EncodeFuuro(callGroups, 3)
+}
+
+// EncodeDora 编码已翻开的宝牌指示牌
+func (e *PassiveTableEncoder) EncodeDora(indicators []BaseTile) {
+	for _, tile := range indicators {
+		e.visibleTiles[locateAttribute(int(e.visibleTilesCount[tile]), tile)]++
+		e.visibleTilesCount[tile]++
+	}
+	e.updateVisibleTiles()
+	for _, tile := range indicators {
+		e.SelfInfo[locateAttribute(SelfInfoDoraIndicator1, tile)]++
+		e.SelfInfo[locateAttribute(SelfInfoDora1, GetDoraNext(tile))]++
+	}
+}
+
+// EncodeRiichiStates 立直状态（V2 中不编码）
+func (e *PassiveTableEncoder) EncodeRiichiStates(riichi [NPlayers]int) {}
+
+// EncodeIppatsuStates 编码四家的一发状态
+func (e *PassiveTableEncoder) EncodeIppatsuStates(ippatsu [NPlayers]int) {
+	for i := 0; i < NPlayers; i++ {
+		e.GlobalInfo[GlobalInfoPlayer0Ippatsu+i] = int16(ippatsu[i])
+	}
+}
+
+func (e *PassiveTableEncoder) updateVisibleTiles() {
+	copy(e.SelfInfo[SelfInfoDiscardedNumber1*NBaseTiles:], e.visibleTiles[:])
+}
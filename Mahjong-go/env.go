@@ -0,0 +1,375 @@
+package mahjong
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// MahjongEnv 与 pymahjong 的 MahjongEnv 一致的多智能体环境
+//
+// 每次 Step 之后环境会自动执行只有唯一选项的选择（如只能 Pass），
+// 因此 CurrentPlayer 总是下一个真正需要做决定的玩家。
+// 立直分两步（见动作空间的说明）：先选择打出的牌，若该牌可以立直，再在 ActionRiichi 与 ActionPassRiichi 之间选择。
+// 暗杠、加杠有多种牌可选时同样分两步：先选择 ActionAnKan 或 ActionKaKan，再用 0-33 选择杠的牌。
+type MahjongEnv struct {
+	Table *Table    // 当前牌桌
+	Rule  *GameRule // 规则，为空时使用默认规则
+
+	// Encoding 为 true 时每局维护一个训练数据编码器 Encoder，随每一步更新
+	Encoding bool
+	Encoder  *TableEncoder
+
+	// Profile 不为空时对每局牌桌的动作生成、算分与听牌分析计时
+	Profile *ProfileScope
+
+	rng       *rand.Rand
+	gameCount int
+	info      EpisodeInfo
+
+	riichiStage2 bool // 是否处于立直的第二步
+	riichiIndex  int  // 第一步选择的可以立直的打牌编号
+	kanStage2    bool // 是否处于选择杠的牌的第二步
+	kanIndex     int  // 第一步选择的杠编号
+}
+
+// ResetOptions 重置环境的选项
+type ResetOptions struct {
+	Oya      int   // 庄家，为 -1 时按局数轮流坐庄
+	GameWind Wind  // 场风
+	Scores   []int // 初始点数，为空时每家25000
+	Kyoutaku int   // 供托数
+	Honba    int   // 本场数
+	HasSeed  bool  // 是否指定牌山种子
+	Seed     int64 // 牌山种子，未指定时由环境的随机数生成
+}
+
+// DefaultResetOptions 默认的重置选项：东场、轮流坐庄、每家25000点
+func DefaultResetOptions() ResetOptions {
+	return ResetOptions{Oya: -1, GameWind: East}
+}
+
+// EpisodeInfo 一局的信息
+type EpisodeInfo struct {
+	Episode    int           // 第几局（从1开始）
+	Seed       int64         // 牌山种子，可用于复现
+	Oya        int           // 庄家
+	GameWind   Wind          // 场风
+	InitScores [NPlayers]int // 开局时的点数
+	Steps      int           // 玩家做出的决定数（不含自动执行的唯一选项）
+	Result     *GameResult   // 本局结果，未结束时为 nil
+}
+
+// NewMahjongEnv 创建环境，seed 决定之后每局的牌山种子
+func NewMahjongEnv(seed int64) *MahjongEnv {
+	return &MahjongEnv{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Seed 重新设置环境的随机数种子
+func (e *MahjongEnv) Seed(seed int64) {
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// Reset 使用默认选项开始新的一局
+func (e *MahjongEnv) Reset() {
+	e.ResetWithOptions(DefaultResetOptions())
+}
+
+// ResetWithOptions 按选项开始新的一局
+func (e *MahjongEnv) ResetWithOptions(opts ResetOptions) {
+	oya := opts.Oya
+	if oya < 0 {
+		oya = e.gameCount % NPlayers
+	}
+	seed := opts.Seed
+	if !opts.HasSeed {
+		seed = e.rng.Int63()
+	}
+
+	table := NewTable()
+	table.Profile = e.Profile
+	table.GameInitWithConfig(GameConfig{
+		HasSeed:    true,
+		Seed:       seed,
+		InitScores: opts.Scores,
+		Kyoutaku:   opts.Kyoutaku,
+		Honba:      opts.Honba,
+		GameWind:   opts.GameWind,
+		Oya:        oya,
+		Rule:       e.Rule,
+	})
+	e.start(table, seed)
+}
+
+// start 以已经开始的牌桌作为新的一局
+func (e *MahjongEnv) start(table *Table, seed int64) {
+	e.Table = table
+	e.gameCount++
+	e.riichiStage2 = false
+	e.kanStage2 = false
+	e.info = EpisodeInfo{
+		Episode:  e.gameCount,
+		Seed:     seed,
+		Oya:      table.Oya,
+		GameWind: table.GameWind,
+	}
+	for i, p := range table.Players {
+		e.info.InitScores[i] = p.Score
+	}
+	e.Encoder = nil
+	if e.Encoding {
+		e.Encoder = NewTableEncoder(table)
+		e.Encoder.Init()
+	}
+	e.proceed()
+}
+
+// proceed 自动执行只有唯一选项的选择，直到有玩家需要做决定或本局结束
+func (e *MahjongEnv) proceed() {
+	t := e.Table
+	for !t.IsGameOver() {
+		n := len(t.ResponseActions)
+		if t.Phase <= P4Action {
+			n = len(t.SelfActions)
+		}
+		if n > 1 {
+			break
+		}
+		t.MakeSelection(0)
+	}
+	if e.Encoder != nil {
+		e.Encoder.Update()
+	}
+	if t.IsGameOver() {
+		e.info.Result = t.Result
+	}
+}
+
+// IsOver 本局是否已结束
+func (e *MahjongEnv) IsOver() bool {
+	return e.Table == nil || e.Table.IsGameOver()
+}
+
+// CurrentPlayer 返回需要做决定的玩家，本局结束时返回 -1
+func (e *MahjongEnv) CurrentPlayer() int {
+	if e.IsOver() {
+		return -1
+	}
+	return e.Table.WhoMakeSelection()
+}
+
+// GetValidActionMask 返回当前玩家的合法动作掩码
+// 第一步为 Table.ActionMask，第二步为 Table.RiichiActionMask 或 Table.KanTileActionMask
+func (e *MahjongEnv) GetValidActionMask() [NActionSpace]bool {
+	if e.IsOver() {
+		return [NActionSpace]bool{}
+	}
+	if e.riichiStage2 {
+		return e.Table.RiichiActionMask(e.riichiIndex)
+	}
+	if e.kanStage2 {
+		return e.Table.KanTileActionMask(e.kanIndex)
+	}
+	return e.Table.ActionMask()
+}
+
+// GetValidActions 返回当前玩家的合法动作编号
+func (e *MahjongEnv) GetValidActions() []int {
+	var actions []int
+	for index, ok := range e.GetValidActionMask() {
+		if ok {
+			actions = append(actions, index)
+		}
+	}
+	return actions
+}
+
+// Step 玩家 player 执行动作编号 action
+func (e *MahjongEnv) Step(player, action int) error {
+	if e.IsOver() {
+		return errors.New("the game is over")
+	}
+	if current := e.CurrentPlayer(); player != current {
+		return fmt.Errorf("current acting player is %d, but player %d tries to act", current, player)
+	}
+	if action < 0 || action >= NActionSpace || !e.GetValidActionMask()[action] {
+		return fmt.Errorf("action %d is not a valid action", action)
+	}
+
+	t := e.Table
+	e.info.Steps++
+	switch {
+	case e.riichiStage2:
+		e.riichiStage2 = false
+		t.MakeRiichiSelectionFromActionIndex(e.riichiIndex, action)
+	case e.kanStage2:
+		e.kanStage2 = false
+		t.MakeKanSelectionFromActionIndex(e.kanIndex, action)
+	case t.KanNeedsTileChoice(action):
+		// 有多种牌可以杠，等待第二步
+		e.kanStage2 = true
+		e.kanIndex = action
+		return nil
+	case t.CanRiichiWithActionIndex(action):
+		// 打出的牌可以立直，等待第二步
+		e.riichiStage2 = true
+		e.riichiIndex = action
+		return nil
+	default:
+		t.MakeSelectionFromActionIndex(action)
+	}
+	e.proceed()
+	return nil
+}
+
+// GetObs 返回玩家 player 的视角，当前玩家的 ActionMask 与 GetValidActionMask 一致
+func (e *MahjongEnv) GetObs(player int) *PlayerView {
+	v := NewPlayerView(e.Table, player)
+	v.ActionMask = e.maskFor(player)
+	return v
+}
+
+// GetOracleObs 返回玩家 player 的完整视角
+func (e *MahjongEnv) GetOracleObs(player int) *OracleView {
+	v := NewOracleView(e.Table, player)
+	v.ActionMask = e.maskFor(player)
+	return v
+}
+
+func (e *MahjongEnv) maskFor(player int) [NActionSpace]bool {
+	if player != e.CurrentPlayer() {
+		return [NActionSpace]bool{}
+	}
+	return e.GetValidActionMask()
+}
+
+// GetPayoffs 返回四家相对开局的点数变化
+func (e *MahjongEnv) GetPayoffs() [NPlayers]float64 {
+	var payoffs [NPlayers]float64
+	for i, p := range e.Table.Players {
+		payoffs[i] = float64(p.Score - e.info.InitScores[i])
+	}
+	return payoffs
+}
+
+// Info 返回本局的信息
+func (e *MahjongEnv) Info() EpisodeInfo {
+	return e.info
+}
+
+// Agent 根据视角选择动作编号，合法动作由视角的 ActionMask 给出
+type Agent interface {
+	SelectAction(view *PlayerView) int
+}
+
+// RandomAgent 在合法动作中均匀随机选择
+type RandomAgent struct {
+	Rand *rand.Rand
+}
+
+// NewRandomAgent 创建随机智能体
+func NewRandomAgent(seed int64) *RandomAgent {
+	return &RandomAgent{Rand: rand.New(rand.NewSource(seed))}
+}
+
+// SelectAction 实现 Agent
+func (a *RandomAgent) SelectAction(view *PlayerView) int {
+	var legal []int
+	for index, ok := range view.ActionMask {
+		if ok {
+			legal = append(legal, index)
+		}
+	}
+	if len(legal) == 0 {
+		return -1
+	}
+	return legal[a.Rand.Intn(len(legal))]
+}
+
+// SingleAgentSeat 单智能体环境中智能体的座位
+const SingleAgentSeat = 0
+
+// SingleAgentEnv 与 pymahjong 的 SingleAgentMahjongEnv 一致的单智能体环境
+// 智能体坐在0号座位（庄家仍然轮流），其余三家由 Opponent 控制
+type SingleAgentEnv struct {
+	Env      *MahjongEnv
+	Opponent Agent
+}
+
+// StepResult 单智能体环境一步的结果
+type StepResult struct {
+	Obs    *PlayerView // 智能体的视角
+	Reward float64     // 本局结束时为智能体的点数变化，否则为0
+	Done   bool        // 本局是否结束
+	Info   EpisodeInfo // 本局信息
+}
+
+// NewSingleAgentEnv 创建单智能体环境，opponent 为空时使用随机对手
+func NewSingleAgentEnv(seed int64, opponent Agent) *SingleAgentEnv {
+	env := NewMahjongEnv(seed)
+	if opponent == nil {
+		opponent = NewRandomAgent(env.rng.Int63())
+	}
+	return &SingleAgentEnv{Env: env, Opponent: opponent}
+}
+
+// Reset 开始新的一局并推进到智能体的回合
+// 若在智能体行动前本局就已结束，则重新开始
+func (s *SingleAgentEnv) Reset() (*PlayerView, error) {
+	return s.ResetWithOptions(DefaultResetOptions())
+}
+
+// ResetWithOptions 按选项开始新的一局，重新开始时沿用同样的选项（指定的种子除外）
+func (s *SingleAgentEnv) ResetWithOptions(opts ResetOptions) (*PlayerView, error) {
+	for {
+		s.Env.ResetWithOptions(opts)
+		if err := s.proceedUntilAgentTurn(); err != nil {
+			return nil, err
+		}
+		if !s.Env.IsOver() {
+			return s.GetObs(), nil
+		}
+		opts.HasSeed = false
+	}
+}
+
+// Step 智能体执行动作并推进到智能体的下一个回合或本局结束
+func (s *SingleAgentEnv) Step(action int) (StepResult, error) {
+	if err := s.Env.Step(SingleAgentSeat, action); err != nil {
+		return StepResult{}, err
+	}
+	if err := s.proceedUntilAgentTurn(); err != nil {
+		return StepResult{}, err
+	}
+	result := StepResult{Obs: s.GetObs(), Done: s.Env.IsOver(), Info: s.Env.Info()}
+	if result.Done {
+		result.Reward = s.Env.GetPayoffs()[SingleAgentSeat]
+	}
+	return result, nil
+}
+
+func (s *SingleAgentEnv) proceedUntilAgentTurn() error {
+	for !s.Env.IsOver() && s.Env.CurrentPlayer() != SingleAgentSeat {
+		player := s.Env.CurrentPlayer()
+		action := s.Opponent.SelectAction(s.Env.GetObs(player))
+		if err := s.Env.Step(player, action); err != nil {
+			return fmt.Errorf("opponent %d: %w", player, err)
+		}
+	}
+	return nil
+}
+
+// GetObs 返回智能体的视角
+func (s *SingleAgentEnv) GetObs() *PlayerView {
+	return s.Env.GetObs(SingleAgentSeat)
+}
+
+// GetOracleObs 返回智能体的完整视角
+func (s *SingleAgentEnv) GetOracleObs() *OracleView {
+	return s.Env.GetOracleObs(SingleAgentSeat)
+}
+
+// GetValidActions 返回智能体的合法动作编号
+func (s *SingleAgentEnv) GetValidActions() []int {
+	return s.Env.GetValidActions()
+}
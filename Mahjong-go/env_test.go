@@ -0,0 +1,191 @@
+package mahjong
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// playRandomEpisode 用随机动作进行一局，返回四家收益
+func playRandomEpisode(t *testing.T, env *MahjongEnv, rng *rand.Rand) [NPlayers]float64 {
+	t.Helper()
+	for !env.IsOver() {
+		player := env.CurrentPlayer()
+		actions := env.GetValidActions()
+		if len(actions) < 2 {
+			t.Fatalf("player %d should have a real choice, got %v", player, actions)
+		}
+		obs := env.GetObs(player)
+		for _, a := range actions {
+			if !obs.ActionMask[a] {
+				t.Fatalf("observation mask disagrees with valid actions at %d", a)
+			}
+		}
+		if err := env.Step(player, actions[rng.Intn(len(actions))]); err != nil {
+			t.Fatalf("step: %v", err)
+		}
+	}
+	return env.GetPayoffs()
+}
+
+func TestMahjongEnvRandomEpisodes(t *testing.T) {
+	env := NewMahjongEnv(3)
+	rng := rand.New(rand.NewSource(3))
+	for episode := 0; episode < 20; episode++ {
+		env.Reset()
+		if oya := env.Info().Oya; oya != episode%NPlayers {
+			t.Fatalf("episode %d: oya %d should rotate", episode, oya)
+		}
+		payoffs := playRandomEpisode(t, env, rng)
+
+		info := env.Info()
+		if info.Result == nil || info.Episode != episode+1 {
+			t.Fatalf("episode %d: bad info %+v", episode, info)
+		}
+		if info.Result.Type == RonAgari || info.Result.Type == TsumoAgari {
+			sum := 0.0
+			for _, p := range payoffs {
+				sum += p
+			}
+			if sum != 0 {
+				t.Fatalf("episode %d: agari payoffs should sum to zero, got %v", episode, payoffs)
+			}
+		}
+		if env.CurrentPlayer() != -1 || len(env.GetValidActions()) != 0 {
+			t.Fatalf("no one should act after the game is over")
+		}
+	}
+}
+
+func TestMahjongEnvSeeding(t *testing.T) {
+	run := func() (string, [NPlayers]float64) {
+		env := NewMahjongEnv(42)
+		env.Reset()
+		payoffs := playRandomEpisode(t, env, rand.New(rand.NewSource(5)))
+		return env.Table.GameLog.String(), payoffs
+	}
+	log1, payoffs1 := run()
+	log2, payoffs2 := run()
+	if log1 != log2 || payoffs1 != payoffs2 {
+		t.Fatalf("the same seeds should replay the same game")
+	}
+
+	env := NewMahjongEnv(0)
+	opts := DefaultResetOptions()
+	opts.HasSeed, opts.Seed = true, 1234
+	env.ResetWithOptions(opts)
+	if env.Info().Seed != 1234 || env.Table.Seed != 1234 {
+		t.Fatalf("explicit seed should be used for the wall")
+	}
+}
+
+func TestMahjongEnvStepErrors(t *testing.T) {
+	env := NewMahjongEnv(1)
+	env.Reset()
+	player := env.CurrentPlayer()
+	if err := env.Step((player+1)%NPlayers, env.GetValidActions()[0]); err == nil {
+		t.Fatalf("a player out of turn should be rejected")
+	}
+	mask := env.GetValidActionMask()
+	invalid := -1
+	for index, ok := range mask {
+		if !ok {
+			invalid = index
+			break
+		}
+	}
+	if err := env.Step(player, invalid); err == nil {
+		t.Fatalf("an invalid action should be rejected")
+	}
+	if err := env.Step(player, NActionSpace); err == nil {
+		t.Fatalf("an out-of-range action should be rejected")
+	}
+}
+
+func TestMahjongEnvTwoStepRiichi(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+	for _, declare := range []bool{true, false} {
+		env := NewMahjongEnv(1)
+		env.start(newScenarioTable(t, hands, []BaseTile{_9s, _9p}, nil, 0), 1)
+
+		mask := env.GetValidActionMask()
+		if mask[ActionRiichi] || !mask[_9s] {
+			t.Fatalf("riichi should be hidden behind the discard in the first step")
+		}
+		if err := env.Step(0, int(_9s)); err != nil {
+			t.Fatal(err)
+		}
+		if got := env.GetValidActions(); len(got) != 2 || got[0] != ActionRiichi || got[1] != ActionPassRiichi {
+			t.Fatalf("second step should offer riichi or not, got %v", got)
+		}
+		if env.CurrentPlayer() != 0 || len(env.Table.Players[0].River.River) != 0 {
+			t.Fatalf("nothing should be discarded before the second step")
+		}
+
+		action := ActionPassRiichi
+		if declare {
+			action = ActionRiichi
+		}
+		if err := env.Step(0, action); err != nil {
+			t.Fatal(err)
+		}
+		p0 := env.Table.Players[0]
+		if len(p0.River.River) != 1 || p0.River.River[0].Tile.Tile != _9s {
+			t.Fatalf("9s should be discarded after the second step")
+		}
+		if p0.IsRiichi() != declare {
+			t.Fatalf("riichi=%v, expected %v", p0.IsRiichi(), declare)
+		}
+	}
+}
+
+func TestMahjongEnvTwoStepKan(t *testing.T) {
+	env := NewMahjongEnv(1)
+	env.start(newScenarioTable(t, twoAnKanHands(), []BaseTile{_9s}, nil, 0), 1)
+
+	if err := env.Step(0, ActionAnKan); err != nil {
+		t.Fatal(err)
+	}
+	if got := env.GetValidActions(); len(got) != 2 || got[0] != int(_1m) || got[1] != int(_1p) {
+		t.Fatalf("second step should offer the kan tiles, got %v", got)
+	}
+	if len(env.Table.Players[0].CallGroups) != 0 {
+		t.Fatalf("nothing should be called before the second step")
+	}
+	if err := env.Step(0, int(_1m)); err != nil {
+		t.Fatal(err)
+	}
+	groups := env.Table.Players[0].CallGroups
+	if len(groups) != 1 || groups[0].Tiles[0] != _1m {
+		t.Fatalf("expected an ankan of 1m, got %v", groups)
+	}
+}
+
+func TestSingleAgentEnv(t *testing.T) {
+	env := NewSingleAgentEnv(9, nil)
+	agent := NewRandomAgent(9)
+	for episode := 0; episode < 10; episode++ {
+		obs, err := env.Reset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if obs.Seat != SingleAgentSeat || env.Env.CurrentPlayer() != SingleAgentSeat {
+				t.Fatalf("control should return to the agent")
+			}
+			result, err := env.Step(agent.SelectAction(obs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Done {
+				if result.Reward != env.Env.GetPayoffs()[SingleAgentSeat] || result.Info.Result == nil {
+					t.Fatalf("final step should carry the payoff and result")
+				}
+				break
+			}
+			if result.Reward != 0 {
+				t.Fatalf("reward should only be given at the end")
+			}
+			obs = result.Obs
+		}
+	}
+}
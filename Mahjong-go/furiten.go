@@ -0,0 +1,69 @@
+package mahjong
+
+// FuritenKind 振听的种类
+type FuritenKind int
+
+const (
+	NoFuriten       FuritenKind = iota // 非振听
+	FuritenByRiichi                    // 立直振听：立直后见逃和牌，到本局结束为止
+	FuritenByRiver                     // 舍张振听：自己的河中有听的牌
+	FuritenByRound                     // 同巡振听：见逃和牌，到自己下次摸牌为止
+)
+
+// String 返回振听种类的名称
+func (k FuritenKind) String() string {
+	switch k {
+	case NoFuriten:
+		return "非振听"
+	case FuritenByRiichi:
+		return "立直振听"
+	case FuritenByRiver:
+		return "舍张振听"
+	case FuritenByRound:
+		return "同巡振听"
+	default:
+		return "未知"
+	}
+}
+
+// FuritenInfo 振听的说明
+type FuritenInfo struct {
+	Kind  FuritenKind   // 最主要的振听种类（按 立直 > 舍张 > 同巡 的顺序）
+	Tile  *Tile         // 造成该振听的牌
+	Kinds []FuritenKind // 所有成立的振听种类
+}
+
+// IsFuriten 判断是否振听
+func (f FuritenInfo) IsFuriten() bool {
+	return f.Kind != NoFuriten
+}
+
+// String 返回振听说明
+func (f FuritenInfo) String() string {
+	if f.Tile == nil {
+		return f.Kind.String()
+	}
+	return f.Kind.String() + "（" + f.Tile.String() + "）"
+}
+
+// ExplainFuriten 说明玩家当前是否振听、属于哪种振听以及是哪张牌造成的
+func (p *Player) ExplainFuriten() FuritenInfo {
+	info := FuritenInfo{Kind: NoFuriten}
+	add := func(kind FuritenKind, tile *Tile) {
+		if info.Kind == NoFuriten {
+			info.Kind = kind
+			info.Tile = tile
+		}
+		info.Kinds = append(info.Kinds, kind)
+	}
+	if p.FuritenRiichi {
+		add(FuritenByRiichi, p.FuritenRiichiTile)
+	}
+	if p.FuritenRiver {
+		add(FuritenByRiver, p.riverFuritenTile())
+	}
+	if p.FuritenRound {
+		add(FuritenByRound, p.FuritenRoundTile)
+	}
+	return info
+}
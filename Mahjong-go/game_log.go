@@ -106,8 +106,10 @@ func (g *BaseGameLog) String() string {
 			result += "P" + string(rune('0'+i)) + "=" + string(rune(g.Scores[i]))
 		}
 	} else {
-		// 这是动作日志
-		result += "P" + string(rune('0'+g.Player)) + " "
+		// 这是动作日志（翻宝牌等不属于某个玩家的事件没有玩家前缀）
+		if g.Player >= 0 {
+			result += "P" + string(rune('0'+g.Player)) + " "
+		}
 		result += LogActionToString(g.Action)
 
 		if g.Tile != nil {
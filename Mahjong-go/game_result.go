@@ -15,21 +15,52 @@ const (
 	NagashiMangan                   // 流局满贯
 
 	// 流局类型
-	RyukyokuNotile       // 牌山无牌（牌库流局）
-	RyukyokuSuzukaflush  // 四风连打
-	RyukyokuSufonrenda   // 四杠散了
-	RyukyokuSuuchahan    // 四家立直
+	RyukyokuNotile // 荒牌流局（牌山无牌）
+
+	// 途中流局
 	RyukyokuKyushukyuhai // 九种九牌
-	RyukyokuFourWind     // 四风连打（复合）
+	RyukyokuSuufonrenda  // 四风连打
+	RyukyokuSuuchaRiichi // 四家立直
+	RyukyokuSuukaikan    // 四杠散了
+	RyukyokuSanchahou    // 三家和了
 
 	// 其他
 	NoResult // 游戏正常进行中
 )
 
+// String 返回结果类型的名称
+func (t ResultType) String() string {
+	switch t {
+	case RonAgari:
+		return "荣和"
+	case TsumoAgari:
+		return "自摸"
+	case NagashiMangan:
+		return "流局满贯"
+	case RyukyokuNotile:
+		return "荒牌流局"
+	case RyukyokuKyushukyuhai:
+		return "九种九牌"
+	case RyukyokuSuufonrenda:
+		return "四风连打"
+	case RyukyokuSuuchaRiichi:
+		return "四家立直"
+	case RyukyokuSuukaikan:
+		return "四杠散了"
+	case RyukyokuSanchahou:
+		return "三家和了"
+	case NoResult:
+		return "进行中"
+	default:
+		return "未知"
+	}
+}
+
 // GameResult 表示一局麻将的结果
 type GameResult struct {
 	Type         ResultType          // 结果类型
 	WinnerIdx    int                 // 胜者索引（-1表示无胜者）
+	Winners      []int               // 所有和牌者（双响时有多个）
 	LoserIdx     int                 // 失败者索引（-1表示无失败者）
 	Score        *ScoreCounterResult // 计分结果
 	ScoreChanges [4]int              // 四个玩家的分数变化
@@ -38,6 +69,11 @@ type GameResult struct {
 	Fu           int                 // 符数
 	Message      string              // 结果信息
 
+	// 下一局的场况
+	Renchan  bool // 是否连庄
+	Honba    int  // 下一局的本场数
+	Kyoutaku int  // 留在场上的供托数
+
 	// 用于重新导出结果
 	FanCount   int  // 番数（对于非胡牌结果）
 	IsYakuman  bool // 是否为役满
@@ -68,19 +104,45 @@ func (r *GameResult) SetRonAgari(winnerIdx int, loserIdx int, score *ScoreCounte
 	}
 	r.ScoreChanges[winnerIdx] = score.RonScore
 	r.ScoreChanges[loserIdx] = -score.RonScore
+	r.Winners = []int{winnerIdx}
 
 	// 复制役
 	r.Yakus = make([]Yaku, len(score.Yakus))
 	copy(r.Yakus, score.Yakus)
 	r.Fan = score.Fan
 	r.Fu = score.Fu
+	r.IsYakuman = score.IsYakuman()
 
 	r.Message = fmt.Sprintf("玩家%d荣和玩家%d的牌，%d番%d符，得分%d",
 		winnerIdx, loserIdx, score.Fan, score.Fu, score.RonScore)
 }
 
+// AddRonAgari 追加一家荣和（一炮多响）
+// 第一家仍由 SetRonAgari 设置，役与番符记录第一家的结果
+func (r *GameResult) AddRonAgari(winnerIdx int, loserIdx int, score *ScoreCounterResult) {
+	if r.Type != RonAgari {
+		r.SetRonAgari(winnerIdx, loserIdx, score)
+		return
+	}
+	r.Winners = append(r.Winners, winnerIdx)
+	r.ScoreChanges[winnerIdx] += score.RonScore
+	r.ScoreChanges[loserIdx] -= score.RonScore
+	r.Message += fmt.Sprintf("；玩家%d荣和玩家%d的牌，%d番%d符，得分%d",
+		winnerIdx, loserIdx, score.Fan, score.Fu, score.RonScore)
+}
+
+// AwardKyoutaku 将场上的供托（每根1000点）交给和牌者
+func (r *GameResult) AwardKyoutaku(winnerIdx int, kyoutaku int) {
+	if kyoutaku <= 0 {
+		return
+	}
+	r.ScoreChanges[winnerIdx] += 1000 * kyoutaku
+	r.Message += fmt.Sprintf("，获得供托%d点", 1000*kyoutaku)
+}
+
 // SetTsumoAgari 设置为自摸结果
-func (r *GameResult) SetTsumoAgari(winnerIdx int, score *ScoreCounterResult) {
+// 庄家自摸时三家各付 TsumoScore[0]；子家自摸时庄家付 TsumoScore[0]，其余两家付 TsumoScore[1]
+func (r *GameResult) SetTsumoAgari(winnerIdx int, oyaIdx int, score *ScoreCounterResult) {
 	r.Type = TsumoAgari
 	r.WinnerIdx = winnerIdx
 	r.LoserIdx = -1
@@ -88,114 +150,146 @@ func (r *GameResult) SetTsumoAgari(winnerIdx int, score *ScoreCounterResult) {
 
 	// 计算分数变化
 	r.ScoreChanges = [4]int{0, 0, 0, 0}
-
-	if winnerIdx == 0 { // 庄家自摸
-		r.ScoreChanges[1] = -score.TsumoScore[0]
-		r.ScoreChanges[2] = -score.TsumoScore[1]
-		r.ScoreChanges[3] = -score.TsumoScore[2]
-		r.ScoreChanges[0] = score.TsumoScore[0]*3 - (r.ScoreChanges[1] + r.ScoreChanges[2] + r.ScoreChanges[3])
-	} else { // 子家自摸
-		r.ScoreChanges[0] = -score.TsumoScore[0]
-		for i := 1; i < 4; i++ {
-			if i == winnerIdx {
-				continue
-			}
-			if i == 0 {
-				r.ScoreChanges[i] = -score.TsumoScore[0]
-			} else {
-				r.ScoreChanges[i] = -score.TsumoScore[1]
-			}
+	r.Winners = []int{winnerIdx}
+	for i := 0; i < 4; i++ {
+		if i == winnerIdx {
+			continue
 		}
-		total := 0
-		for i := 0; i < 4; i++ {
-			if i != winnerIdx {
-				total += (-r.ScoreChanges[i])
-			}
+		pay := score.TsumoScore[1]
+		if winnerIdx == oyaIdx || i == oyaIdx {
+			pay = score.TsumoScore[0]
 		}
-		r.ScoreChanges[winnerIdx] = total
+		r.ScoreChanges[i] = -pay
+		r.ScoreChanges[winnerIdx] += pay
 	}
 
 	r.Yakus = make([]Yaku, len(score.Yakus))
 	copy(r.Yakus, score.Yakus)
 	r.Fan = score.Fan
 	r.Fu = score.Fu
+	r.IsYakuman = score.IsYakuman()
 
 	r.Message = fmt.Sprintf("玩家%d自摸，%d番%d符",
 		winnerIdx, score.Fan, score.Fu)
 }
 
 // SetNagashiMangan 设置为流局满贯
-func (r *GameResult) SetNagashiMangan(winnerIdx int) {
+// 每个流局满贯的玩家按满贯自摸收取点数（庄家每家4000，子家庄家4000、闲家2000）
+func (r *GameResult) SetNagashiMangan(nagashi [4]bool, oyaIdx int) {
 	r.Type = NagashiMangan
-	r.WinnerIdx = winnerIdx
+	r.WinnerIdx = -1
 	r.LoserIdx = -1
+	r.Winners = make([]int, 0)
 	r.Fan = 5
 	r.Fu = 30
 	r.FanCount = 5
+	r.ScoreChanges = [4]int{0, 0, 0, 0}
 
-	// 计算满贯分数（8000点）
-	for i := 0; i < 4; i++ {
-		r.ScoreChanges[i] = 0
-	}
-
-	if winnerIdx == 0 { // 庄家
-		r.ScoreChanges[0] = 4000
-		r.ScoreChanges[1] = -4000
-		r.ScoreChanges[2] = -4000
-		r.ScoreChanges[3] = -4000
-	} else { // 子家
-		r.ScoreChanges[winnerIdx] = 8000
-		r.ScoreChanges[0] = -4000
-		for i := 1; i < 4; i++ {
-			if i != winnerIdx {
-				r.ScoreChanges[i] = -2000
+	for w := 0; w < 4; w++ {
+		if !nagashi[w] {
+			continue
+		}
+		if r.WinnerIdx < 0 {
+			r.WinnerIdx = w
+		}
+		r.Winners = append(r.Winners, w)
+		for i := 0; i < 4; i++ {
+			if i == w {
+				continue
+			}
+			pay := 2000
+			if w == oyaIdx || i == oyaIdx {
+				pay = 4000
 			}
+			r.ScoreChanges[i] -= pay
+			r.ScoreChanges[w] += pay
 		}
 	}
 
-	r.Message = fmt.Sprintf("玩家%d流局满贯", winnerIdx)
+	r.Message = fmt.Sprintf("玩家%v流局满贯", r.Winners)
 }
 
-// SetRyukyokuNotile 设置为牌库流局
-func (r *GameResult) SetRyukyokuNotile() {
+// SetRyukyokuNotile 设置为荒牌流局
+// tenpai 为各家是否听牌，不听的玩家向听牌的玩家共支付3000点
+func (r *GameResult) SetRyukyokuNotile(tenpai [4]bool) {
 	r.Type = RyukyokuNotile
 	r.WinnerIdx = -1
 	r.LoserIdx = -1
-	r.Message = "牌库流局（无牌可摸）"
-}
-
-// SetRyukyokuSuzukaflush 设置为四风连打
-func (r *GameResult) SetRyukyokuSuzukaflush() {
-	r.Type = RyukyokuSuzukaflush
-	r.WinnerIdx = -1
-	r.LoserIdx = -1
-	r.Message = "四风连打流局"
-}
+	r.ScoreChanges = [4]int{0, 0, 0, 0}
 
-// SetRyukyokuSufonrenda 设置为四杠散了
-func (r *GameResult) SetRyukyokuSufonrenda() {
-	r.Type = RyukyokuSufonrenda
-	r.WinnerIdx = -1
-	r.LoserIdx = -1
-	r.Message = "四杠散了流局"
+	nTenpai := 0
+	for _, t := range tenpai {
+		if t {
+			nTenpai++
+		}
+	}
+	if nTenpai > 0 && nTenpai < 4 {
+		for i := 0; i < 4; i++ {
+			if tenpai[i] {
+				r.ScoreChanges[i] = 3000 / nTenpai
+			} else {
+				r.ScoreChanges[i] = -3000 / (4 - nTenpai)
+			}
+		}
+	}
+	r.Message = fmt.Sprintf("荒牌流局，听牌%d家", nTenpai)
 }
 
-// SetRyukyokuSuuchahan 设置为四家立直
-func (r *GameResult) SetRyukyokuSuuchahan() {
-	r.Type = RyukyokuSuuchahan
+// setAbortiveDraw 设置为途中流局，不发生点数移动
+func (r *GameResult) setAbortiveDraw(t ResultType) {
+	r.Type = t
 	r.WinnerIdx = -1
 	r.LoserIdx = -1
-	r.Message = "四家立直流局"
+	r.ScoreChanges = [4]int{0, 0, 0, 0}
+	r.Message = t.String() + "流局"
 }
 
 // SetRyukyokuKyushukyuhai 设置为九种九牌
 func (r *GameResult) SetRyukyokuKyushukyuhai(playerIdx int) {
-	r.Type = RyukyokuKyushukyuhai
-	r.WinnerIdx = playerIdx
-	r.LoserIdx = -1
+	r.setAbortiveDraw(RyukyokuKyushukyuhai)
 	r.Message = fmt.Sprintf("玩家%d九种九牌流局", playerIdx)
 }
 
+// SetRyukyokuSuufonrenda 设置为四风连打
+func (r *GameResult) SetRyukyokuSuufonrenda() {
+	r.setAbortiveDraw(RyukyokuSuufonrenda)
+}
+
+// SetRyukyokuSuuchaRiichi 设置为四家立直
+func (r *GameResult) SetRyukyokuSuuchaRiichi() {
+	r.setAbortiveDraw(RyukyokuSuuchaRiichi)
+}
+
+// SetRyukyokuSuukaikan 设置为四杠散了
+func (r *GameResult) SetRyukyokuSuukaikan() {
+	r.setAbortiveDraw(RyukyokuSuukaikan)
+}
+
+// SetRyukyokuSanchahou 设置为三家和了
+func (r *GameResult) SetRyukyokuSanchahou() {
+	r.setAbortiveDraw(RyukyokuSanchahou)
+}
+
+// AwardHonba 和牌时的本场棒：荣和由放铳者向第一个和牌者支付300点/本场，自摸时每家支付100点/本场
+func (r *GameResult) AwardHonba(honba int) {
+	if honba <= 0 || len(r.Winners) == 0 {
+		return
+	}
+	winner := r.Winners[0]
+	switch r.Type {
+	case RonAgari:
+		r.ScoreChanges[winner] += 300 * honba
+		r.ScoreChanges[r.LoserIdx] -= 300 * honba
+	case TsumoAgari:
+		for i := 0; i < 4; i++ {
+			if i != winner {
+				r.ScoreChanges[i] -= 100 * honba
+				r.ScoreChanges[winner] += 100 * honba
+			}
+		}
+	}
+}
+
 // ApplyScoreChanges 将分数变化应用到玩家
 func (r *GameResult) ApplyScoreChanges(players [4]*Player) {
 	for i := 0; i < 4; i++ {
@@ -210,7 +304,12 @@ func (r *GameResult) IsAgari() bool {
 
 // IsRyukyoku 判断是否为流局
 func (r *GameResult) IsRyukyoku() bool {
-	return r.Type >= RyukyokuNotile
+	return r.Type >= RyukyokuNotile && r.Type < NoResult
+}
+
+// IsAbortiveDraw 判断是否为途中流局
+func (r *GameResult) IsAbortiveDraw() bool {
+	return r.Type > RyukyokuNotile && r.Type < NoResult
 }
 
 // String 返回结果的字符串表示
@@ -247,38 +346,45 @@ func (r *GameResult) String() string {
 	return sb.String()
 }
 
-// GenerateResultNotile 生成牌库流局的结果
-func GenerateResultNotile() *GameResult {
+// GenerateResultNotile 生成荒牌流局的结果
+func GenerateResultNotile(tenpai [4]bool) *GameResult {
 	result := NewGameResult()
-	result.SetRyukyokuNotile()
+	result.SetRyukyokuNotile(tenpai)
 	return result
 }
 
-// GenerateResultSuzukaflush 生成四风连打的结果
-func GenerateResultSuzukaflush() *GameResult {
+// GenerateResultKyushukyuhai 生成九种九牌的结果
+func GenerateResultKyushukyuhai(playerIdx int) *GameResult {
 	result := NewGameResult()
-	result.SetRyukyokuSuzukaflush()
+	result.SetRyukyokuKyushukyuhai(playerIdx)
 	return result
 }
 
-// GenerateResultSufonrenda 生成四杠散了的结果
-func GenerateResultSufonrenda() *GameResult {
+// GenerateResultSuufonrenda 生成四风连打的结果
+func GenerateResultSuufonrenda() *GameResult {
 	result := NewGameResult()
-	result.SetRyukyokuSufonrenda()
+	result.SetRyukyokuSuufonrenda()
 	return result
 }
 
-// GenerateResultSuuchahan 生成四家立直的结果
-func GenerateResultSuuchahan() *GameResult {
+// GenerateResultSuuchaRiichi 生成四家立直的结果
+func GenerateResultSuuchaRiichi() *GameResult {
 	result := NewGameResult()
-	result.SetRyukyokuSuuchahan()
+	result.SetRyukyokuSuuchaRiichi()
 	return result
 }
 
-// GenerateResultKyushukyuhai 生成九种九牌的结果
-func GenerateResultKyushukyuhai(playerIdx int) *GameResult {
+// GenerateResultSuukaikan 生成四杠散了的结果
+func GenerateResultSuukaikan() *GameResult {
 	result := NewGameResult()
-	result.SetRyukyokuKyushukyuhai(playerIdx)
+	result.SetRyukyokuSuukaikan()
+	return result
+}
+
+// GenerateResultSanchahou 生成三家和了的结果
+func GenerateResultSanchahou() *GameResult {
+	result := NewGameResult()
+	result.SetRyukyokuSanchahou()
 	return result
 }
 
@@ -290,15 +396,15 @@ func GenerateResultRon(winnerIdx int, loserIdx int, score *ScoreCounterResult) *
 }
 
 // GenerateResultTsumo 生成自摸的结果
-func GenerateResultTsumo(winnerIdx int, score *ScoreCounterResult) *GameResult {
+func GenerateResultTsumo(winnerIdx int, oyaIdx int, score *ScoreCounterResult) *GameResult {
 	result := NewGameResult()
-	result.SetTsumoAgari(winnerIdx, score)
+	result.SetTsumoAgari(winnerIdx, oyaIdx, score)
 	return result
 }
 
 // GenerateResultNagashiMangan 生成流局满贯的结果
-func GenerateResultNagashiMangan(playerIdx int) *GameResult {
+func GenerateResultNagashiMangan(nagashi [4]bool, oyaIdx int) *GameResult {
 	result := NewGameResult()
-	result.SetNagashiMangan(playerIdx)
+	result.SetNagashiMangan(nagashi, oyaIdx)
 	return result
 }
@@ -0,0 +1,85 @@
+package mahjong
+
+// GameRule 描述一局游戏采用的可选规则
+// 不同雀庄/平台的规则差异集中在这里，计分与牌桌流程通过 Table.Rule 读取
+type GameRule struct {
+	// 役满相关
+	MultipleYakuman bool // 复合役满是否叠加计算（如大三元+字一色为两倍役满）
+	DoubleYakuman   bool // 是否承认双倍役满（四暗刻单骑、纯正九莲宝灯、国士无双十三面、大四喜）
+	KazoeYakuman    bool // 13番以上是否计为数役满（否则按三倍满封顶）
+
+	// 地方役：启用的役及其门清番数（0表示使用役信息表中的默认番数）
+	LocalYaku map[Yaku]int
+
+	// 途中流局：启用时按流局处理（连庄、本场数加一、供托保留）
+	Kyushukyuhai bool // 九种九牌：第一巡无人鸣牌时，持有9种以上幺九牌可宣告流局
+	Suufonrenda  bool // 四风连打：第一巡四家打出同一种风牌
+	SuuchaRiichi bool // 四家立直：第四家立直成立时流局
+	Suukaikan    bool // 四杠散了：两家以上合计开杠4次，第4个杠后的打牌无人荣和时流局
+	Sanchahou    bool // 三家和了：三家同时荣和时流局（停用时三家均和牌）
+
+	// 杠宝牌
+	KanDoraDelayed bool // 明杠、加杠的新宝牌在打牌后（或下次开杠、和牌时）翻开，否则立即翻开；暗杠总是立即翻开
+	KanUraDora     bool // 是否计算杠里宝牌（里宝牌只对立直和牌者计算）
+}
+
+// DefaultGameRule 返回默认规则（与天凤规则一致）
+func DefaultGameRule() *GameRule {
+	return &GameRule{
+		MultipleYakuman: true,
+		DoubleYakuman:   false,
+		KazoeYakuman:    true,
+		Kyushukyuhai:    true,
+		Suufonrenda:     true,
+		SuuchaRiichi:    true,
+		Suukaikan:       true,
+		Sanchahou:       true,
+		KanDoraDelayed:  true,
+		KanUraDora:      true,
+	}
+}
+
+// EnableLocalYaku 启用地方役，fan 为0时使用默认番数
+func (r *GameRule) EnableLocalYaku(yaku Yaku, fan int) {
+	if r.LocalYaku == nil {
+		r.LocalYaku = make(map[Yaku]int)
+	}
+	r.LocalYaku[yaku] = fan
+}
+
+// DisableLocalYaku 停用地方役
+func (r *GameRule) DisableLocalYaku(yaku Yaku) {
+	delete(r.LocalYaku, yaku)
+}
+
+// IsLocalYakuEnabled 判断地方役是否启用
+func (r *GameRule) IsLocalYakuEnabled(yaku Yaku) bool {
+	_, ok := r.LocalYaku[yaku]
+	return ok
+}
+
+// YakuFan 按规则获取役的番数
+// 未启用的地方役返回0；地方役副露时的减番与默认番数保持一致
+func (r *GameRule) YakuFan(yaku Yaku, menzen bool) int {
+	if !IsLocalYaku(yaku) {
+		return YakuFan(yaku, menzen)
+	}
+	fan, ok := r.LocalYaku[yaku]
+	if !ok {
+		return 0
+	}
+	if fan <= 0 {
+		fan = GetFanCount(yaku)
+	}
+	if menzen {
+		return fan
+	}
+	open := GetFanCountOpen(yaku)
+	if open == 0 {
+		return 0
+	}
+	if reduced := fan - (GetFanCount(yaku) - open); reduced > 0 {
+		return reduced
+	}
+	return 1
+}
@@ -47,6 +47,7 @@ func (pr *PaipuReplayer) Init() {
 func (pr *PaipuReplayer) InitWithYama(yamaLog []int) {
 	pr.Table.InitBeforePlaying()
 	pr.Table.ImportYama(yamaLog)
+	pr.Table.InitDora()
 	pr.Table.DrawTenhouStyle()
 	pr.Table.SortPlayerHands()
 	pr.CurrentRound = 0
@@ -68,7 +69,7 @@ func (pr *PaipuReplayer) GetSelfActions(playerIdx int) []*SelfAction {
 	}
 
 	if player.IsMenzen() && !player.IsRiichi() {
-		actions = append(actions, player.GetRiichi()...)
+		actions = append(actions, player.GetRiichiWithTable(pr.Table)...)
 	}
 
 	if pr.CurrentRound == 0 {
@@ -105,13 +106,13 @@ func (pr *PaipuReplayer) GetResponseActions(playerIdx int, action BaseAction, ti
 		if chanAnkanActions != nil {
 			actions = append(actions, chanAnkanActions...)
 		}
-		chankanActions := player.GetChankan(tile)
+		chankanActions := player.GetChankan(pr.Table, tile)
 		if chankanActions != nil {
 			actions = append(actions, chankanActions...)
 		}
 	case Kan:
 		// 可以选择抢杠或荣和
-		chankanActions := player.GetChankan(tile)
+		chankanActions := player.GetChankan(pr.Table, tile)
 		if chankanActions != nil {
 			actions = append(actions, chankanActions...)
 		}
@@ -161,12 +162,9 @@ func (pr *PaipuReplayer) ExecuteAction(playerIdx int, action *Action) {
 			player.River.PushBack(riverTile)
 			player.RemoveFromHand(tile)
 
-			// 更新振听状态
-			if player.IsRiichi() && player.IsTenpai() {
-				if BaseTileInSlice(tile.Tile, player.AtariTiles) {
-					player.Minogashi()
-				}
-			}
+			// 更新听牌与舍张振听
+			player.UpdateAtariTiles()
+			player.UpdateFuritenRiver()
 
 			// 记录弃牌日志
 			if pr.Table != nil && pr.Table.GameLog != nil {
@@ -209,7 +207,7 @@ func (pr *PaipuReplayer) ExecuteAction(playerIdx int, action *Action) {
 			isSevenPair := IsSevenPairPattern(baseTiles)
 			result := counter.CalculateScore(pr.Table, player, baseTiles, player.CallGroups, baseTiles[len(baseTiles)-1], isSevenPair)
 			if result != nil {
-				gameResult := GenerateResultTsumo(playerIdx, result)
+				gameResult := GenerateResultTsumo(playerIdx, pr.Table.Oya, result)
 				pr.ResultLog = append(pr.ResultLog, gameResult)
 				var players [4]*Player
 				for i := 0; i < 4; i++ {
@@ -263,42 +261,29 @@ func (pr *PaipuReplayer) ExecuteAction(playerIdx int, action *Action) {
 }
 
 // SimulateToCompletion 模拟游戏直到完成
+// 由牌桌的流程驱动，每个阶段都选择第一个选项（自主行动为打牌或杠，响应为 Pass）
 func (pr *PaipuReplayer) SimulateToCompletion() *GameResult {
-	for pr.GameState == 0 && len(pr.Table.Yama) > 14 {
-		currentPlayer := pr.Table.Players[pr.Table.Turn]
+	t := pr.Table
+	if t.Phase == PhaseUninitialized {
+		t.GameStart()
+	}
 
-		// 摸一张牌（如果不是初始状态）
-		if pr.CurrentRound > 0 {
-			pr.Table.DrawNormal(pr.Table.Turn)
-			currentPlayer.UpdateAtariTiles()
+	for !t.IsGameOver() {
+		if t.Phase <= P4Action {
+			pr.Paipu = append(pr.Paipu, t.SelfActions[0].GetAction())
+			pr.CurrentRound++
 		}
-
-		pr.CurrentRound++
-
-		// 获取可能的行动
-		selfActions := pr.GetSelfActions(pr.Table.Turn)
-		if len(selfActions) > 0 {
-			// 选择第一个行动（弃牌）
-			selectedAction := selfActions[0]
-			pr.ExecuteAction(pr.Table.Turn, &selectedAction.Action)
+		pr.SelectionLog = append(pr.SelectionLog, 0)
+		if !t.MakeSelection(0) {
+			break
 		}
-
-		// 推进回合
-		pr.Table.NextTurn((pr.Table.Turn + 1) % 4)
 	}
 
-	// 如果游戏还没有结束，产生流局
-	if pr.GameState == 0 {
-		gameResult := GenerateResultNotile()
-		pr.ResultLog = append(pr.ResultLog, gameResult)
-		pr.GameState = 1
-		return gameResult
+	pr.GameState = 1
+	if t.Result != nil {
+		pr.ResultLog = append(pr.ResultLog, t.Result)
+		return t.Result
 	}
-
-	if len(pr.ResultLog) > 0 {
-		return pr.ResultLog[len(pr.ResultLog)-1]
-	}
-
 	return nil
 }
 
@@ -399,6 +384,21 @@ func IsSevenPairPattern(tiles []BaseTile) bool {
 	return pairCount == 7
 }
 
+// IsKokushiPattern 判断14张牌是否为国士无双牌型（13种幺九牌齐全且其中一种成对）
+func IsKokushiPattern(tiles []BaseTile) bool {
+	if len(tiles) != 14 {
+		return false
+	}
+	counts := make(map[BaseTile]int)
+	for _, tile := range tiles {
+		if !IsYaochuhai(tile) {
+			return false
+		}
+		counts[tile]++
+	}
+	return len(counts) == 13
+}
+
 // FindInTiles 在Tile数组中查找第一个匹配的索引
 func FindInTiles(tiles []*Tile, target *Tile) int {
 	for i, tile := range tiles {
@@ -0,0 +1,384 @@
+package mahjong
+
+import "math/bits"
+
+// Hand34 以34种牌的张数表示的手牌
+// 是值类型，可以直接复制；增减一张牌为 O(1)，分析时不需要分配切片
+type Hand34 struct {
+	Counts [NBaseTiles]uint8 // 各种牌的张数
+	Red    [3]uint8          // 万、筒、索的赤5张数（包含在 Counts 中）
+}
+
+// NewHand34 由实际的牌创建 Hand34
+func NewHand34(tiles []*Tile) Hand34 {
+	var h Hand34
+	for _, t := range tiles {
+		h.AddTile(t)
+	}
+	return h
+}
+
+// NewHand34FromBaseTiles 由基础牌创建 Hand34（不含赤5）
+func NewHand34FromBaseTiles(tiles []BaseTile) Hand34 {
+	var h Hand34
+	for _, t := range tiles {
+		h.Counts[t]++
+	}
+	return h
+}
+
+// ParseHand34 解析 "123m406p11z" 形式的牌
+func ParseHand34(s string) (Hand34, error) {
+	tiles, err := ParseTiles(s)
+	if err != nil {
+		return Hand34{}, err
+	}
+	return NewHand34(tiles), nil
+}
+
+// redIndex 返回赤5在 Red 中的下标，不是5的数牌时返回 -1
+func redIndex(tile BaseTile) int {
+	if IsRedFiveKind(tile) {
+		return int(tile) / 9
+	}
+	return -1
+}
+
+// Add 加入一张牌
+func (h *Hand34) Add(tile BaseTile) {
+	h.Counts[tile]++
+}
+
+// AddTile 加入一张实际的牌，记录赤5
+func (h *Hand34) AddTile(t *Tile) {
+	h.Counts[t.Tile]++
+	if t.RedDora {
+		h.Red[redIndex(t.Tile)]++
+	}
+}
+
+// Remove 移除一张牌，优先移除非赤的牌，没有这种牌时返回 false
+func (h *Hand34) Remove(tile BaseTile) bool {
+	if h.Counts[tile] == 0 {
+		return false
+	}
+	if r := redIndex(tile); r >= 0 && h.Red[r] == h.Counts[tile] {
+		h.Red[r]--
+	}
+	h.Counts[tile]--
+	return true
+}
+
+// RemoveTile 移除一张实际的牌，没有这张牌时返回 false
+func (h *Hand34) RemoveTile(t *Tile) bool {
+	if !t.RedDora {
+		if r := redIndex(t.Tile); r >= 0 && h.Red[r] == h.Counts[t.Tile] {
+			return false
+		}
+		return h.Remove(t.Tile)
+	}
+	r := redIndex(t.Tile)
+	if r < 0 || h.Red[r] == 0 {
+		return false
+	}
+	h.Red[r]--
+	h.Counts[t.Tile]--
+	return true
+}
+
+// Count 返回某种牌的张数
+func (h *Hand34) Count(tile BaseTile) int {
+	return int(h.Counts[tile])
+}
+
+// CountRed 返回赤5的张数
+func (h *Hand34) CountRed() int {
+	return int(h.Red[0] + h.Red[1] + h.Red[2])
+}
+
+// Len 返回牌的总张数
+func (h *Hand34) Len() int {
+	n := 0
+	for _, c := range h.Counts {
+		n += int(c)
+	}
+	return n
+}
+
+// BaseTiles 返回排好序的基础牌
+func (h *Hand34) BaseTiles() []BaseTile {
+	tiles := make([]BaseTile, 0, h.Len())
+	for tile, c := range h.Counts {
+		for i := uint8(0); i < c; i++ {
+			tiles = append(tiles, BaseTile(tile))
+		}
+	}
+	return tiles
+}
+
+// Tiles 返回排好序的实际的牌，赤5排在同种牌的最前
+// 牌的 ID 与 ParseTiles 的分配方式相同，超过4张的牌 ID 为 -1
+func (h *Hand34) Tiles() []*Tile {
+	alloc := &tileAllocator{}
+	tiles := make([]*Tile, 0, h.Len())
+	for tile, c := range h.Counts {
+		bt := BaseTile(tile)
+		red := 0
+		if r := redIndex(bt); r >= 0 {
+			red = int(h.Red[r])
+		}
+		for i := 0; i < int(c); i++ {
+			t, err := alloc.alloc(bt, i < red)
+			if err != nil {
+				t = &Tile{Tile: bt, RedDora: i < red, ID: -1}
+			}
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
+// String 返回紧凑的文字表示，例如 "123m406p11z"
+func (h *Hand34) String() string {
+	return FormatTiles(h.Tiles())
+}
+
+// GetAllCompletedTiles 与 TileSplitter.GetAllCompletedTiles 相同，返回所有拆分（顺序也相同）的副本
+// 只读时使用 Decompose 可以避免复制
+func (h *Hand34) GetAllCompletedTiles() []CompletedTiles {
+	shared := h.Decompose()
+	result := make([]CompletedTiles, len(shared))
+	for i := range shared {
+		result[i] = cloneCompletedTiles(&shared[i])
+	}
+	return result
+}
+
+// canStartShuntsu 判断下标为 pos 的牌能否作为顺子的第一张（1~7的数牌）
+func canStartShuntsu(pos int) bool {
+	return pos < int(_1z) && pos%9 < 7
+}
+
+// IsCompleteNormal 判断是否为一般形的和牌形（雀头加面子，不含七对子与国士无双）
+func (h *Hand34) IsCompleteNormal() bool {
+	if h.Len()%3 != 2 {
+		return false
+	}
+	counts := h.Counts
+	for tile := range counts {
+		if counts[tile] < 2 {
+			continue
+		}
+		counts[tile] -= 2
+		if allMentsu(counts) {
+			return true
+		}
+		counts[tile] += 2
+	}
+	return false
+}
+
+// allMentsu 判断张数能否全部拆成面子（从小到大，先取刻子再取顺子）
+func allMentsu(counts [NBaseTiles]uint8) bool {
+	for pos := 0; pos < NBaseTiles; pos++ {
+		c := counts[pos]
+		if c >= 3 {
+			c -= 3
+		}
+		if c == 0 {
+			continue
+		}
+		if !canStartShuntsu(pos) || counts[pos+1] < c || counts[pos+2] < c {
+			return false
+		}
+		counts[pos+1] -= c
+		counts[pos+2] -= c
+	}
+	return true
+}
+
+// FindAllWinTiles 返回加入后成为一般形和牌形的牌（3n+1张时有效），由和牌查表得到
+// 与 AgariMask 一样，已有4张的牌不算
+func (h *Hand34) FindAllWinTiles() []BaseTile {
+	var winTiles []BaseTile
+	for mask := h.normalAgariMask(); mask != 0; mask &= mask - 1 {
+		winTiles = append(winTiles, BaseTile(bits.TrailingZeros64(mask)))
+	}
+	return winTiles
+}
+
+// Shanten 返回向听数（-1为和牌，0为听牌），没有副露时同时考虑七对子与国士无双
+// nCallGroups 为副露的面子数，手牌应为 3n+1 或 3n+2 张
+func (h *Hand34) Shanten(nCallGroups int) int {
+	shanten := h.NormalShanten(nCallGroups)
+	if nCallGroups == 0 {
+		shanten = min(shanten, h.ChiitoiShanten(), h.KokushiShanten())
+	}
+	return shanten
+}
+
+// NormalShanten 返回一般形的向听数，由各花色的查表合并得到
+// 张数多于 3*(4-nCallGroups)+2 或某一花色不在表中时使用 searchNormalShanten
+func (h *Hand34) NormalShanten(nCallGroups int) int {
+	need := 4 - nCallGroups
+	if h.Len() <= 3*need+2 {
+		if blocks, ok := h.blockEntries(); ok {
+			return shantenFromBlocks(&blocks, need)
+		}
+	}
+	return h.searchNormalShanten(nCallGroups)
+}
+
+// searchNormalShanten 枚举面子与搭子求一般形的向听数，不使用查表
+func (h *Hand34) searchNormalShanten(nCallGroups int) int {
+	s := shantenSearch{counts: h.Counts, need: 4 - nCallGroups, left: h.Len()}
+	s.best = 2 * s.need
+	for tile := range s.counts {
+		if s.counts[tile] >= 2 {
+			s.counts[tile] -= 2
+			s.left -= 2
+			s.search(0, 0, 0, 1)
+			s.counts[tile] += 2
+			s.left += 2
+		}
+	}
+	s.search(0, 0, 0, 0)
+	return s.best
+}
+
+// shantenSearch 枚举面子与搭子求一般形向听数
+type shantenSearch struct {
+	counts [NBaseTiles]uint8
+	need   int // 需要的面子数
+	left   int // counts 中剩余的张数
+	best   int
+}
+
+func (s *shantenSearch) search(pos, mentsu, taatsu, head int) {
+	c := &s.counts
+	for pos < NBaseTiles && c[pos] == 0 {
+		pos++
+	}
+	if pos == NBaseTiles {
+		taatsu = min(taatsu, s.need-mentsu)
+		s.best = min(s.best, 2*s.need-2*mentsu-taatsu-head)
+		return
+	}
+	// 剩下的牌每3张最多再减少2向听，达不到当前最优时剪枝
+	if bound := 2*s.need - 2*mentsu - min(taatsu, s.need-mentsu) - head - 2*s.left/3; bound >= s.best {
+		return
+	}
+	if c[pos] >= 3 {
+		c[pos] -= 3
+		s.left -= 3
+		s.search(pos, mentsu+1, taatsu, head)
+		c[pos] += 3
+		s.left += 3
+	}
+	if canStartShuntsu(pos) && c[pos+1] > 0 && c[pos+2] > 0 {
+		c[pos]--
+		c[pos+1]--
+		c[pos+2]--
+		s.left -= 3
+		s.search(pos, mentsu+1, taatsu, head)
+		c[pos]++
+		c[pos+1]++
+		c[pos+2]++
+		s.left += 3
+	}
+	if mentsu+taatsu < s.need {
+		if c[pos] >= 2 {
+			c[pos] -= 2
+			s.left -= 2
+			s.search(pos, mentsu, taatsu+1, head)
+			c[pos] += 2
+			s.left += 2
+		}
+		if pos < int(_1z) && pos%9 < 8 && c[pos+1] > 0 {
+			c[pos]--
+			c[pos+1]--
+			s.left -= 2
+			s.search(pos, mentsu, taatsu+1, head)
+			c[pos]++
+			c[pos+1]++
+			s.left += 2
+		}
+		if canStartShuntsu(pos) && c[pos+2] > 0 {
+			c[pos]--
+			c[pos+2]--
+			s.left -= 2
+			s.search(pos, mentsu, taatsu+1, head)
+			c[pos]++
+			c[pos+2]++
+			s.left += 2
+		}
+	}
+	// 剩下的这种牌作为孤张
+	saved := c[pos]
+	c[pos] = 0
+	s.left -= int(saved)
+	s.search(pos+1, mentsu, taatsu, head)
+	c[pos] = saved
+	s.left += int(saved)
+}
+
+// ChiitoiShanten 返回七对子的向听数
+func (h *Hand34) ChiitoiShanten() int {
+	pairs, kinds := 0, 0
+	for _, c := range h.Counts {
+		if c > 0 {
+			kinds++
+		}
+		if c >= 2 {
+			pairs++
+		}
+	}
+	return 6 - pairs + max(0, 7-kinds)
+}
+
+// yaochuTiles 十三种幺九牌
+var yaochuTiles = [...]BaseTile{_1m, _9m, _1p, _9p, _1s, _9s, _1z, _2z, _3z, _4z, _5z, _6z, _7z}
+
+// KokushiShanten 返回国士无双的向听数
+func (h *Hand34) KokushiShanten() int {
+	kinds, pair := 0, 0
+	for _, tile := range yaochuTiles {
+		if h.Counts[tile] == 0 {
+			continue
+		}
+		kinds++
+		if h.Counts[tile] >= 2 {
+			pair = 1
+		}
+	}
+	return 13 - kinds - pair
+}
+
+// Ukeire 返回摸到后向听数减少的牌（手牌为 3n+1 张时有效）与这些牌剩余的总张数
+// 剩余张数为4减去手牌与 visible（已见的牌，可以为空）中的张数
+func (h *Hand34) Ukeire(nCallGroups int, visible *Hand34) ([]BaseTile, int) {
+	var tiles []BaseTile
+	total := 0
+	if h.Len()%3 != 1 {
+		return tiles, total
+	}
+	current := h.Shanten(nCallGroups)
+	for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+		if h.Counts[tile] >= 4 {
+			continue
+		}
+		h.Counts[tile]++
+		improved := h.Shanten(nCallGroups) < current
+		h.Counts[tile]--
+		if !improved {
+			continue
+		}
+		tiles = append(tiles, tile)
+		remain := 4 - int(h.Counts[tile])
+		if visible != nil {
+			remain -= int(visible.Counts[tile])
+		}
+		total += max(0, remain)
+	}
+	return tiles, total
+}
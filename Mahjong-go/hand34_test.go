@@ -0,0 +1,298 @@
+package mahjong
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func mustHand34(t testing.TB, s string) Hand34 {
+	t.Helper()
+	h, err := ParseHand34(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// randomHand34 从一副牌中随机取 n 张；complete 为 true 时先由随机的面子与一个雀头组成，不足的张数随机补齐
+func randomHand34(rng *rand.Rand, n int, complete bool) Hand34 {
+	var h Hand34
+	if complete {
+		for h.Len()+3 <= n-2 {
+			tile := BaseTile(rng.Intn(NBaseTiles))
+			if rng.Intn(2) == 0 && canStartShuntsu(int(tile)) {
+				if h.Counts[tile] < 4 && h.Counts[tile+1] < 4 && h.Counts[tile+2] < 4 {
+					h.Add(tile)
+					h.Add(tile + 1)
+					h.Add(tile + 2)
+				}
+			} else if h.Counts[tile] <= 1 {
+				h.Add(tile)
+				h.Add(tile)
+				h.Add(tile)
+			}
+		}
+		for h.Len()+2 <= n {
+			if tile := BaseTile(rng.Intn(NBaseTiles)); h.Counts[tile] <= 2 {
+				h.Add(tile)
+				h.Add(tile)
+				break
+			}
+		}
+	}
+	for h.Len() < n {
+		if tile := BaseTile(rng.Intn(NBaseTiles)); h.Counts[tile] < 4 {
+			h.Add(tile)
+		}
+	}
+	return h
+}
+
+func TestHand34Conversions(t *testing.T) {
+	h := mustHand34(t, "406m11z0p")
+	if h.Len() != 6 || h.Count(_5m) != 1 || h.CountRed() != 2 || h.Count(_1z) != 2 {
+		t.Fatalf("unexpected hand %+v", h)
+	}
+	if got := h.String(); got != "406m0p11z" {
+		t.Fatalf("formatted as %q", got)
+	}
+	tiles := h.Tiles()
+	if back := NewHand34(tiles); back != h {
+		t.Fatalf("round trip through tiles gave %+v", back)
+	}
+	if !slices.Equal(h.BaseTiles(), []BaseTile{_4m, _5m, _6m, _5p, _1z, _1z}) {
+		t.Fatalf("unexpected base tiles %v", h.BaseTiles())
+	}
+
+	// 普通的5优先于赤5移除
+	h = mustHand34(t, "055m")
+	if !h.Remove(_5m) || h.Red[0] != 1 || !h.Remove(_5m) || h.Red[0] != 1 || !h.Remove(_5m) || h.Red[0] != 0 {
+		t.Fatalf("plain fives should be removed before the red one: %+v", h)
+	}
+	if h.Remove(_5m) {
+		t.Fatalf("removing from an empty kind should fail")
+	}
+	h = mustHand34(t, "05m")
+	if h.RemoveTile(&Tile{Tile: _5p, RedDora: true}) || !h.RemoveTile(&Tile{Tile: _5m, RedDora: true}) || h.CountRed() != 0 {
+		t.Fatalf("unexpected red removal %+v", h)
+	}
+	if h.RemoveTile(&Tile{Tile: _5m, RedDora: true}) || !h.RemoveTile(&Tile{Tile: _5m}) || h.Len() != 0 {
+		t.Fatalf("unexpected plain removal %+v", h)
+	}
+}
+
+func TestHand34MatchesTileSplitter(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		h := randomHand34(rng, 14, i%2 == 0)
+		want := NewTileSplitter().GetAllCompletedTiles(h.BaseTiles())
+		got := h.GetAllCompletedTiles()
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d decompositions, expected %d", h.String(), len(got), len(want))
+		}
+		for j := range want {
+			if got[j].String() != want[j].String() {
+				t.Fatalf("%s: decomposition %d is %s, expected %s", h.String(), j, got[j].String(), want[j].String())
+			}
+		}
+		if h.IsCompleteNormal() != (len(want) > 0) {
+			t.Fatalf("%s: IsCompleteNormal disagrees with the splitter", h.String())
+		}
+
+		// 已有4张的牌不算和牌
+		h13 := randomHand34(rng, 13, i%2 == 0)
+		var wantWin []BaseTile
+		for _, tile := range FindAllWinTiles(h13.BaseTiles()) {
+			if h13.Counts[tile] < 4 {
+				wantWin = append(wantWin, tile)
+			}
+		}
+		if got := h13.FindAllWinTiles(); !slices.Equal(got, wantWin) {
+			t.Fatalf("%s: win tiles %v, expected %v", h13.String(), got, wantWin)
+		}
+	}
+}
+
+func TestHand34Shanten(t *testing.T) {
+	for _, c := range []struct {
+		hand    string
+		calls   int
+		shanten int
+	}{
+		{"123456789m1234p", 0, 0},
+		{"123456789m12344p", 0, -1},
+		{"1122m3344p5566s7z", 0, 0},
+		{"19m19p19s1234567z", 0, 0},
+		{"19m19p19s123456z5m", 0, 1},
+		{"147m258p369s1234z", 0, 6},
+		{"1234m", 3, 0},
+		{"12m", 4, 0},
+		{"13m58p", 3, 1},
+	} {
+		h := mustHand34(t, c.hand)
+		if got := h.Shanten(c.calls); got != c.shanten {
+			t.Fatalf("%s with %d calls: shanten %d, expected %d", c.hand, c.calls, got, c.shanten)
+		}
+	}
+}
+
+// TestHand34ShantenConsistent 向听数为 s 的手牌摸一张后最少为 s-1，且至少有一张能达到 s-1；
+// 14张的向听数等于打出一张后的最小向听数（和了时打出后为听牌）
+func TestHand34ShantenConsistent(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 150; i++ {
+		h := randomHand34(rng, 13, i%3 == 0)
+		s := h.Shanten(0)
+		if s == 0 != (len(h.FindAllWinTiles()) > 0 || h.ChiitoiShanten() == 0 || h.KokushiShanten() == 0) {
+			t.Fatalf("%s: tenpai disagrees with the win tiles", h.String())
+		}
+		best := s + 1
+		for draw := BaseTile(0); draw < NBaseTiles; draw++ {
+			if h.Counts[draw] == 4 {
+				continue
+			}
+			h.Add(draw)
+			drawn := h.Shanten(0)
+			if drawn < s-1 {
+				t.Fatalf("%s: shanten dropped by more than one", h.String())
+			}
+			best = min(best, drawn)
+			discarded := s + 1
+			for discard := BaseTile(0); discard < NBaseTiles; discard++ {
+				if h.Remove(discard) {
+					discarded = min(discarded, h.Shanten(0))
+					h.Add(discard)
+				}
+			}
+			if discarded != max(drawn, 0) {
+				t.Fatalf("%s: shanten %d but the best discard gives %d", h.String(), drawn, discarded)
+			}
+			h.Remove(draw)
+		}
+		if best != s-1 {
+			t.Fatalf("%s: shanten %d but the best draw gives %d", h.String(), s, best)
+		}
+	}
+}
+
+func TestHand34Ukeire(t *testing.T) {
+	h := mustHand34(t, "123456789m1234p")
+	tiles, total := h.Ukeire(0, nil)
+	if !slices.Equal(tiles, []BaseTile{_1p, _4p}) || total != 6 {
+		t.Fatalf("ukeire %v (%d), expected [1p 4p] (6)", tiles, total)
+	}
+	visible := mustHand34(t, "44p")
+	if _, total := h.Ukeire(0, &visible); total != 4 {
+		t.Fatalf("visible tiles should reduce ukeire to 4, got %d", total)
+	}
+	full := mustHand34(t, "12m")
+	if tiles, _ := full.Ukeire(4, nil); len(tiles) != 0 {
+		t.Fatalf("two tiles are not 3n+1, got %v", tiles)
+	}
+}
+
+// TestNormalShantenTableMatchesSearch 查表的一般形向听数与不查表的搜索相同，查表的和牌判断与切片版本相同
+func TestNormalShantenTableMatchesSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 3000; i++ {
+		calls := rng.Intn(5)
+		n := 3*(4-calls) + 1 + rng.Intn(2)
+		h := randomHand34(rng, n, i%2 == 0)
+		if got, want := h.NormalShanten(calls), h.searchNormalShanten(calls); got != want {
+			t.Fatalf("%s with %d calls: table gives %d, search gives %d", h.String(), calls, got, want)
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		h := randomHand34(rng, 3*rng.Intn(5)+1, i%2 == 0)
+		var want []BaseTile
+		for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+			if h.Counts[tile] == 4 {
+				continue
+			}
+			h.Add(tile)
+			if h.IsCompleteNormal() {
+				want = append(want, tile)
+			}
+			h.Counts[tile]--
+		}
+		if got := h.FindAllWinTiles(); !slices.Equal(got, want) {
+			t.Fatalf("%s: win tiles %v, expected %v", h.String(), got, want)
+		}
+	}
+	// 单骑已有4张的牌不算听牌
+	single := mustHand34(t, "1111m234p")
+	if got := single.FindAllWinTiles(); len(got) != 0 {
+		t.Fatalf("1111m234p: win tiles %v, expected none", got)
+	}
+	// 一种花色集中14张
+	for _, hand := range []string{"11112222333345m", "11112345678999p", "11122233344455s", "1112223334445z"} {
+		h := mustHand34(t, hand)
+		if got, want := h.NormalShanten(0), h.searchNormalShanten(0); got != want {
+			t.Fatalf("%s: table gives %d, search gives %d", hand, got, want)
+		}
+	}
+}
+
+func BenchmarkShanten(b *testing.B) {
+	rng := rand.New(rand.NewSource(3))
+	hands := make([]Hand34, 64)
+	for i := range hands {
+		hands[i] = randomHand34(rng, 13, false)
+	}
+	b.Run("Hand34", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			h.Shanten(0)
+		}
+	})
+	b.Run("NormalTable", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			h.NormalShanten(0)
+		}
+	})
+	b.Run("NormalSearch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := hands[i%len(hands)]
+			h.searchNormalShanten(0)
+		}
+	})
+	b.Run("CalculateRoundToWin", func(b *testing.B) {
+		tiles := make([][]*Tile, len(hands))
+		for i := range hands {
+			tiles[i] = hands[i].Tiles()
+		}
+		for i := 0; i < b.N; i++ {
+			CalculateRoundToWin(tiles[i%len(tiles)], 0)
+		}
+	})
+}
+
+func BenchmarkFindAllWinTiles(b *testing.B) {
+	rng := rand.New(rand.NewSource(4))
+	hands := make([]Hand34, 64)
+	for i := range hands {
+		hands[i] = randomHand34(rng, 13, true)
+	}
+	for _, name := range []string{"Hand34", "BaseTiles"} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h := hands[i%len(hands)]
+				if name == "Hand34" {
+					h.FindAllWinTiles()
+				} else {
+					FindAllWinTiles(h.BaseTiles())
+				}
+			}
+		})
+	}
+}
+
+func ExampleHand34() {
+	h, _ := ParseHand34("123456789m1234p")
+	tiles, total := h.Ukeire(0, nil)
+	fmt.Println(h.Shanten(0), tiles, total)
+	// Output: 0 [9 12] 6
+}
@@ -0,0 +1,611 @@
+package mahjong
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 牌的文字表示
+//
+//	牌:     "123m406p789s1122z"，数字后跟花色 m/p/s/z，0 表示赤5
+//	副露:   "[345s]" 吃（鸣入的牌写在最前）、"[555p]" 碰、"[5555p]" 大明杠，来源未知
+//	        "(chi 3-4-5s from kamicha)"、"(pon 0-5-5p from toimen)"、"(kan 1-1-1-1z from shimocha)"、
+//	        "(kakan 5-5-5-5m from toimen)"、"(ankan 9-9-9-9s)"，鸣入的牌写在最前
+//	手牌:   "123m406p11z [555p] (chi 3-4-5s from kamicha)"
+//	局面:   "<手牌> +9s tsumo riichi ippatsu round:E seat:S dora:3m ura:1z"，见 ParseSituation
+
+// 副露的来源，与 ExecuteNakiWithTiles 的 relativePosition 一致
+const (
+	FromUnknown  = -1 // 来源未知
+	FromSelf     = 0  // 暗杠
+	FromShimocha = 1  // 下家
+	FromToimen   = 2  // 对家
+	FromKamicha  = 3  // 上家
+)
+
+var fromNames = [...]string{"self", "shimocha", "toimen", "kamicha"}
+
+var windNames = [...]string{"E", "S", "W", "N"}
+
+// NotationError 牌的文字表示解析错误
+type NotationError struct {
+	Input string // 出错的输入
+	Pos   int    // 出错位置（字节下标）
+	Msg   string // 错误描述
+}
+
+func (e *NotationError) Error() string {
+	return fmt.Sprintf("notation %q: %s at position %d", e.Input, e.Msg, e.Pos)
+}
+
+// tileAllocator 为解析出的牌分配 ID（牌种*4+序号，赤5占序号0，与 InitRedDora3 一致），并检查张数
+type tileAllocator struct {
+	taken [NBaseTiles][4]bool
+	red   [NBaseTiles]bool
+}
+
+func (a *tileAllocator) alloc(tile BaseTile, red bool) (*Tile, error) {
+	// 赤5只能占用序号0，普通的5优先使用序号1~3
+	order := []int{0, 1, 2, 3}
+	if red {
+		order = order[:1]
+	} else if IsRedFiveKind(tile) {
+		order = []int{1, 2, 3, 0}
+	}
+	for _, index := range order {
+		if !a.taken[tile][index] {
+			a.taken[tile][index] = true
+			a.red[tile] = a.red[tile] || red
+			return &Tile{Tile: tile, RedDora: red, ID: int(tile)*4 + index}, nil
+		}
+	}
+	if red && a.red[tile] {
+		return nil, fmt.Errorf("more than one red %s", BaseTileToString(tile))
+	}
+	return nil, fmt.Errorf("more than four %s", BaseTileToString(tile))
+}
+
+// IsRedFiveKind 判断牌种是否可能为赤宝牌（数牌的5）
+func IsRedFiveKind(tile BaseTile) bool {
+	return tile == _5m || tile == _5p || tile == _5s
+}
+
+// parseTileRun 解析 "406p11z" 形式的牌，start 为 s 在整个输入中的偏移
+func parseTileRun(input, s string, start int, alloc *tileAllocator) ([]*Tile, error) {
+	var tiles []*Tile
+	var digits []int // 等待花色的数字所在位置
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, i)
+		case c == 'm' || c == 'p' || c == 's' || c == 'z':
+			if len(digits) == 0 {
+				return nil, &NotationError{input, start + i, fmt.Sprintf("suit %q without numbers", c)}
+			}
+			for _, pos := range digits {
+				number := s[pos]
+				if number == '0' && c == 'z' || c == 'z' && number > '7' {
+					return nil, &NotationError{input, start + pos, fmt.Sprintf("no such tile %c%c", number, c)}
+				}
+				tile, red := Char2ToBaseTile(number, c)
+				t, err := alloc.alloc(tile, red)
+				if err != nil {
+					return nil, &NotationError{input, start + pos, err.Error()}
+				}
+				tiles = append(tiles, t)
+			}
+			digits = digits[:0]
+		default:
+			return nil, &NotationError{input, start + i, fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	if len(digits) > 0 {
+		return nil, &NotationError{input, start + len(s), "numbers without a suit"}
+	}
+	return tiles, nil
+}
+
+// ParseTiles 解析 "123m406p11z" 形式的牌，0 表示赤5
+// 牌的 ID 按牌种*4+序号分配（赤5为序号0），同种牌超过4张或同花色出现两张赤5时返回错误
+func ParseTiles(s string) ([]*Tile, error) {
+	return parseTileRun(s, s, 0, &tileAllocator{})
+}
+
+// ParseBaseTiles 解析 "123m406p11z" 形式的牌，赤5视为普通的5
+func ParseBaseTiles(s string) ([]BaseTile, error) {
+	tiles, err := ParseTiles(s)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertTilesToBaseTiles(tiles), nil
+}
+
+// ParseTile 解析单张牌，例如 "0p"
+func ParseTile(s string) (*Tile, error) {
+	tiles, err := ParseTiles(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiles) != 1 {
+		return nil, &NotationError{s, 0, fmt.Sprintf("expected one tile, got %d", len(tiles))}
+	}
+	return tiles[0], nil
+}
+
+// FormatTiles 以紧凑形式输出牌（保持顺序，相邻的同花色牌共用花色），与 ParseTiles 互逆
+func FormatTiles(tiles []*Tile) string {
+	var sb strings.Builder
+	for i, t := range tiles {
+		s := t.String()
+		sb.WriteByte(s[0])
+		if i+1 == len(tiles) || tiles[i+1].Tile/9 != t.Tile/9 {
+			sb.WriteByte(s[1])
+		}
+	}
+	return sb.String()
+}
+
+// FormatBaseTiles 以紧凑形式输出基础牌，与 ParseBaseTiles 互逆
+func FormatBaseTiles(tiles []BaseTile) string {
+	physical := make([]*Tile, len(tiles))
+	for i, bt := range tiles {
+		physical[i] = &Tile{Tile: bt}
+	}
+	return FormatTiles(physical)
+}
+
+// Meld 一组副露
+type Meld struct {
+	Type  BaseAction // Chi、Pon、Kan（大明杠）、KaKan 或 AnKan
+	Tiles []*Tile    // 副露的牌，明副露时 Tiles[0] 为鸣入的牌
+	From  int        // 来源（FromSelf、FromShimocha、FromToimen、FromKamicha 或 FromUnknown）
+}
+
+// CallGroup 转换为计分使用的鸣牌组
+func (m *Meld) CallGroup() CallGroup {
+	tiles := ConvertTilesToBaseTiles(m.Tiles)
+	group := CallGroup{Tiles: tiles, IsOpen: m.Type != AnKan, RedDora: CountRedDora(m.Tiles)}
+	switch m.Type {
+	case Chi:
+		group.Type = Shuntsu
+		sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+		for group.Take < len(tiles)-1 && tiles[group.Take] != m.Tiles[0].Tile {
+			group.Take++
+		}
+	case Pon:
+		group.Type = Koutsu
+	default:
+		group.Type = Kantsu
+	}
+	if m.Type != Chi && m.Type != AnKan {
+		// 与 ExecuteNakiWithTiles 一致：上家在左，对家在中，下家在右
+		switch m.From {
+		case FromShimocha:
+			group.Take = 2
+		case FromToimen:
+			group.Take = 1
+		}
+	}
+	return group
+}
+
+// String 返回副露的文字表示，来源未知的碰与大明杠为紧凑形式，与 ParseMeld 互逆
+func (m *Meld) String() string {
+	if m.From == FromUnknown && (m.Type == Pon || m.Type == Kan) {
+		return "[" + FormatTiles(m.Tiles) + "]"
+	}
+	numbers := make([]string, len(m.Tiles))
+	for i, t := range m.Tiles {
+		numbers[i] = t.String()[:1]
+	}
+	s := "(" + meldKeyword(m.Type) + " " + strings.Join(numbers, "-") + m.Tiles[0].String()[1:]
+	if m.From != FromUnknown && m.Type != AnKan {
+		s += " from " + fromNames[m.From]
+	}
+	return s + ")"
+}
+
+func meldKeyword(action BaseAction) string {
+	switch action {
+	case Chi:
+		return "chi"
+	case Pon:
+		return "pon"
+	case Kan:
+		return "kan"
+	case KaKan:
+		return "kakan"
+	case AnKan:
+		return "ankan"
+	}
+	return ""
+}
+
+// ParseMeld 解析一组副露，例如 "[555p]" 或 "(chi 3-4-5s from kamicha)"
+func ParseMeld(s string) (*Meld, error) {
+	return parseMeld(s, s, 0, &tileAllocator{})
+}
+
+func parseMeld(input, s string, start int, alloc *tileAllocator) (*Meld, error) {
+	fail := func(pos int, format string, args ...any) error {
+		return &NotationError{input, start + pos, fmt.Sprintf(format, args...)}
+	}
+	if len(s) < 2 {
+		return nil, fail(0, "empty meld")
+	}
+	switch {
+	case s[0] == '[' && s[len(s)-1] == ']':
+		tiles, err := parseTileRun(input, s[1:len(s)-1], start+1, alloc)
+		if err != nil {
+			return nil, err
+		}
+		m := &Meld{Tiles: tiles, From: FromUnknown}
+		base := ConvertTilesToBaseTiles(tiles)
+		switch {
+		case len(tiles) == 3 && IsKoutsu(base):
+			m.Type = Pon
+		case len(tiles) == 3 && isSequence(base):
+			// 吃只能来自上家
+			m.Type, m.From = Chi, FromKamicha
+		case len(tiles) == 4 && IsKantsu(base):
+			m.Type = Kan
+		default:
+			return nil, fail(0, "%s is not a chi, pon or kan", FormatTiles(tiles))
+		}
+		return m, nil
+	case s[0] == '(' && s[len(s)-1] == ')':
+	default:
+		return nil, fail(0, "a meld must be written as [...] or (...)")
+	}
+
+	fields := strings.Fields(s[1 : len(s)-1])
+	pos := func(field string) int {
+		return strings.Index(s, field)
+	}
+	if len(fields) == 0 {
+		return nil, fail(0, "empty meld")
+	}
+	m := &Meld{From: FromUnknown}
+	switch fields[0] {
+	case "chi":
+		m.Type = Chi
+	case "pon":
+		m.Type = Pon
+	case "kan", "minkan", "daiminkan":
+		m.Type = Kan
+	case "kakan", "shouminkan":
+		m.Type = KaKan
+	case "ankan":
+		m.Type, m.From = AnKan, FromSelf
+	default:
+		return nil, fail(pos(fields[0]), "unknown meld type %q", fields[0])
+	}
+	if len(fields) < 2 {
+		return nil, fail(len(s)-1, "missing tiles")
+	}
+	tiles, err := parseTileRun(input, strings.ReplaceAll(fields[1], "-", ""), start+pos(fields[1]), alloc)
+	if err != nil {
+		return nil, err
+	}
+	m.Tiles = tiles
+
+	rest := fields[2:]
+	if len(rest) > 0 {
+		if m.Type == AnKan {
+			return nil, fail(pos(rest[0]), "ankan has no source")
+		}
+		if len(rest) != 2 || rest[0] != "from" {
+			return nil, fail(pos(rest[0]), "expected \"from <kamicha|toimen|shimocha>\"")
+		}
+		from := -1
+		for i := FromShimocha; i <= FromKamicha; i++ {
+			if rest[1] == fromNames[i] {
+				from = i
+			}
+		}
+		if from < 0 {
+			return nil, fail(pos(rest[1]), "unknown source %q", rest[1])
+		}
+		m.From = from
+	}
+
+	base := ConvertTilesToBaseTiles(tiles)
+	switch m.Type {
+	case Chi:
+		if len(tiles) != 3 || !isSequence(base) {
+			return nil, fail(pos(fields[1]), "chi needs three consecutive tiles")
+		}
+		if m.From != FromUnknown && m.From != FromKamicha {
+			return nil, fail(pos(rest[1]), "chi can only be called from kamicha")
+		}
+		m.From = FromKamicha
+	case Pon:
+		if len(tiles) != 3 || !IsKoutsu(base) {
+			return nil, fail(pos(fields[1]), "pon needs three identical tiles")
+		}
+	default:
+		if len(tiles) != 4 || !IsKantsu(base) {
+			return nil, fail(pos(fields[1]), "kan needs four identical tiles")
+		}
+	}
+	return m, nil
+}
+
+// isSequence 判断三张牌排序后是否为顺子
+func isSequence(tiles []BaseTile) bool {
+	sorted := append([]BaseTile(nil), tiles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return IsShuntsu(sorted)
+}
+
+// Hand 手牌与副露
+type Hand struct {
+	Tiles []*Tile // 门前的牌
+	Melds []*Meld // 副露
+}
+
+// String 返回手牌的文字表示，与 ParseHand 互逆
+func (h *Hand) String() string {
+	parts := make([]string, 0, 1+len(h.Melds))
+	if len(h.Tiles) > 0 {
+		parts = append(parts, FormatTiles(h.Tiles))
+	}
+	for _, m := range h.Melds {
+		parts = append(parts, m.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// CallGroups 返回副露对应的鸣牌组
+func (h *Hand) CallGroups() []CallGroup {
+	groups := make([]CallGroup, 0, len(h.Melds))
+	for _, m := range h.Melds {
+		groups = append(groups, m.CallGroup())
+	}
+	return groups
+}
+
+// ParseHand 解析手牌，例如 "123m406p11z [555p] (chi 3-4-5s from kamicha)"
+func ParseHand(s string) (*Hand, error) {
+	hand := &Hand{}
+	err := scanNotation(s, &tileAllocator{}, hand, func(token string, pos int) error {
+		return &NotationError{s, pos, fmt.Sprintf("unexpected %q", token)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hand, nil
+}
+
+// scanNotation 依次读取手牌中的牌与副露，其他单词交给 other 处理
+func scanNotation(s string, alloc *tileAllocator, hand *Hand, other func(token string, pos int) error) error {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '[' || c == '(':
+			closer := byte(']')
+			if c == '(' {
+				closer = ')'
+			}
+			end := strings.IndexByte(s[i:], closer)
+			if end < 0 {
+				return &NotationError{s, i, fmt.Sprintf("unclosed %q", c)}
+			}
+			m, err := parseMeld(s, s[i:i+end+1], i, alloc)
+			if err != nil {
+				return err
+			}
+			hand.Melds = append(hand.Melds, m)
+			i += end + 1
+		default:
+			end := strings.IndexAny(s[i:], " \t[(")
+			if end < 0 {
+				end = len(s) - i
+			}
+			token := s[i : i+end]
+			if c >= '0' && c <= '9' {
+				tiles, err := parseTileRun(s, token, i, alloc)
+				if err != nil {
+					return err
+				}
+				hand.Tiles = append(hand.Tiles, tiles...)
+			} else if err := other(token, i); err != nil {
+				return err
+			}
+			i += end
+		}
+	}
+	return nil
+}
+
+// Situation 一个和牌局面
+type Situation struct {
+	Hand              *Hand   // 和牌前的手牌
+	WinTile           *Tile   // 和牌
+	Tsumo             bool    // 是否自摸
+	Riichi            bool    // 立直
+	DoubleRiichi      bool    // 两立直
+	Ippatsu           bool    // 一发
+	FirstRound        bool    // 第一巡内无人鸣牌（天和、地和、人和）
+	GameWind          Wind    // 场风
+	SeatWind          Wind    // 自风，东为庄家
+	DoraIndicators    []*Tile // 宝牌指示牌
+	UraDoraIndicators []*Tile // 里宝牌指示牌
+}
+
+var situationFlags = []struct {
+	name string
+	flag func(s *Situation) *bool
+}{
+	{"tsumo", func(s *Situation) *bool { return &s.Tsumo }},
+	{"riichi", func(s *Situation) *bool { return &s.Riichi }},
+	{"double-riichi", func(s *Situation) *bool { return &s.DoubleRiichi }},
+	{"ippatsu", func(s *Situation) *bool { return &s.Ippatsu }},
+	{"first-round", func(s *Situation) *bool { return &s.FirstRound }},
+}
+
+// ParseSituation 解析和牌局面，例如 "234m567m345p67s55p +8s riichi round:E seat:S dora:4m"
+//
+//	+<牌>          和牌（必需）
+//	tsumo / ron    自摸或荣和（默认荣和）
+//	riichi、double-riichi、ippatsu、first-round
+//	round:<ESWN>   场风（默认东），seat:<ESWN> 自风（默认东，即庄家）
+//	dora:<牌>      宝牌指示牌，ura:<牌> 里宝牌指示牌
+func ParseSituation(s string) (*Situation, error) {
+	alloc := &tileAllocator{}
+	situation := &Situation{Hand: &Hand{}}
+	err := scanNotation(s, alloc, situation.Hand, func(token string, pos int) error {
+		fail := func(format string, args ...any) error {
+			return &NotationError{s, pos, fmt.Sprintf(format, args...)}
+		}
+		if strings.HasPrefix(token, "+") {
+			if situation.WinTile != nil {
+				return fail("more than one winning tile")
+			}
+			tiles, err := parseTileRun(s, token[1:], pos+1, alloc)
+			if err != nil {
+				return err
+			}
+			if len(tiles) != 1 {
+				return fail("expected one winning tile")
+			}
+			situation.WinTile = tiles[0]
+			return nil
+		}
+		if token == "ron" {
+			situation.Tsumo = false
+			return nil
+		}
+		for _, f := range situationFlags {
+			if token == f.name {
+				*f.flag(situation) = true
+				return nil
+			}
+		}
+		key, value, ok := strings.Cut(token, ":")
+		if !ok {
+			return fail("unknown word %q", token)
+		}
+		switch key {
+		case "round", "seat":
+			wind := -1
+			for i, name := range windNames {
+				if value == name {
+					wind = i
+				}
+			}
+			if wind < 0 {
+				return fail("unknown wind %q, expected E, S, W or N", value)
+			}
+			if key == "round" {
+				situation.GameWind = Wind(wind)
+			} else {
+				situation.SeatWind = Wind(wind)
+			}
+		case "dora", "ura":
+			tiles, err := parseTileRun(s, value, pos+len(key)+1, alloc)
+			if err != nil {
+				return err
+			}
+			if key == "dora" {
+				situation.DoraIndicators = append(situation.DoraIndicators, tiles...)
+			} else {
+				situation.UraDoraIndicators = append(situation.UraDoraIndicators, tiles...)
+			}
+		default:
+			return fail("unknown key %q", key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if situation.WinTile == nil {
+		return nil, &NotationError{s, len(s), "missing winning tile (+<tile>)"}
+	}
+	return situation, nil
+}
+
+// String 返回局面的文字表示，与 ParseSituation 互逆
+func (s *Situation) String() string {
+	parts := []string{}
+	if hand := s.Hand.String(); hand != "" {
+		parts = append(parts, hand)
+	}
+	parts = append(parts, "+"+s.WinTile.String())
+	if !s.Tsumo {
+		parts = append(parts, "ron")
+	}
+	for _, f := range situationFlags {
+		if *f.flag(s) {
+			parts = append(parts, f.name)
+		}
+	}
+	parts = append(parts, "round:"+windNames[s.GameWind], "seat:"+windNames[s.SeatWind])
+	if len(s.DoraIndicators) > 0 {
+		parts = append(parts, "dora:"+FormatTiles(s.DoraIndicators))
+	}
+	if len(s.UraDoraIndicators) > 0 {
+		parts = append(parts, "ura:"+FormatTiles(s.UraDoraIndicators))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ErrNoYaku 局面不是有役的和牌
+var ErrNoYaku = errors.New("not a winning hand with yaku")
+
+// Score 按规则 rule（为空时使用默认规则）计算局面的得分
+// 手牌张数不正确时返回错误，不能和牌或无役时返回 ErrNoYaku
+func (s *Situation) Score(rule *GameRule) (*ScoreCounterResult, error) {
+	if n := len(s.Hand.Tiles) + 3*len(s.Hand.Melds); n != 13 {
+		return nil, fmt.Errorf("hand has %d tiles (counting each meld as 3), expected 13", n)
+	}
+	table := NewTable()
+	if rule != nil {
+		table.Rule = rule
+	}
+	table.GameWind = s.GameWind
+	table.DoraIndicator = s.DoraIndicators
+	table.UraDoraIndicator = s.UraDoraIndicators
+	table.NActiveDora = len(s.DoraIndicators)
+
+	player := NewPlayer(s.SeatWind, s.SeatWind == East)
+	player.Hand = append(player.Hand, s.Hand.Tiles...)
+	player.CallGroups = s.Hand.CallGroups()
+	for _, m := range s.Hand.Melds {
+		if m.Type != AnKan {
+			player.Menzen = false
+		}
+	}
+	player.Riichi = s.Riichi
+	player.DoubleRiichi = s.DoubleRiichi
+	player.Ippatsu = s.Ippatsu
+	player.FirstRound = s.FirstRound
+	if s.Tsumo {
+		player.Hand = append(player.Hand, s.WinTile)
+	}
+	table.Players[table.Turn] = player
+
+	tiles := append(ConvertTilesToBaseTiles(s.Hand.Tiles), s.WinTile.Tile)
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+	counter := &ScoreCounter{RonTileRed: !s.Tsumo && s.WinTile.RedDora}
+	result := counter.CalculateScore(table, player, tiles, player.CallGroups, s.WinTile.Tile, IsSevenPairPattern(tiles))
+	if result == nil {
+		return nil, ErrNoYaku
+	}
+	return result, nil
+}
+
+// ScoreNotation 解析局面并计算得分，例如 ScoreNotation("123m456p789s11z22z +2z tsumo", nil)
+func ScoreNotation(s string, rule *GameRule) (*ScoreCounterResult, error) {
+	situation, err := ParseSituation(s)
+	if err != nil {
+		return nil, err
+	}
+	return situation.Score(rule)
+}
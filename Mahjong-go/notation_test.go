@@ -0,0 +1,221 @@
+package mahjong
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mustBaseTiles 解析牌的文字表示，出错时终止测试
+func mustBaseTiles(t *testing.T, s string) []BaseTile {
+	t.Helper()
+	tiles, err := ParseBaseTiles(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tiles
+}
+
+func TestParseTiles(t *testing.T) {
+	tiles, err := ParseTiles("1m2m3m406p7z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BaseTile{_1m, _2m, _3m, _4p, _5p, _6p, _7z}
+	if !slices.Equal(ConvertTilesToBaseTiles(tiles), want) {
+		t.Fatalf("got %v, expected %v", ConvertTilesToBaseTiles(tiles), want)
+	}
+	if CountRedDora(tiles) != 1 || !tiles[4].RedDora || tiles[4].ID != int(_5p)*4 {
+		t.Fatalf("0p should be the red five with ID %d, got %+v", int(_5p)*4, tiles[4])
+	}
+	if got := FormatTiles(tiles); got != "123m406p7z" {
+		t.Fatalf("formatted as %q", got)
+	}
+
+	// 普通的5不占用赤5的 ID
+	fives, _ := ParseTiles("5550m")
+	ids := make(map[int]bool)
+	for _, tile := range fives {
+		ids[tile.ID] = true
+	}
+	if len(ids) != 4 || fives[3].ID != int(_5m)*4 {
+		t.Fatalf("unexpected IDs for 5550m: %+v", fives)
+	}
+}
+
+func TestNotationRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"11123456789999m",
+		"19m19p19s1234567z",
+		"406m",
+	} {
+		tiles, err := ParseTiles(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatTiles(tiles); got != s {
+			t.Fatalf("%q formatted as %q", s, got)
+		}
+	}
+
+	for _, s := range []string{
+		"[555p]",
+		"[5555z]",
+		"(chi 4-3-5s from kamicha)",
+		"(pon 0-5-5p from toimen)",
+		"(kan 1-1-1-1z from shimocha)",
+		"(kakan 5-5-5-0m from toimen)",
+		"(kakan 2-2-2-2m)",
+		"(ankan 9-9-9-9s)",
+	} {
+		m, err := ParseMeld(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.String(); got != s {
+			t.Fatalf("%q formatted as %q", s, got)
+		}
+	}
+
+	for _, s := range []string{
+		"234567m345p67s55p +8s ron riichi round:E seat:S dora:4m ura:9p",
+		"1112345678999m +9m tsumo first-round round:S seat:E",
+		"23m67855p [666z] (chi 3-4-5s from kamicha) +4m ron round:E seat:W",
+	} {
+		situation, err := ParseSituation(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := situation.String(); got != s {
+			t.Fatalf("%q formatted as %q", s, got)
+		}
+	}
+}
+
+func TestParseNotationCanonical(t *testing.T) {
+	m, err := ParseMeld("[345s]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != Chi || m.From != FromKamicha || m.String() != "(chi 3-4-5s from kamicha)" {
+		t.Fatalf("unexpected chi %+v", m)
+	}
+	hand, err := ParseHand("1m2m3m 0p [555s](ankan 1-1-1-1z)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hand.String(); got != "123m0p [555s] (ankan 1-1-1-1z)" {
+		t.Fatalf("unexpected hand %q", got)
+	}
+	groups := hand.CallGroups()
+	if len(groups) != 2 || groups[0].Type != Koutsu || !groups[0].IsOpen || groups[1].Type != Kantsu || groups[1].IsOpen {
+		t.Fatalf("unexpected call groups %+v", groups)
+	}
+
+	// 鸣入的牌的位置与 ExecuteNakiWithTiles 一致
+	chi, _ := ParseMeld("(chi 4-3-5s from kamicha)")
+	if g := chi.CallGroup(); !slices.Equal(g.Tiles, []BaseTile{_3s, _4s, _5s}) || g.Take != 1 {
+		t.Fatalf("unexpected chi group %+v", g)
+	}
+	pon, _ := ParseMeld("(pon 0-5-5p from shimocha)")
+	if g := pon.CallGroup(); g.Take != 2 || g.RedDora != 1 {
+		t.Fatalf("unexpected pon group %+v", g)
+	}
+}
+
+func TestNotationErrors(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{"123", 3, "numbers without a suit"},
+		{"12m8z", 3, "no such tile 8z"},
+		{"m", 0, "without numbers"},
+		{"11111m", 4, "more than four 1m"},
+		{"0m0m", 2, "more than one red 5m"},
+		{"12x", 2, "unexpected character"},
+	} {
+		_, err := ParseTiles(c.input)
+		var notationErr *NotationError
+		if !errors.As(err, &notationErr) || notationErr.Pos != c.pos || !strings.Contains(notationErr.Msg, c.msg) {
+			t.Fatalf("%q: expected %q at %d, got %v", c.input, c.msg, c.pos, err)
+		}
+	}
+
+	for _, c := range []struct {
+		input string
+		msg   string
+	}{
+		{"[124m]", "not a chi, pon or kan"},
+		{"(chi 3-4-5s from toimen)", "only be called from kamicha"},
+		{"(ankan 1-1-1-1z from toimen)", "ankan has no source"},
+		{"(pon 1-1-2z)", "three identical tiles"},
+		{"(peng 1-1-1z)", "unknown meld type"},
+		{"(pon 1-1-1z from nowhere)", "unknown source"},
+		{"555p", "must be written"},
+	} {
+		if _, err := ParseMeld(c.input); err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Fatalf("%q: expected %q, got %v", c.input, c.msg, err)
+		}
+	}
+
+	for _, c := range []struct {
+		input string
+		msg   string
+	}{
+		{"123m (pon 1-1-1z", "unclosed"},
+		{"123m", "missing winning tile"},
+		{"123m +1m +2m", "more than one winning tile"},
+		{"123m +1m seat:X", "unknown wind"},
+		{"123m +1m dealer", "unknown word"},
+		{"1111m +1m", "more than four 1m"},
+		{"123m +1m dora:1m1m1m", "more than four 1m"},
+	} {
+		if _, err := ParseSituation(c.input); err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Fatalf("%q: expected %q, got %v", c.input, c.msg, err)
+		}
+	}
+}
+
+func TestScoreNotation(t *testing.T) {
+	// 立直、平和、断幺、宝牌1，子家荣和 4番30符
+	res, err := ScoreNotation("234m567m345p67s55p +8s riichi round:E seat:S dora:4m ura:9p", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, y := range []Yaku{RiichiYaku, Pinfu, Tanyao} {
+		if !hasYaku(res.Yakus, y) {
+			t.Fatalf("expected %v in %v", y, res.Yakus)
+		}
+	}
+	if yakuFan(res, DoraYaku) != 1 || hasYaku(res.Yakus, UradoraYaku) || res.Fan != 4 || res.Fu != 30 || res.RonScore != 7700 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// 副露的赤5与荣和的赤5都计入赤宝牌
+	res, err = ScoreNotation("34m678p22s (pon 0-5-5p from toimen) [777s] +0m round:E seat:S", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasYaku(res.Yakus, Tanyao) || hasYaku(res.Yakus, Pinfu) || yakuFan(res, AkadoraYaku) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// 庄家天和
+	res, err = ScoreNotation("1112345678999m +9m tsumo first-round round:E seat:E", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasYaku(res.Yakus, Tenhou) || !res.IsYakuman() {
+		t.Fatalf("expected tenhou, got %+v", res)
+	}
+
+	if _, err := ScoreNotation("123m456p789s23s55z +1s", nil); !errors.Is(err, ErrNoYaku) {
+		t.Fatalf("expected ErrNoYaku, got %v", err)
+	}
+	if _, err := ScoreNotation("123m +1m", nil); err == nil || !strings.Contains(err.Error(), "expected 13") {
+		t.Fatalf("expected a tile count error, got %v", err)
+	}
+}
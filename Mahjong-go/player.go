@@ -2,6 +2,7 @@ package mahjong
 
 import (
 	"fmt"
+	"math/bits"
 	"sort"
 	"strings"
 )
@@ -79,15 +80,18 @@ type Player struct {
 	// 基本状态
 	DoubleRiichi bool // 是否为两立直
 	Riichi       bool // 是否已立直
+	OpenRiichi   bool // 是否为开立直（公开手牌立直）
 	Menzen       bool // 是否为门前清（未鸣牌）
 	Wind         Wind // 玩家风向
 	Oya          bool // 是否为庄家
 	Score        int  // 点数
 
 	// 陷阱（复合状态）
-	FuritenRound  bool // 回合振听（本回合弃过同牌）
-	FuritenRiver  bool // 河振听（历史弃过同牌）
-	FuritenRiichi bool // 立直振听（立直后弃过同牌）
+	FuritenRound      bool  // 同巡振听（见逃和牌，到自己下次摸牌为止）
+	FuritenRiver      bool  // 舍张振听（自己的河中有听的牌）
+	FuritenRiichi     bool  // 立直振听（立直后见逃和牌，到本局结束为止）
+	FuritenRoundTile  *Tile // 造成同巡振听的牌
+	FuritenRiichiTile *Tile // 造成立直振听的牌
 
 	// 其他标记
 	Ippatsu    bool // 是否有一发权
@@ -124,7 +128,7 @@ func (p *Player) IsRiichi() bool {
 	return p.Riichi || p.DoubleRiichi
 }
 
-// IsFuriten 判断是否处于振听状态
+// IsFuriten 判断是否处于振听状态，振听的种类与原因见 ExplainFuriten
 func (p *Player) IsFuriten() bool {
 	return p.FuritenRound || p.FuritenRiver || p.FuritenRiichi
 }
@@ -197,37 +201,45 @@ func (p *Player) TenpaiToString() string {
 }
 
 // UpdateAtariTiles 更新听牌列表
+// 手中已有4张的牌不能作为听牌（不能听第5张）
 func (p *Player) UpdateAtariTiles() {
-	p.AtariTiles = p.AtariTiles[:0]
-
-	// 获取手中牌的BaseTile列表
-	baseTiles := ConvertTilesToBaseTiles(p.Hand)
+	h := NewHand34(p.Hand)
+	mask := h.AgariMask()
+	p.AtariTiles = h.AppendAgariTiles(make([]BaseTile, 0, bits.OnesCount64(mask)))
+}
 
-	// 对于每一张可能的牌，检查是否能胡
-	for tile := BaseTile(0); tile < 34; tile++ {
-		testTiles := make([]BaseTile, len(baseTiles)+1)
-		copy(testTiles, baseTiles)
-		testTiles[len(testTiles)-1] = tile
-		sort.Slice(testTiles, func(i, j int) bool { return testTiles[i] < testTiles[j] })
+// GetFalseAtariHai 获取手中已有4张、不能作为听牌的牌
+func (p *Player) GetFalseAtariHai() []BaseTile {
+	return fourCopiesOf(ConvertTilesToBaseTiles(p.Hand))
+}
 
-		// 如果能成形胡牌，则加入听牌列表
-		if CanWinWithTiles(testTiles) {
-			p.AtariTiles = append(p.AtariTiles, tile)
+// fourCopiesOf 返回牌列表中有4张的牌
+func fourCopiesOf(tiles []BaseTile) []BaseTile {
+	var counts [NBaseTiles]int
+	result := make([]BaseTile, 0)
+	for _, tile := range tiles {
+		counts[tile]++
+		if counts[tile] == 4 {
+			result = append(result, tile)
 		}
 	}
+	return result
 }
 
-// UpdateFuritenRiver 更新河振听状态
+// UpdateFuritenRiver 更新舍张振听状态
+// 听牌改变后重新判断，被鸣走的牌也算在自己的河中
 func (p *Player) UpdateFuritenRiver() {
-	// 检查是否弃过听牌的牌
-	for _, atariTile := range p.AtariTiles {
-		for _, riverTile := range p.River.River {
-			if riverTile.Tile.Tile == atariTile && riverTile.Remain {
-				p.FuritenRiver = true
-				return
-			}
+	p.FuritenRiver = p.riverFuritenTile() != nil
+}
+
+// riverFuritenTile 返回自己河中第一张听的牌，没有时返回 nil
+func (p *Player) riverFuritenTile() *Tile {
+	for _, riverTile := range p.River.River {
+		if IsIn(p.AtariTiles, riverTile.Tile.Tile) {
+			return riverTile.Tile
 		}
 	}
+	return nil
 }
 
 // RemoveAtariTiles 移除某个特定的听牌
@@ -240,22 +252,34 @@ func (p *Player) RemoveAtariTiles(tile BaseTile) {
 	}
 }
 
-// Minogashi 当立直状态下过掉自己的听牌时调用
-func (p *Player) Minogashi() {
+// Minogashi 见逃和牌时调用：立直后为立直振听，否则为同巡振听
+func (p *Player) Minogashi(tile *Tile) {
 	if p.IsRiichi() {
-		p.FuritenRiichi = true
-	} else {
+		if !p.FuritenRiichi {
+			p.FuritenRiichi = true
+			p.FuritenRiichiTile = tile
+		}
+		return
+	}
+	if !p.FuritenRound {
 		p.FuritenRound = true
+		p.FuritenRoundTile = tile
 	}
 }
 
+// ClearFuritenRound 解除同巡振听（轮到自己时调用）
+func (p *Player) ClearFuritenRound() {
+	p.FuritenRound = false
+	p.FuritenRoundTile = nil
+}
+
 // GetKakan 获取可能的加杠列表
 func (p *Player) GetKakan() []*SelfAction {
 	actions := make([]*SelfAction, 0)
 	for _, group := range p.CallGroups {
 		if group.Type == Koutsu && len(group.Tiles) == 3 {
-			if idx := FindInTiles(p.Hand, &Tile{Tile: group.Tiles[0]}); idx >= 0 {
-				action := &SelfAction{Action{Action: KaKan, CorrespondTiles: []*Tile{p.Hand[idx]}}}
+			if tiles := GetNCopies(p.Hand, group.Tiles[0], 1); len(tiles) == 1 {
+				action := &SelfAction{Action{Action: KaKan, CorrespondTiles: tiles}}
 				actions = append(actions, action)
 			}
 		}
@@ -284,10 +308,10 @@ func (p *Player) GetAnkan() []*SelfAction {
 }
 
 // GetDiscard 获取可能的弃牌列表
-// 去重弃牌，避免重复弃牌选项
+// 去重弃牌，避免重复弃牌选项（赤5与普通5分别生成）；吃碰后不能打出食替的牌
 func (p *Player) GetDiscard(afterChipon bool) []*SelfAction {
 	actions := make([]*SelfAction, 0)
-	seen := make(map[BaseTile]bool)
+	seen := make(map[int]bool)
 
 	// 创建一个临时副本用于排序
 	handTiles := make([]*Tile, len(p.Hand))
@@ -303,10 +327,13 @@ func (p *Player) GetDiscard(afterChipon bool) []*SelfAction {
 
 	// 遍历排序后的手牌，为每种牌添加一个弃牌选项
 	for _, tile := range handTiles {
-		if !seen[tile.Tile] {
+		if afterChipon && p.isKuikae(tile.Tile) {
+			continue
+		}
+		if !seen[tile.physicalKey()] {
 			action := &SelfAction{Action{Action: Discard, CorrespondTiles: []*Tile{tile}}}
 			actions = append(actions, action)
-			seen[tile.Tile] = true
+			seen[tile.physicalKey()] = true
 		}
 	}
 
@@ -314,13 +341,19 @@ func (p *Player) GetDiscard(afterChipon bool) []*SelfAction {
 }
 
 // GetTsumo 获取自摸胡牌的选项
-// 只有听牌时才能自摸，并且需要有役
+// 摸到的牌（手牌最后一张）在听牌列表中且有役时才能自摸（启用十三不塔时第一巡也可自摸）
 func (p *Player) GetTsumo(table *Table) []*SelfAction {
-	if p.IsTenpai() {
+	if len(p.Hand) == 0 {
+		return nil
+	}
+	winTile := p.Hand[len(p.Hand)-1].Tile
+	shiisanpuutaa := table != nil && table.Rule != nil && p.FirstRound &&
+		table.Rule.IsLocalYakuEnabled(Shiisanpuutaa)
+	if IsIn(p.AtariTiles, winTile) || shiisanpuutaa {
 		counter := &ScoreCounter{}
 		baseTiles := ConvertTilesToBaseTiles(p.Hand)
 		isSevenPair := IsSevenPairPattern(baseTiles)
-		result := counter.CalculateScore(table, p, baseTiles, p.CallGroups, baseTiles[len(baseTiles)-1], isSevenPair)
+		result := counter.CalculateScore(table, p, baseTiles, p.CallGroups, winTile, isSevenPair)
 		if result != nil {
 			action := &SelfAction{Action{Action: Tsumo, CorrespondTiles: []*Tile{}}}
 			return []*SelfAction{action}
@@ -330,31 +363,25 @@ func (p *Player) GetTsumo(table *Table) []*SelfAction {
 }
 
 // GetRiichi 获取立直的选项
-// 立直后自动生成可弃牌的列表
+//
+// Deprecated: 不检查牌山剩余张数，请使用 GetRiichiWithTable
 func (p *Player) GetRiichi() []*SelfAction {
-	// 与 C++ 实现一致：立直必须是门清并且尚未立直，返回针对每个可弃牌的立直动作
-	if p.IsRiichi() || !p.IsMenzen() {
-		return nil
-	}
+	return p.GetRiichiWithTable(nil)
+}
 
-	// 只有处于听牌状态才可立直
-	if !p.IsTenpai() {
+// GetRiichiWithTable 获取立直的选项
+// 每个打出后仍然听牌的牌生成一个立直宣言动作
+func (p *Player) GetRiichiWithTable(table *Table) []*SelfAction {
+	if !p.CanDeclareRiichi(table) {
 		return nil
 	}
 
 	actions := make([]*SelfAction, 0)
-	// 利用已有的弃牌选项生成立直对应的弃牌（去重）
-	discards := p.GetDiscard(false)
-	seen := make(map[BaseTile]bool)
-	for _, d := range discards {
-		if len(d.CorrespondTiles) == 0 {
-			continue
-		}
+	for _, d := range p.GetDiscard(false) {
 		tile := d.CorrespondTiles[0]
-		if seen[tile.Tile] {
+		if !p.IsTenpaiAfterDiscard(tile.Tile) {
 			continue
 		}
-		seen[tile.Tile] = true
 		action := &SelfAction{Action{Action: Riichi, CorrespondTiles: []*Tile{tile}}}
 		actions = append(actions, action)
 	}
@@ -364,6 +391,21 @@ func (p *Player) GetRiichi() []*SelfAction {
 	return actions
 }
 
+// CanDeclareRiichi 判断是否满足立直宣言的前提
+// 门清、尚未立直、持有至少1000点（供托），且牌山至少剩余4张
+func (p *Player) CanDeclareRiichi(table *Table) bool {
+	if p.IsRiichi() || !p.IsMenzen() {
+		return false
+	}
+	if p.Score < 1000 {
+		return false
+	}
+	if table != nil && table.GetRemainTile() < 4 {
+		return false
+	}
+	return true
+}
+
 // GetKyushukyuhai 获取九种九牌流局的选项
 func (p *Player) GetKyushukyuhai() []*SelfAction {
 	yaochuTiles := make(map[BaseTile]bool)
@@ -373,79 +415,201 @@ func (p *Player) GetKyushukyuhai() []*SelfAction {
 		}
 	}
 
+	if !p.FirstRound || len(p.Hand) != 14 {
+		// 只有第一巡且未被鸣牌打断时可以宣告
+		return nil
+	}
 	if len(yaochuTiles) >= 9 {
-		action := &SelfAction{Action{Action: Kyushukyuhai, CorrespondTiles: p.Hand}}
+		tiles := append([]*Tile(nil), p.Hand...)
+		action := &SelfAction{Action{Action: Kyushukyuhai, CorrespondTiles: tiles}}
 		return []*SelfAction{action}
 	}
 	return nil
 }
 
 // GetRon 生成荣和行动
+// 振听或无役时不能荣和；只生成行动，不改变振听状态（见逃由牌桌在响应结束时处理）
 func (p *Player) GetRon(table *Table, tile *Tile) []*ResponseAction {
-	if !p.IsFuriten() {
-		action := &ResponseAction{Action{Action: Ron, CorrespondTiles: []*Tile{tile}}}
-		return []*ResponseAction{action}
+	if p.IsFuriten() {
+		return nil
 	}
-	return nil
+	if !IsIn(p.AtariTiles, tile.Tile) {
+		return nil
+	}
+	if p.ronScore(table, tile) == nil {
+		return nil
+	}
+	action := &ResponseAction{Action{Action: Ron, CorrespondTiles: []*Tile{tile}}}
+	return []*ResponseAction{action}
+}
+
+// ronScore 计算荣和该牌时的得分，无役时返回 nil
+func (p *Player) ronScore(table *Table, tile *Tile) *ScoreCounterResult {
+	tiles := append(ConvertTilesToBaseTiles(p.Hand), tile.Tile)
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+	counter := &ScoreCounter{RonTileRed: tile.RedDora}
+	return counter.CalculateScore(table, p, tiles, p.CallGroups, tile.Tile, IsSevenPairPattern(tiles))
 }
 
 // GetChi 生成吃的行动
+// CorrespondTiles 为手中用于吃的两张牌；吃后只能打出食替牌的情况不能吃
 func (p *Player) GetChi(tile *Tile) []*ResponseAction {
 	actions := make([]*ResponseAction, 0)
-	baseTile := tile.Tile
-	if baseTile >= _1m && baseTile <= _7m {
-		action := &ResponseAction{Action{Action: Chi, CorrespondTiles: []*Tile{tile}}}
+	if IsTsuhai(tile.Tile) {
+		return actions
+	}
+
+	// 同种牌只按是否为赤宝牌区分，如 0p6p 与 5p6p 是两种吃法
+	seen := make(map[[2]int]bool)
+	for _, pair := range p.tilePairs() {
+		t1, t2 := pair[0].Tile, pair[1].Tile
+		key := [2]int{pair[0].physicalKey(), pair[1].physicalKey()}
+		if !IsShuntsu([]BaseTile{t1, t2, tile.Tile}) || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// 吃后剩下的牌全部是食替牌时不能吃
+		var banned []BaseTile
+		switch {
+		case t2-t1 == 2:
+			// 坎张
+			banned = []BaseTile{t1 + 1}
+		case t2-t1 == 1:
+			// 两面/边张
+			if !Is1hai(t1) {
+				banned = append(banned, t1-1)
+			}
+			if !Is9hai(t2) {
+				banned = append(banned, t2+1)
+			}
+		}
+		rest := RemoveTiles(ConvertTilesToBaseTiles(p.Hand), []BaseTile{t1, t2})
+		onlyBanned := true
+		for _, t := range rest {
+			if !IsIn(banned, t) {
+				onlyBanned = false
+				break
+			}
+		}
+		if onlyBanned {
+			continue
+		}
+
+		action := &ResponseAction{Action{Action: Chi, CorrespondTiles: []*Tile{pair[0], pair[1]}}}
 		actions = append(actions, action)
 	}
 	return actions
 }
 
 // GetPon 生成碰的行动
+// CorrespondTiles 为手中用于碰的两张牌；持有赤宝牌时分别生成含与不含赤宝牌的碰法
 func (p *Player) GetPon(tile *Tile) []*ResponseAction {
-	count := 0
-	for _, handTile := range p.Hand {
-		if handTile.Tile == tile.Tile {
-			count++
-		}
-	}
-	if count >= 2 {
-		action := &ResponseAction{Action{Action: Pon, CorrespondTiles: []*Tile{tile}}}
-		return []*ResponseAction{action}
+	var actions []*ResponseAction
+	for _, tiles := range callVariants(GetNCopies(p.Hand, tile.Tile, 3), 2) {
+		actions = append(actions, &ResponseAction{Action{Action: Pon, CorrespondTiles: tiles}})
 	}
-	return nil
+	return actions
 }
 
 // GetKan 生成大明杠的行动
+// CorrespondTiles 为手中用于杠的三张牌
 func (p *Player) GetKan(tile *Tile) []*ResponseAction {
-	count := 0
-	for _, handTile := range p.Hand {
-		if handTile.Tile == tile.Tile {
-			count++
-		}
+	var actions []*ResponseAction
+	for _, tiles := range callVariants(GetNCopies(p.Hand, tile.Tile, 3), 3) {
+		actions = append(actions, &ResponseAction{Action{Action: Kan, CorrespondTiles: tiles}})
 	}
-	if count >= 3 {
-		action := &ResponseAction{Action{Action: Kan, CorrespondTiles: []*Tile{tile}}}
-		return []*ResponseAction{action}
+	return actions
+}
+
+// callVariants 枚举从候选牌中取 n 张的组合，只在赤宝牌构成不同时才视为不同的组合
+func callVariants(candidates []*Tile, n int) [][]*Tile {
+	var variants [][]*Tile
+	seen := make(map[int]bool)
+	var pick func(start int, chosen []*Tile)
+	pick = func(start int, chosen []*Tile) {
+		if len(chosen) == n {
+			// 候选牌为同一种牌，赤宝牌张数即可区分构成
+			red := CountRedDora(chosen)
+			if !seen[red] {
+				seen[red] = true
+				variants = append(variants, append([]*Tile(nil), chosen...))
+			}
+			return
+		}
+		for i := start; i < len(candidates); i++ {
+			pick(i+1, append(chosen, candidates[i]))
+		}
 	}
-	return nil
+	pick(0, make([]*Tile, 0, n))
+	return variants
 }
 
-// GetChanAnkan 生成抢暗杠的行动
+// GetChanAnkan 生成抢暗杠的行动（只有国士无双可以抢暗杠）
 func (p *Player) GetChanAnkan(tile *Tile) []*ResponseAction {
-	if !p.IsFuriten() {
-		action := &ResponseAction{Action{Action: ChanAnKan, CorrespondTiles: []*Tile{tile}}}
-		return []*ResponseAction{action}
+	if p.IsFuriten() || !IsYaochuhai(tile.Tile) || !IsIn(p.AtariTiles, tile.Tile) {
+		return nil
 	}
-	return nil
+	tiles := append(ConvertTilesToBaseTiles(p.Hand), tile.Tile)
+	if !IsKokushiPattern(tiles) {
+		return nil
+	}
+	action := &ResponseAction{Action{Action: ChanAnKan, CorrespondTiles: []*Tile{tile}}}
+	return []*ResponseAction{action}
 }
 
 // GetChankan 生成抢杠的行动
-func (p *Player) GetChankan(tile *Tile) []*ResponseAction {
-	if !p.IsFuriten() {
-		action := &ResponseAction{Action{Action: ChanKan, CorrespondTiles: []*Tile{tile}}}
-		return []*ResponseAction{action}
+// 与 GetRon 一样需要牌桌来判断场风等役
+func (p *Player) GetChankan(table *Table, tile *Tile) []*ResponseAction {
+	if p.IsFuriten() || !IsIn(p.AtariTiles, tile.Tile) {
+		return nil
 	}
-	return nil
+	if p.ronScore(table, tile) == nil {
+		return nil
+	}
+	action := &ResponseAction{Action{Action: ChanKan, CorrespondTiles: []*Tile{tile}}}
+	return []*ResponseAction{action}
+}
+
+// tilePairs 枚举手牌中所有两张牌的组合（按手牌顺序）
+func (p *Player) tilePairs() [][2]*Tile {
+	pairs := make([][2]*Tile, 0)
+	for i := 0; i < len(p.Hand); i++ {
+		for j := i + 1; j < len(p.Hand); j++ {
+			a, b := p.Hand[i], p.Hand[j]
+			if b.Tile < a.Tile {
+				a, b = b, a
+			}
+			pairs = append(pairs, [2]*Tile{a, b})
+		}
+	}
+	return pairs
+}
+
+// isKuikae 判断吃碰后打出该牌是否为食替
+func (p *Player) isKuikae(t BaseTile) bool {
+	if len(p.CallGroups) == 0 {
+		return false
+	}
+	cg := p.CallGroups[len(p.CallGroups)-1]
+	switch cg.Type {
+	case Koutsu:
+		// 碰：不能打出同一种牌
+		return cg.Tiles[0] == t
+	case Shuntsu:
+		taken := cg.Tiles[cg.Take]
+		if taken == t {
+			return true
+		}
+		// 两面吃：另一侧的牌也不能打出（如 (3)45 后打6）
+		switch cg.Take {
+		case 0:
+			return !Is9hai(cg.Tiles[2]) && taken+3 == t
+		case 2:
+			return !Is1hai(cg.Tiles[0]) && taken-3 == t
+		}
+	}
+	return false
 }
 
 // RemoveFromHand 从手中移除一张牌
@@ -464,28 +628,68 @@ func (p *Player) ExecuteAnkan(tile BaseTile) {
 	for _, t := range tiles {
 		p.RemoveFromHand(t)
 	}
-	p.CallGroups = append(p.CallGroups, CallGroup{Type: Kantsu, Tiles: []BaseTile{tile, tile, tile, tile}, IsOpen: false})
+	p.CallGroups = append(p.CallGroups, CallGroup{
+		Type: Kantsu, Tiles: []BaseTile{tile, tile, tile, tile}, IsOpen: false, RedDora: CountRedDora(tiles),
+	})
 }
 
 // ExecuteKakan 执行加杠
 func (p *Player) ExecuteKakan(tile *Tile) {
 	p.RemoveFromHand(tile)
 	for i := range p.CallGroups {
-		if p.CallGroups[i].Type == Koutsu && len(p.CallGroups[i].Tiles) == 3 && p.CallGroups[i].Tiles[0] == tile.Tile {
-			p.CallGroups[i] = CallGroup{Type: Kantsu, Tiles: []BaseTile{tile.Tile, tile.Tile, tile.Tile, tile.Tile}, IsOpen: true}
+		group := &p.CallGroups[i]
+		if group.Type == Koutsu && len(group.Tiles) == 3 && group.Tiles[0] == tile.Tile {
+			group.Type = Kantsu
+			group.Tiles = []BaseTile{tile.Tile, tile.Tile, tile.Tile, tile.Tile}
+			if tile.RedDora {
+				group.RedDora++
+			}
 			break
 		}
 	}
 }
 
 // CanWinWithTiles 判断给定的牌是否能胡牌
+// 副露后手牌不足14张时只判断一般形，七对子与国士无双只在14张时成立
+// 使用和牌查表（见 Hand34.IsAgari），不做拆分
 func CanWinWithTiles(tiles []BaseTile) bool {
-	if len(tiles) != 14 {
+	if len(tiles)%3 != 2 {
 		return false
 	}
-	splitter := GetTileSplitter()
-	completed := splitter.GetAllCompletedTiles(tiles)
-	return len(completed) > 0
+	h := NewHand34FromBaseTiles(tiles)
+	return h.IsAgari()
+}
+
+// GetAtariHai 获取听牌列表（3n+1张牌加上哪些牌可以和牌），except 中的牌不计入
+// 使用和牌查表（见 Hand34.AgariMask），已有4张的牌不会作为听牌
+func GetAtariHai(tiles []BaseTile, except []BaseTile) []BaseTile {
+	result := make([]BaseTile, 0)
+	if len(tiles)%3 != 1 {
+		return result
+	}
+	h := NewHand34FromBaseTiles(tiles)
+	mask := h.AgariMask()
+	for _, tile := range except {
+		mask &^= 1 << tile
+	}
+	for ; mask != 0; mask &= mask - 1 {
+		result = append(result, BaseTile(bits.TrailingZeros64(mask)))
+	}
+	return result
+}
+
+// ExecuteDiscard 打出一张牌并放入河中
+// number 为全场的弃牌序号计数，onRiichi 表示这张牌是立直宣言牌
+func (p *Player) ExecuteDiscard(tile *Tile, number *int, onRiichi bool, fromHand bool) {
+	p.RemoveFromHand(tile)
+	*number++
+	p.River.PushBack(RiverTile{
+		Tile:     tile,
+		Number:   *number,
+		Riichi:   p.IsRiichi() || onRiichi,
+		Remain:   true,
+		FromHand: fromHand,
+	})
 }
 
 // SortHand 对手牌进行排序
@@ -501,31 +705,51 @@ func (p *Player) SortHand() {
 }
 
 // RiichiGetAnkan 立直后的暗杠选项
-// 立直后只能进行特定的暗杠（新摸到的牌同花色）
+// 只能杠新摸到的牌，且杠后听牌不能改变，杠的牌在所有和牌拆分中都必须是刻子
 func (p *Player) RiichiGetAnkan() []*SelfAction {
 	actions := make([]*SelfAction, 0)
-	if !p.IsRiichi() {
+	if !p.IsRiichi() || len(p.Hand) == 0 {
 		return actions
 	}
 
 	// 立直后只能杠新摸到的牌
-	if len(p.Hand) > 0 {
-		lastTile := p.Hand[len(p.Hand)-1]
-		count := 0
-		for _, tile := range p.Hand {
-			if tile.Tile == lastTile.Tile {
-				count++
+	lastTile := p.Hand[len(p.Hand)-1]
+	tiles := GetNCopies(p.Hand, lastTile.Tile, 4)
+	if len(tiles) != 4 {
+		return actions
+	}
+
+	// 杠后听牌不变
+	handTiles := ConvertTilesToBaseTiles(p.Hand)
+	rest := RemoveTiles(handTiles, []BaseTile{lastTile.Tile, lastTile.Tile, lastTile.Tile, lastTile.Tile})
+	if !IsSameContainer(GetAtariHai(rest, p.GetFalseAtariHai()), p.AtariTiles) {
+		return actions
+	}
+
+	// 不改变手牌结构：摸牌前的手牌以任何听牌和牌时，杠的牌都只能拆成刻子
+	before := RemoveTile(handTiles, lastTile.Tile)
+	for _, atari := range p.AtariTiles {
+		winTiles := append(append([]BaseTile(nil), before...), atari)
+		sort.Slice(winTiles, func(i, j int) bool { return winTiles[i] < winTiles[j] })
+		for _, ct := range DecomposeTiles(winTiles) {
+			if !hasKoutsuOf(&ct, lastTile.Tile) {
+				return actions
 			}
 		}
-		if count >= 4 {
-			tiles := GetNCopies(p.Hand, lastTile.Tile, 4)
-			if len(tiles) == 4 {
-				action := &SelfAction{Action{Action: AnKan, CorrespondTiles: tiles}}
-				actions = append(actions, action)
-			}
+	}
+
+	action := &SelfAction{Action{Action: AnKan, CorrespondTiles: tiles}}
+	return append(actions, action)
+}
+
+// hasKoutsuOf 判断拆分中是否含有该牌的刻子
+func hasKoutsuOf(ct *CompletedTiles, tile BaseTile) bool {
+	for _, g := range ct.Body {
+		if g.Type == Koutsu && len(g.Tiles) > 0 && g.Tiles[0] == tile {
+			return true
 		}
 	}
-	return actions
+	return false
 }
 
 // RiichiGetDiscard 立直后的弃牌选项
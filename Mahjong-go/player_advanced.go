@@ -1,9 +1,54 @@
 package mahjong
 
-// 注：鸣牌相关方法的完整实现需要根据实际的数据结构进行调整
-// CallGroup结构中的Type字段应该是TileGroupType，而这里的BaseAction需要进行转换
+import "sort"
+
+// ExecuteNakiWithTiles 执行鸣牌操作（吃、碰、大明杠）
+// tiles 为手中参与鸣牌的牌，tile 为鸣入的牌，relativePosition 为打出者相对于鸣牌者的位置
+// （(打出者 - 鸣牌者 + 4) % 4：1为下家，2为对家，3为上家）
+func (p *Player) ExecuteNakiWithTiles(tiles []*Tile, tile *Tile, relativePosition int) {
+	p.Menzen = false
+	// 碰、杠时鸣入的牌按来源放在不同位置：上家在左，对家在中，下家在右
+	take := 0
+	switch (relativePosition%4 + 4) % 4 {
+	case 1:
+		take = 2
+	case 2:
+		take = 1
+	}
+
+	red := CountRedDora(tiles)
+	if tile.RedDora {
+		red++
+	}
+
+	switch {
+	case len(tiles) == 2 && IsKoutsu([]BaseTile{tiles[0].Tile, tiles[1].Tile, tile.Tile}):
+		p.CallGroups = append(p.CallGroups, CallGroup{
+			Type: Koutsu, Tiles: []BaseTile{tile.Tile, tile.Tile, tile.Tile}, IsOpen: true, Take: take, RedDora: red,
+		})
+	case len(tiles) == 2 && IsShuntsu([]BaseTile{tiles[0].Tile, tiles[1].Tile, tile.Tile}):
+		group := []BaseTile{tiles[0].Tile, tiles[1].Tile, tile.Tile}
+		sort.Slice(group, func(i, j int) bool { return group[i] < group[j] })
+		take = 0
+		for group[take] != tile.Tile {
+			take++
+		}
+		p.CallGroups = append(p.CallGroups, CallGroup{Type: Shuntsu, Tiles: group, IsOpen: true, Take: take, RedDora: red})
+	case len(tiles) == 3 && IsKantsu([]BaseTile{tiles[0].Tile, tiles[1].Tile, tiles[2].Tile, tile.Tile}):
+		p.CallGroups = append(p.CallGroups, CallGroup{
+			Type: Kantsu, Tiles: []BaseTile{tile.Tile, tile.Tile, tile.Tile, tile.Tile}, IsOpen: true, Take: take, RedDora: red,
+		})
+	default:
+		return
+	}
+	for _, t := range tiles {
+		p.RemoveFromHand(t)
+	}
+}
 
 // ExecuteNaki 执行鸣牌操作（统一入口）
+//
+// Deprecated: 不知道用手中哪几张牌、从哪家鸣牌，请使用 ExecuteNakiWithTiles
 func (p *Player) ExecuteNaki(tile *Tile, actionType BaseAction) {
 	switch actionType {
 	case Chi:
@@ -12,34 +57,35 @@ func (p *Player) ExecuteNaki(tile *Tile, actionType BaseAction) {
 		p.ExecutePonSimple(tile)
 	case Kan:
 		p.ExecuteKanSimple(tile)
-	default:
-		// 未知的鸣牌类型
 	}
 }
 
-// ExecuteChiSimple 执行吃牌操作（简化版）
+// ExecuteChiSimple 吃上家打出的牌，使用第一个可行的组合
+//
+// Deprecated: 请使用 GetChi 选择组合后调用 ExecuteNakiWithTiles
 func (p *Player) ExecuteChiSimple(tile *Tile) {
-	// 从手牌中移除对应的牌（使用RemoveFromHand）
-	p.RemoveFromHand(tile)
-	// 更新门前清状态
-	p.Menzen = false
+	p.executeFirstNaki(p.GetChi(tile), tile)
 }
 
-// ExecutePonSimple 执行碰牌操作（简化版）
+// ExecutePonSimple 碰一张牌，按上家打出处理
+//
+// Deprecated: 请使用 GetPon 选择组合后调用 ExecuteNakiWithTiles
 func (p *Player) ExecutePonSimple(tile *Tile) {
-	// 从手牌中移除两张同牌（使用现有的RemoveFromHand方法）
-	p.RemoveFromHand(tile)
-	p.RemoveFromHand(tile)
-	p.Menzen = false
+	p.executeFirstNaki(p.GetPon(tile), tile)
 }
 
-// ExecuteKanSimple 执行大明杠操作（简化版）
+// ExecuteKanSimple 大明杠一张牌，按上家打出处理
+//
+// Deprecated: 请使用 GetKan 后调用 ExecuteNakiWithTiles
 func (p *Player) ExecuteKanSimple(tile *Tile) {
-	// 从手牌中移除三张同牌
-	p.RemoveFromHand(tile)
-	p.RemoveFromHand(tile)
-	p.RemoveFromHand(tile)
-	p.Menzen = false
+	p.executeFirstNaki(p.GetKan(tile), tile)
+}
+
+// executeFirstNaki 用第一个可行的鸣牌组合鸣入上家打出的牌
+func (p *Player) executeFirstNaki(actions []*ResponseAction, tile *Tile) {
+	if len(actions) > 0 {
+		p.ExecuteNakiWithTiles(actions[0].CorrespondTiles, tile, 3)
+	}
 }
 
 // GetNormalXiangHuShu 计算向胡数（某个牌能让多少个玩家听牌）
@@ -68,19 +114,6 @@ func (p *Player) RemoveAtariTileByList(tiles []BaseTile) {
 	}
 }
 
-// UpdateBothFuriten 同时更新两种振听
-func (p *Player) UpdateBothFuriten() {
-	p.UpdateFuritenRiver()
-	if p.IsRiichi() {
-		// 检查立直后弃过的牌
-		for _, riverTile := range p.River.River {
-			if riverTile.Riichi {
-				p.RemoveAtariTiles(riverTile.Tile.Tile)
-			}
-		}
-	}
-}
-
 // GetMinGluedTile 获取最小粘着张（吃、碰后必须立即出牌的情况下的最小可出牌）
 func (p *Player) GetMinGluedTile() BaseTile {
 	if len(p.Hand) == 0 {
@@ -114,81 +147,48 @@ func (p *Player) GetMaxGluedTile() BaseTile {
 
 // IsTenpaiAfterDiscard 检查弃某张牌后是否仍然听牌
 func (p *Player) IsTenpaiAfterDiscard(tile BaseTile) bool {
-	// 创建临时手牌副本
-	tempHand := make([]BaseTile, 0)
-	for _, t := range p.Hand {
-		tempHand = append(tempHand, t.Tile)
-	}
-
-	// 移除该牌
-	for i, t := range tempHand {
-		if t == tile {
-			tempHand = append(tempHand[:i], tempHand[i+1:]...)
-			break
-		}
-	}
-
-	// 检查是否听牌
-	splitter := &TileSplitter{}
-	completedTiles := splitter.GetAllCompletedTiles(tempHand)
-	return len(completedTiles) > 0
+	h := NewHand34(p.Hand)
+	return h.Remove(tile) && h.AgariMask() != 0
 }
 
-// GetFuritenTiles 获取振听的牌列表
+// GetFuritenTiles 获取造成振听的牌：河中听的牌，以及见逃的和牌
 func (p *Player) GetFuritenTiles() []BaseTile {
 	furitenTiles := make([]BaseTile, 0)
-
-	if p.FuritenRiver {
-		// 历史弃过的牌
-		for _, riverTile := range p.River.River {
-			found := false
-			for _, t := range furitenTiles {
-				if t == riverTile.Tile.Tile {
-					found = true
-					break
-				}
-			}
-			if !found {
-				furitenTiles = append(furitenTiles, riverTile.Tile.Tile)
-			}
+	add := func(tile BaseTile) {
+		if !IsIn(furitenTiles, tile) {
+			furitenTiles = append(furitenTiles, tile)
 		}
 	}
 
-	if p.FuritenRound {
-		// 本轮弃过的牌（通过听牌列表的差异确定）
-		// 这需要更复杂的逻辑
+	for _, riverTile := range p.River.River {
+		if IsIn(p.AtariTiles, riverTile.Tile.Tile) {
+			add(riverTile.Tile.Tile)
+		}
+	}
+	if p.FuritenRiichi && p.FuritenRiichiTile != nil {
+		add(p.FuritenRiichiTile.Tile)
+	}
+	if p.FuritenRound && p.FuritenRoundTile != nil {
+		add(p.FuritenRoundTile.Tile)
 	}
 
 	return furitenTiles
 }
 
 // CanDiscardForRiichi 检查是否可以弃该牌并立直
+//
+// Deprecated: 不检查牌山剩余张数，请使用 CanDiscardForRiichiWithTable
 func (p *Player) CanDiscardForRiichi(tile BaseTile) bool {
-	// 1. 必须门前清
-	if !p.IsMenzen() {
-		return false
-	}
+	return p.CanDiscardForRiichiWithTable(nil, tile)
+}
 
-	// 2. 弃牌后必须听牌
-	if !p.IsTenpaiAfterDiscard(tile) {
+// CanDiscardForRiichiWithTable 检查是否可以弃该牌并立直
+// 需满足立直宣言的前提（门清、1000点、牌山剩余4张），且弃牌后听牌；振听立直是允许的
+func (p *Player) CanDiscardForRiichiWithTable(table *Table, tile BaseTile) bool {
+	if !p.CanDeclareRiichi(table) {
 		return false
 	}
-
-	// 3. 弃牌后不能是振听
-	if p.FuritenRiver {
-		for _, riverTile := range p.River.River {
-			if riverTile.Tile.Tile == tile {
-				return false
-			}
-		}
-	}
-
-	return true
-}
-
-// UpdateFuritenAfterRiichi 立直后更新振听状态
-func (p *Player) UpdateFuritenAfterRiichi() {
-	p.FuritenRiichi = p.FuritenRiver || p.FuritenRound
+	return p.IsTenpaiAfterDiscard(tile)
 }
 
 // CountKan 计算杠的数量
@@ -205,7 +205,7 @@ func (p *Player) CountKan() int {
 // ResetRoundFlags 重置回合标志
 func (p *Player) ResetRoundFlags() {
 	p.Ippatsu = false
-	p.FuritenRound = false
+	p.ClearFuritenRound()
 	p.FirstRound = false
 }
 
@@ -0,0 +1,135 @@
+package mahjong
+
+// PlayerView 某个座位在牌桌上能看到的信息
+// 所有内容都是复制出来的值，不含他家手牌与牌山，修改视图不会影响牌桌
+type PlayerView struct {
+	Seat  int    // 视角玩家
+	Phase Phase  // 当前阶段
+	Turn  int    // 当前回合玩家
+	Hand  []Tile // 自己的手牌
+
+	Seats [NPlayers]SeatView // 各座位的公开信息（下标为座位号）
+
+	DoraIndicators []Tile // 已翻开的宝牌指示牌
+	GameWind       Wind   // 场风
+	Oya            int    // 庄家
+	Honba          int    // 本场数
+	Kyoutaku       int    // 供托数
+	RemainTiles    int    // 牌山剩余张数
+
+	LastTile *Tile // 当前等待响应的牌（打出或杠出的牌），没有时为 nil
+
+	// ActionMask 视角玩家当前的合法动作，轮不到该玩家选择时全部为 false
+	ActionMask [NActionSpace]bool
+}
+
+// SeatView 一个座位的公开信息
+type SeatView struct {
+	Wind         Wind            // 自风
+	Score        int             // 点数
+	HandSize     int             // 手牌张数
+	River        []RiverTileView // 河（含被鸣走的牌）
+	CallGroups   []CallGroup     // 副露
+	Riichi       bool            // 是否已立直
+	DoubleRiichi bool            // 是否为两立直
+	Ippatsu      bool            // 是否有一发
+	Menzen       bool            // 是否门清
+}
+
+// RiverTileView 河中一张牌的公开信息
+type RiverTileView struct {
+	Tile     Tile // 牌
+	Number   int  // 全场弃牌序号
+	Riichi   bool // 是否为立直后弃牌（含立直宣言牌），第一张为 true 的是宣言牌
+	Remain   bool // 是否还在河里（未被鸣走）
+	FromHand bool // true为手切，false为摸切
+}
+
+// OracleView 包含隐藏信息的完整视角，供教师模型使用
+type OracleView struct {
+	PlayerView
+	Hands             [NPlayers][]Tile // 四家手牌
+	Yama              []Tile           // 剩余牌山（末尾为下一张摸到的牌）
+	UraDoraIndicators []Tile           // 与已翻开的宝牌对应的里宝牌指示牌
+}
+
+// NewPlayerView 生成 seat 座位的视角
+func NewPlayerView(t *Table, seat int) *PlayerView {
+	v := &PlayerView{
+		Seat:           seat,
+		Phase:          t.Phase,
+		Turn:           t.Turn,
+		Hand:           copyTiles(t.Players[seat].Hand),
+		DoraIndicators: copyTiles(activeIndicators(t.DoraIndicator, t.NActiveDora)),
+		GameWind:       t.GameWind,
+		Oya:            t.Oya,
+		Honba:          t.Honba,
+		Kyoutaku:       t.Kyoutaku,
+		RemainTiles:    t.GetRemainTile(),
+	}
+	for i, p := range t.Players {
+		v.Seats[i] = newSeatView(p)
+	}
+	if t.Phase > P4Action && t.Phase < GameOver && t.SelectedTile != nil {
+		tile := *t.SelectedTile
+		v.LastTile = &tile
+	}
+	if t.WhoMakeSelection() == seat {
+		v.ActionMask = t.ActionMask()
+	}
+	return v
+}
+
+// NewOracleView 生成包含四家手牌与牌山的视角
+func NewOracleView(t *Table, seat int) *OracleView {
+	v := &OracleView{
+		PlayerView:        *NewPlayerView(t, seat),
+		Yama:              copyTiles(t.Yama),
+		UraDoraIndicators: copyTiles(activeIndicators(t.UraDoraIndicator, t.NActiveDora)),
+	}
+	for i, p := range t.Players {
+		v.Hands[i] = copyTiles(p.Hand)
+	}
+	return v
+}
+
+func newSeatView(p *Player) SeatView {
+	s := SeatView{
+		Wind:         p.Wind,
+		Score:        p.Score,
+		HandSize:     len(p.Hand),
+		River:        make([]RiverTileView, 0, len(p.River.River)),
+		CallGroups:   make([]CallGroup, 0, len(p.CallGroups)),
+		Riichi:       p.Riichi,
+		DoubleRiichi: p.DoubleRiichi,
+		Ippatsu:      p.Ippatsu,
+		Menzen:       p.IsMenzen(),
+	}
+	for _, r := range p.River.River {
+		s.River = append(s.River, RiverTileView{
+			Tile: *r.Tile, Number: r.Number, Riichi: r.Riichi, Remain: r.Remain, FromHand: r.FromHand,
+		})
+	}
+	for _, cg := range p.CallGroups {
+		cg.Tiles = append([]BaseTile(nil), cg.Tiles...)
+		s.CallGroups = append(s.CallGroups, cg)
+	}
+	return s
+}
+
+// activeIndicators 返回前 n 张指示牌
+func activeIndicators(indicators []*Tile, n int) []*Tile {
+	if n > len(indicators) {
+		n = len(indicators)
+	}
+	return indicators[:n]
+}
+
+// copyTiles 复制牌的值
+func copyTiles(tiles []*Tile) []Tile {
+	result := make([]Tile, len(tiles))
+	for i, t := range tiles {
+		result[i] = *t
+	}
+	return result
+}
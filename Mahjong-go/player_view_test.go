@@ -0,0 +1,78 @@
+package mahjong
+
+import (
+	"reflect"
+	"testing"
+)
+
+// containsPointerTo 判断类型中是否可以到达 Table 或 Player
+func containsPointerTo(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsPointerTo(typ.Elem(), seen)
+	case reflect.Struct:
+		if typ == reflect.TypeOf(Table{}) || typ == reflect.TypeOf(Player{}) {
+			return true
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if containsPointerTo(typ.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestPlayerViewHidesPrivateInformation(t *testing.T) {
+	if containsPointerTo(reflect.TypeOf(PlayerView{}), map[reflect.Type]bool{}) {
+		t.Fatalf("PlayerView must not reference the table or players")
+	}
+
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+	table := newScenarioTable(t, hands, []BaseTile{_9s, _9p}, nil, 0)
+	selectSelf(t, table, Riichi, _9s)
+	passResponses(table)
+
+	view := NewPlayerView(table, 1)
+	if len(view.Hand) != 14 || view.Seats[2].HandSize != 13 {
+		t.Fatalf("unexpected hand sizes %d/%d", len(view.Hand), view.Seats[2].HandSize)
+	}
+	river := view.Seats[0].River
+	if len(river) != 1 || !river[0].Riichi || river[0].FromHand || river[0].Tile.Tile != _9s {
+		t.Fatalf("river should show the tsumogiri riichi tile, got %+v", river)
+	}
+	if !view.Seats[0].Riichi || view.Kyoutaku != 1 || view.Seats[0].Score != 24000 {
+		t.Fatalf("riichi state and stick should be public")
+	}
+	if len(view.DoraIndicators) != 1 || view.RemainTiles != table.GetRemainTile() {
+		t.Fatalf("unexpected dora indicators or remaining tiles")
+	}
+	// 轮到下家打牌
+	if table.Turn != 1 || view.ActionMask != table.ActionMask() {
+		t.Fatalf("the acting player should see its action mask")
+	}
+	if NewPlayerView(table, 2).ActionMask != [NActionSpace]bool{} {
+		t.Fatalf("waiting players should not get an action mask")
+	}
+
+	// 视图是复制出来的
+	view.Hand[0].Tile = _7z
+	view.Seats[0].River[0].Tile.Tile = _7z
+	if table.Players[1].Hand[0].Tile == _7z || table.Players[0].River.River[0].Tile.Tile == _7z {
+		t.Fatalf("modifying the view must not change the table")
+	}
+
+	oracle := NewOracleView(table, 1)
+	for i := 0; i < NPlayers; i++ {
+		if len(oracle.Hands[i]) != len(table.Players[i].Hand) {
+			t.Fatalf("oracle should include every hand")
+		}
+	}
+	if len(oracle.Yama) != len(table.Yama) || len(oracle.UraDoraIndicators) != 1 {
+		t.Fatalf("oracle should include the wall and ura indicators")
+	}
+}
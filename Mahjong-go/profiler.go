@@ -3,6 +3,7 @@ package mahjong
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -26,65 +27,120 @@ type ProfileEvent struct {
 }
 
 // Profiler 性能分析器
-// 用于测量和分析代码执行时间
+// 用于测量和分析代码执行时间，可以在多个 goroutine 中同时使用
+// Begin/End 以名称配对，嵌套或并发的计时请使用 NewScope 得到的 ProfileScope
 type Profiler struct {
-	events map[string]*ProfileEvent // 事件映射
-	active map[string]time.Time     // 当前活跃的计时
+	// TraceEnabled 为 true 时保留每一次计时，用于 WriteChromeTrace
+	TraceEnabled bool
+	// RuntimeTrace 为 true 时 span 同时作为 runtime/trace 的 region 记录
+	RuntimeTrace bool
+	// MaxSamples 每个事件保留用于计算分位数的样本数，为0时使用 DefaultMaxSamples
+	MaxSamples int
+	// MaxTraceEvents 保留的计时条数上限，为0时使用 DefaultMaxTraceEvents
+	MaxTraceEvents int
+
+	mu      sync.Mutex
+	events  map[string]*ProfileEvent    // 事件映射
+	active  map[string]time.Time        // 当前活跃的计时
+	samples map[string]*durationSamples // 各事件的耗时样本
+	trace   []TraceEvent                // 保留的计时
+	scopes  []string                    // 作用域名称，下标为作用域编号
+	epoch   time.Time                   // trace 的时间零点
 }
 
-var profilerInstance *Profiler
+var (
+	profilerMu       sync.Mutex
+	profilerInstance *Profiler
+)
+
+// NewProfiler 创建分析器
+func NewProfiler() *Profiler {
+	return &Profiler{
+		events:  make(map[string]*ProfileEvent),
+		active:  make(map[string]time.Time),
+		samples: make(map[string]*durationSamples),
+		scopes:  []string{"main"},
+		epoch:   time.Now(),
+	}
+}
 
 // GetProfiler 获取全局分析器实例
 func GetProfiler() *Profiler {
+	profilerMu.Lock()
+	defer profilerMu.Unlock()
 	if profilerInstance == nil {
-		profilerInstance = &Profiler{
-			events: make(map[string]*ProfileEvent),
-			active: make(map[string]time.Time),
-		}
+		profilerInstance = NewProfiler()
 	}
 	return profilerInstance
 }
 
 // Begin 开始对一个事件进行计时
 func (p *Profiler) Begin(name string) {
-	p.active[name] = time.Now()
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[name] = now
 }
 
 // End 结束计时并记录事件
 func (p *Profiler) End(name string) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if startTime, exists := p.active[name]; exists {
 		duration := time.Since(startTime)
-
-		if event, ok := p.events[name]; ok {
-			event.EndTime = time.Now()
-			event.Duration += duration
-			event.Count++
-		} else {
-			p.events[name] = &ProfileEvent{
-				Name:      name,
-				StartTime: startTime,
-				EndTime:   time.Now(),
-				Duration:  duration,
-				Count:     1,
-			}
-		}
-
+		p.record(name, startTime, duration, 0, 0)
 		delete(p.active, name)
 		return duration
 	}
 	return 0
 }
 
-// GetEvent 获取指定名称的事件
+// record 记录一次计时，调用者需持有 p.mu
+func (p *Profiler) record(name string, start time.Time, duration time.Duration, scope, depth int) {
+	if event, ok := p.events[name]; ok {
+		event.EndTime = start.Add(duration)
+		event.Duration += duration
+		event.Count++
+	} else {
+		p.events[name] = &ProfileEvent{
+			Name:      name,
+			StartTime: start,
+			EndTime:   start.Add(duration),
+			Duration:  duration,
+			Count:     1,
+		}
+	}
+
+	samples, ok := p.samples[name]
+	if !ok {
+		samples = &durationSamples{seed: uint64(len(p.samples))*2 + 1}
+		p.samples[name] = samples
+	}
+	samples.add(duration, p.maxSamples())
+
+	if p.TraceEnabled && len(p.trace) < p.maxTraceEvents() {
+		p.trace = append(p.trace, TraceEvent{
+			Name: name, Scope: scope, Depth: depth,
+			Start: start.Sub(p.epoch), Duration: duration,
+		})
+	}
+}
+
+// GetEvent 获取指定名称的事件（副本）
 func (p *Profiler) GetEvent(name string) *ProfileEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if event, exists := p.events[name]; exists {
-		return event
+		copied := *event
+		return &copied
 	}
 	return nil
 }
 
 // GetAverageDuration 获取平均执行时间(纳秒)
 func (p *Profiler) GetAverageDuration(name string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if event, exists := p.events[name]; exists && event.Count > 0 {
 		return float64(event.Duration.Nanoseconds()) / float64(event.Count)
 	}
@@ -93,6 +149,8 @@ func (p *Profiler) GetAverageDuration(name string) float64 {
 
 // GetTotalDuration 获取总执行时间(纳秒)
 func (p *Profiler) GetTotalDuration(name string) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if event, exists := p.events[name]; exists {
 		return event.Duration.Nanoseconds()
 	}
@@ -101,6 +159,8 @@ func (p *Profiler) GetTotalDuration(name string) int64 {
 
 // GetCallCount 获取调用次数
 func (p *Profiler) GetCallCount(name string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if event, exists := p.events[name]; exists {
 		return event.Count
 	}
@@ -109,26 +169,34 @@ func (p *Profiler) GetCallCount(name string) int {
 
 // Reset 重置所有事件
 func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.events = make(map[string]*ProfileEvent)
 	p.active = make(map[string]time.Time)
+	p.samples = make(map[string]*durationSamples)
+	p.trace = nil
 }
 
 // ResetEvent 重置指定的事件
 func (p *Profiler) ResetEvent(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	delete(p.events, name)
 	delete(p.active, name)
+	delete(p.samples, name)
 }
 
 // PrintReport 打印性能报告
 func (p *Profiler) PrintReport() {
-	if len(p.events) == 0 {
+	events := p.GetAllEvents()
+	if len(events) == 0 {
 		fmt.Println("No profile events recorded")
 		return
 	}
 
 	// 排序事件
-	names := make([]string, 0, len(p.events))
-	for name := range p.events {
+	names := make([]string, 0, len(events))
+	for name := range events {
 		names = append(names, name)
 	}
 	sort.Strings(names)
@@ -140,7 +208,7 @@ func (p *Profiler) PrintReport() {
 
 	// 打印每个事件
 	for _, name := range names {
-		event := p.events[name]
+		event := events[name]
 		totalNs := event.Duration.Nanoseconds()
 		avgNs := float64(totalNs) / float64(event.Count)
 
@@ -152,14 +220,15 @@ func (p *Profiler) PrintReport() {
 
 // PrintReportMilliseconds 打印性能报告(毫秒)
 func (p *Profiler) PrintReportMilliseconds() {
-	if len(p.events) == 0 {
+	events := p.GetAllEvents()
+	if len(events) == 0 {
 		fmt.Println("No profile events recorded")
 		return
 	}
 
 	// 排序事件
-	names := make([]string, 0, len(p.events))
-	for name := range p.events {
+	names := make([]string, 0, len(events))
+	for name := range events {
 		names = append(names, name)
 	}
 	sort.Strings(names)
@@ -171,7 +240,7 @@ func (p *Profiler) PrintReportMilliseconds() {
 
 	// 打印每个事件
 	for _, name := range names {
-		event := p.events[name]
+		event := events[name]
 		totalMs := float64(event.Duration.Nanoseconds()) / 1e6
 		avgMs := totalMs / float64(event.Count)
 
@@ -181,28 +250,37 @@ func (p *Profiler) PrintReportMilliseconds() {
 	fmt.Println(string(make([]byte, 75)))
 }
 
-// GetAllEvents 获取所有事件
+// GetAllEvents 获取所有事件（副本）
 func (p *Profiler) GetAllEvents() map[string]*ProfileEvent {
-	return p.events
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := make(map[string]*ProfileEvent, len(p.events))
+	for name, event := range p.events {
+		copied := *event
+		events[name] = &copied
+	}
+	return events
 }
 
 // ScopedTimer 作用域计时器，用于自动计时
+// 每个计时器独立计时，同名的计时器可以嵌套或并发使用
 type ScopedTimer struct {
-	name string
+	span *Span
 }
 
 // NewScopedTimer 创建一个作用域计时器
 func NewScopedTimer(name string) *ScopedTimer {
-	GetProfiler().Begin(name)
-	return &ScopedTimer{name: name}
+	return &ScopedTimer{span: GetProfiler().StartSpan(name)}
 }
 
 // Stop 停止计时
 func (st *ScopedTimer) Stop() {
-	GetProfiler().End(st.name)
+	st.span.End()
 }
 
 // ResetProfiler 重置全局分析器
 func ResetProfiler() {
+	profilerMu.Lock()
+	defer profilerMu.Unlock()
 	profilerInstance = nil
 }
@@ -0,0 +1,215 @@
+package mahjong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProfileScopeNestedSpans(t *testing.T) {
+	p := NewProfiler()
+	p.TraceEnabled = true
+	scope := p.NewScope("table")
+
+	outer := scope.Start("outer")
+	inner := scope.Start("inner")
+	time.Sleep(time.Millisecond)
+	innerDuration := inner.End()
+	scope.Start("unfinished")
+	outerDuration := outer.End()
+
+	if innerDuration <= 0 || outerDuration < innerDuration {
+		t.Fatalf("outer %v should cover inner %v", outerDuration, innerDuration)
+	}
+	events := p.TraceEvents()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 trace events, got %d", len(events))
+	}
+	depth := make(map[string]int)
+	for _, e := range events {
+		if e.Scope != 1 {
+			t.Fatalf("%s recorded in scope %d, expected 1", e.Name, e.Scope)
+		}
+		depth[e.Name] = e.Depth
+	}
+	if depth["outer"] != 0 || depth["inner"] != 1 || depth["unfinished"] != 1 {
+		t.Fatalf("unexpected depths %v", depth)
+	}
+	if len(scope.stack) != 0 {
+		t.Fatalf("ending the outer span should also end its children")
+	}
+
+	// nil 作用域与 nil span 不做任何事
+	var none *ProfileScope
+	if none.Start("x").End() != 0 {
+		t.Fatalf("nil span should report zero duration")
+	}
+	none.Close()
+}
+
+func TestProfilerPercentiles(t *testing.T) {
+	p := NewProfiler()
+	p.mu.Lock()
+	for i := 1; i <= 100; i++ {
+		p.record("step", p.epoch, time.Duration(i)*time.Microsecond, 0, 0)
+	}
+	p.mu.Unlock()
+
+	stats, ok := p.GetStats("step")
+	if !ok {
+		t.Fatalf("stats should exist")
+	}
+	if stats.Count != 100 || stats.P50 != 50*time.Microsecond || stats.P90 != 90*time.Microsecond ||
+		stats.P99 != 99*time.Microsecond || stats.Max != 100*time.Microsecond {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if got := p.GetPercentile("step", 0.25); got != 25*time.Microsecond {
+		t.Fatalf("p25 %v, expected 25µs", got)
+	}
+	if _, ok := p.GetStats("missing"); ok {
+		t.Fatalf("missing event should have no stats")
+	}
+
+	// 超过样本上限后仍保留准确的次数与最大值
+	p.MaxSamples = 10
+	p.ResetEvent("step")
+	p.mu.Lock()
+	for i := 1; i <= 1000; i++ {
+		p.record("step", p.epoch, time.Duration(i), 0, 0)
+	}
+	samples := len(p.samples["step"].values)
+	p.mu.Unlock()
+	stats, _ = p.GetStats("step")
+	if samples != 10 || stats.Count != 1000 || stats.Max != 1000 {
+		t.Fatalf("unexpected bounded stats %+v with %d samples", stats, samples)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteReport(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "P99") || !strings.Contains(buf.String(), "step") {
+		t.Fatalf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	p := NewProfiler()
+	p.TraceEnabled = true
+	scope := p.NewScope("game 0")
+	span := scope.Start("outer")
+	scope.Start("inner").End()
+	span.End()
+	p.StartSpan("detached").End()
+
+	var buf bytes.Buffer
+	if err := p.WriteChromeTrace(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		TraceEvents []struct {
+			Name string            `json:"name"`
+			Ph   string            `json:"ph"`
+			Ts   float64           `json:"ts"`
+			Dur  float64           `json:"dur"`
+			Tid  int               `json:"tid"`
+			Args map[string]string `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid trace JSON: %v", err)
+	}
+	threads := make(map[int]string)
+	spans := make(map[string]int)
+	for _, e := range doc.TraceEvents {
+		switch e.Ph {
+		case "M":
+			threads[e.Tid] = e.Args["name"]
+		case "X":
+			spans[e.Name] = e.Tid
+		}
+	}
+	if threads[0] != "main" || threads[1] != "game 0" {
+		t.Fatalf("unexpected thread names %v", threads)
+	}
+	if len(spans) != 3 || spans["outer"] != 1 || spans["inner"] != 1 || spans["detached"] != 0 {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+}
+
+func TestProfileScopesConcurrent(t *testing.T) {
+	p := NewProfiler()
+	p.TraceEnabled = true
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			scope := p.NewScope(fmt.Sprintf("worker %d", g))
+			defer scope.Close()
+			for i := 0; i < 100; i++ {
+				outer := scope.Start("outer")
+				scope.Start("inner").End()
+				outer.End()
+			}
+		}(g)
+	}
+	wg.Wait()
+	if p.GetCallCount("outer") != 800 || p.GetCallCount("inner") != 800 {
+		t.Fatalf("expected 800 outer and inner spans, got %d and %d",
+			p.GetCallCount("outer"), p.GetCallCount("inner"))
+	}
+	for _, e := range p.TraceEvents() {
+		if (e.Name == "outer") != (e.Depth == 0) {
+			t.Fatalf("%s recorded at depth %d", e.Name, e.Depth)
+		}
+	}
+}
+
+func TestTableProfileHooks(t *testing.T) {
+	p := NewProfiler()
+	config := SelfPlayConfig{Games: 4, Seed: 3, Workers: 2, Profiler: p}
+	if err := RunSelfPlay(context.Background(), config, SelfPlaySinkFunc(func(r *SelfPlayResult) error {
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"generateSelfActions", "generateResponseActions", "UpdateAtariTiles"} {
+		if p.GetCallCount(name) == 0 {
+			t.Fatalf("%s was not profiled", name)
+		}
+	}
+	p.mu.Lock()
+	scopes := len(p.scopes)
+	p.mu.Unlock()
+	if scopes != 1+config.Games {
+		t.Fatalf("expected one scope per game, got %d scopes", scopes)
+	}
+}
+
+func TestProfilerRuntimeTrace(t *testing.T) {
+	if trace.IsEnabled() {
+		t.Skip("runtime trace already running")
+	}
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProfiler()
+	p.RuntimeTrace = true
+	scope := p.NewScope("table")
+	scope.Start("region").End()
+	scope.Close()
+	p.StartSpan("detached").End()
+	trace.Stop()
+
+	if p.GetCallCount("region") != 1 || buf.Len() == 0 {
+		t.Fatalf("runtime trace regions should be recorded")
+	}
+}
@@ -0,0 +1,318 @@
+package mahjong
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/trace"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultMaxSamples     = 10000   // 每个事件默认保留的样本数
+	DefaultMaxTraceEvents = 1000000 // 默认保留的计时条数
+)
+
+// TraceEvent 一次计时
+type TraceEvent struct {
+	Name     string        // 事件名称
+	Scope    int           // 所属作用域编号（0为全局）
+	Depth    int           // 嵌套深度（0为最外层）
+	Start    time.Duration // 相对分析器创建时的开始时间
+	Duration time.Duration // 持续时间
+}
+
+// ProfileStats 某个事件的统计
+type ProfileStats struct {
+	Name  string
+	Count int
+	Total time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// ProfileScope 分析器的一个作用域（对应 Chrome trace 中的一条线程）
+// 作用域记录嵌套的 span，只能在一个 goroutine 中使用，一般每张牌桌或每个 goroutine 一个
+// nil 作用域可以安全使用，此时所有计时都被忽略
+type ProfileScope struct {
+	profiler *Profiler
+	id       int
+	ctx      context.Context
+	task     *trace.Task
+	stack    []*Span
+}
+
+// Span 一段正在进行的计时，nil Span 的 End 不做任何事
+type Span struct {
+	profiler *Profiler
+	scope    *ProfileScope
+	name     string
+	start    time.Time
+	depth    int
+	region   *trace.Region
+}
+
+// NewScope 创建一个作用域
+func (p *Profiler) NewScope(name string) *ProfileScope {
+	p.mu.Lock()
+	id := len(p.scopes)
+	p.scopes = append(p.scopes, name)
+	p.mu.Unlock()
+
+	s := &ProfileScope{profiler: p, id: id, ctx: context.Background()}
+	if p.RuntimeTrace {
+		s.ctx, s.task = trace.NewTask(s.ctx, name)
+	}
+	return s
+}
+
+// StartSpan 在全局作用域中开始一段不参与嵌套的计时，可以在任意 goroutine 中使用
+func (p *Profiler) StartSpan(name string) *Span {
+	span := &Span{profiler: p, name: name}
+	if p.RuntimeTrace {
+		span.region = trace.StartRegion(context.Background(), name)
+	}
+	span.start = time.Now()
+	return span
+}
+
+// Start 开始一段计时，在 End 之前开始的计时都是它的子计时
+func (s *ProfileScope) Start(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	span := &Span{profiler: s.profiler, scope: s, name: name, depth: len(s.stack)}
+	if s.profiler.RuntimeTrace {
+		span.region = trace.StartRegion(s.ctx, name)
+	}
+	s.stack = append(s.stack, span)
+	span.start = time.Now()
+	return span
+}
+
+// Close 结束作用域（以及对应的 runtime/trace task）
+func (s *ProfileScope) Close() {
+	if s == nil {
+		return
+	}
+	if s.task != nil {
+		s.task.End()
+	}
+}
+
+// End 结束计时并返回耗时，同时结束尚未结束的子计时
+func (sp *Span) End() time.Duration {
+	if sp == nil {
+		return 0
+	}
+	duration := time.Since(sp.start)
+	scopeID := 0
+	if s := sp.scope; s != nil {
+		scopeID = s.id
+		for i := len(s.stack) - 1; i >= 0; i-- {
+			if s.stack[i] == sp {
+				for _, child := range s.stack[i+1:] {
+					child.finish(time.Since(child.start), scopeID)
+				}
+				s.stack = s.stack[:i]
+				break
+			}
+		}
+	}
+	sp.finish(duration, scopeID)
+	return duration
+}
+
+func (sp *Span) finish(duration time.Duration, scopeID int) {
+	if sp.region != nil {
+		sp.region.End()
+		sp.region = nil
+	}
+	p := sp.profiler
+	p.mu.Lock()
+	p.record(sp.name, sp.start, duration, scopeID, sp.depth)
+	p.mu.Unlock()
+}
+
+// durationSamples 用蓄水池抽样保留一部分耗时，用于估计分位数
+type durationSamples struct {
+	seen   int
+	values []time.Duration
+	max    time.Duration
+	seed   uint64
+}
+
+func (d *durationSamples) add(v time.Duration, limit int) {
+	d.seen++
+	if v > d.max {
+		d.max = v
+	}
+	if len(d.values) < limit {
+		d.values = append(d.values, v)
+		return
+	}
+	// xorshift64，保证结果可复现
+	d.seed ^= d.seed << 13
+	d.seed ^= d.seed >> 7
+	d.seed ^= d.seed << 17
+	if j := int(d.seed % uint64(d.seen)); j < limit {
+		d.values[j] = v
+	}
+}
+
+func (p *Profiler) maxSamples() int {
+	if p.MaxSamples > 0 {
+		return p.MaxSamples
+	}
+	return DefaultMaxSamples
+}
+
+func (p *Profiler) maxTraceEvents() int {
+	if p.MaxTraceEvents > 0 {
+		return p.MaxTraceEvents
+	}
+	return DefaultMaxTraceEvents
+}
+
+// percentile 返回已排序样本的 q 分位数（最近秩法）
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(q*float64(len(sorted))+0.999999) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// GetPercentile 返回事件耗时的 q 分位数（0 < q <= 1），样本超过 MaxSamples 时为估计值
+func (p *Profiler) GetPercentile(name string, q float64) time.Duration {
+	p.mu.Lock()
+	samples, ok := p.samples[name]
+	if !ok {
+		p.mu.Unlock()
+		return 0
+	}
+	if q >= 1 {
+		p.mu.Unlock()
+		return samples.max
+	}
+	sorted := append([]time.Duration(nil), samples.values...)
+	p.mu.Unlock()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, q)
+}
+
+// GetStats 返回事件的统计
+func (p *Profiler) GetStats(name string) (ProfileStats, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.statsLocked(name)
+}
+
+func (p *Profiler) statsLocked(name string) (ProfileStats, bool) {
+	event, ok := p.events[name]
+	if !ok || event.Count == 0 {
+		return ProfileStats{}, false
+	}
+	stats := ProfileStats{
+		Name:  name,
+		Count: event.Count,
+		Total: event.Duration,
+		Mean:  event.Duration / time.Duration(event.Count),
+	}
+	if samples := p.samples[name]; samples != nil {
+		sorted := append([]time.Duration(nil), samples.values...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats.P50 = percentile(sorted, 0.5)
+		stats.P90 = percentile(sorted, 0.9)
+		stats.P99 = percentile(sorted, 0.99)
+		stats.Max = samples.max
+	}
+	return stats, true
+}
+
+// Stats 返回所有事件的统计，按总耗时从大到小排列
+func (p *Profiler) Stats() []ProfileStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]ProfileStats, 0, len(p.events))
+	for name := range p.events {
+		if stats, ok := p.statsLocked(name); ok {
+			result = append(result, stats)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// WriteReport 将包含分位数的报告写入 w
+func (p *Profiler) WriteReport(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%-30s %10s %12s %12s %12s %12s %12s %12s\n",
+		"Event Name", "Count", "Total", "Mean", "P50", "P90", "P99", "Max"); err != nil {
+		return err
+	}
+	for _, s := range p.Stats() {
+		if _, err := fmt.Fprintf(w, "%-30s %10d %12v %12v %12v %12v %12v %12v\n",
+			s.Name, s.Count, s.Total, s.Mean, s.P50, s.P90, s.P99, s.Max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TraceEvents 返回保留的计时（需要 TraceEnabled）
+func (p *Profiler) TraceEvents() []TraceEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]TraceEvent(nil), p.trace...)
+}
+
+// chromeTraceEvent Chrome trace-event 格式中的一条事件
+type chromeTraceEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	Ts   float64           `json:"ts"`
+	Dur  float64           `json:"dur,omitempty"`
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace 以 Chrome trace-event JSON 格式写出保留的计时（需要 TraceEnabled）
+// 输出可以在 chrome://tracing 或 Perfetto 中打开，每个作用域为一条线程
+func (p *Profiler) WriteChromeTrace(w io.Writer) error {
+	p.mu.Lock()
+	events := make([]chromeTraceEvent, 0, len(p.trace)+len(p.scopes))
+	for id, name := range p.scopes {
+		events = append(events, chromeTraceEvent{
+			Name: "thread_name", Ph: "M", Tid: id, Args: map[string]string{"name": name},
+		})
+	}
+	for _, e := range p.trace {
+		events = append(events, chromeTraceEvent{
+			Name: e.Name,
+			Cat:  "mahjong",
+			Ph:   "X",
+			Ts:   float64(e.Start) / float64(time.Microsecond),
+			Dur:  float64(e.Duration) / float64(time.Microsecond),
+			Tid:  e.Scope,
+		})
+	}
+	p.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+		DisplayTimeUnit string             `json:"displayTimeUnit"`
+	}{events, "ns"})
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Syanten 计算向听(round to win)
@@ -21,34 +22,43 @@ type Syanten struct {
 	// 值的含义与 C++ 一致，用于 normal 向听计算的选择
 	syanten_map map[uint32][4]int
 	is_loaded   bool
+	loadErr     error
 }
 
-var syantenInstance *Syanten
+var (
+	syantenInstance *Syanten
+	syantenOnce     sync.Once
+)
 
 // GetSyanten 获取向听计算单例
+// 查表只在第一次调用时加载一次，之后只读，可以在多个 goroutine 中同时使用
+// 找不到 syanten.dat 时由 Hand34 的逐花色查表计算，LoadError 返回加载失败的原因
 func GetSyanten() *Syanten {
-	if syantenInstance == nil {
-		syantenInstance = &Syanten{
-			syanten_map: make(map[uint32][4]int),
-			is_loaded:   false,
-		}
-		// 由于无法加载外部syanten.dat文件，这里提供了一个简化的实现
-		// 完整的实现需要依赖syanten.dat文件
-		syantenInstance.loadSyantenMap()
-	}
+	syantenOnce.Do(func() {
+		s := &Syanten{}
+		s.loadErr = s.loadSyantenMap()
+		syantenInstance = s
+	})
 	return syantenInstance
 }
 
-// loadSyantenMap 加载向听数查表
-// 在完整版本中，这会从resource/syanten.dat文件加载
-// 这里提供了一个基础实现，可以计算常见的向听情况
-func (s *Syanten) loadSyantenMap() {
-	// 尝试加载仓库中的 resource/syanten.dat 文件
+// IsLoaded 查表是否已加载
+func (s *Syanten) IsLoaded() bool {
+	return s.is_loaded
+}
+
+// LoadError 返回加载查表时的错误，加载成功时为 nil
+func (s *Syanten) LoadError() error {
+	return s.loadErr
+}
+
+// loadSyantenMap 从 resource/syanten.dat 加载向听数查表
+func (s *Syanten) loadSyantenMap() error {
 	// 路径与 C++ 实现一致: "../resource/syanten.dat"
 	path := "../resource/syanten.dat"
 	file, err := os.Open(path)
 	if err != nil {
-		panic(fmt.Sprintf("open syanten.dat error: %v\n请将 'syanten.dat' 放到 %s", err, path))
+		return fmt.Errorf("open syanten.dat error: %v\n请将 'syanten.dat' 放到 %s", err, path)
 	}
 	defer file.Close()
 
@@ -107,14 +117,17 @@ func (s *Syanten) loadSyantenMap() {
 		count++
 	}
 	if err := scanner.Err(); err != nil {
-		panic(fmt.Sprintf("read syanten.dat error: %v", err))
+		s.syanten_map = nil
+		return fmt.Errorf("read syanten.dat error: %v", err)
 	}
 
 	// C++ 实现校验条目数为 405350
 	if count != 405350 {
-		panic(fmt.Sprintf("syanten.dat broken or incomplete: expected 405350, got %d", count))
+		s.syanten_map = nil
+		return fmt.Errorf("syanten.dat broken or incomplete: expected 405350, got %d", count)
 	}
 	s.is_loaded = true
+	return nil
 }
 
 // HandToCode 将手牌转换为编码表示
@@ -153,9 +166,10 @@ func (s *Syanten) CodeToString(code uint32) string {
 // handCode: 编码后的手牌
 // nCallGroups: 副露的面子数(0-3)
 func (s *Syanten) CheckNormal(handCode [4]uint32, nCallGroups int) int {
-	// 如果没有加载查表，返回-1表示错误
+	// 没有加载查表时由 Hand34 计算（Hand34 以 -1 表示和牌）
 	if !s.is_loaded || len(s.syanten_map) == 0 {
-		return s.checkNormalWithoutMap(handCode, nCallGroups)
+		h := handFromCode(handCode)
+		return h.NormalShanten(nCallGroups) + 1
 	}
 
 	// 从查表中获取向听数
@@ -203,60 +217,13 @@ func (s *Syanten) CheckNormal(handCode [4]uint32, nCallGroups int) int {
 	return 9 - ptm*2 - ptt - nCallGroups*2
 }
 
-// checkNormalWithoutMap 在没有查表的情况下计算向听数(简化版本)
-func (s *Syanten) checkNormalWithoutMap(handCode [4]uint32, nCallGroups int) int {
-	// 简化实现: 直接计算当前牌型可能的面子+雀头数
-	ptm := 0 // 面子数
-	ptt := 0 // 雀头数
-
-	// 对每一类牌(万、筒、索)进行计数
-	for j := 0; j < 3; j++ {
-		code := handCode[j]
-
-		// 统计各个位置的牌数
-		groupPtm := 0 // 这一类的面子数
-		groupPtt := 0 // 这一类的雀头数
-
-		for i := 0; i < 9; i++ {
-			count := int((code >> (i * 3)) & 0x7)
-
-			// 尽可能地组成面子和雀头
-			if count >= 3 {
-				groupPtm += count / 3
-				count %= 3
-			}
-			if count >= 2 {
-				groupPtt += count / 2
-				count %= 2
-			}
-		}
-
-		ptm += groupPtm
-		if groupPtt > 0 {
-			ptt = groupPtt // 只能有一个雀头
-		}
-	}
-
-	// 处理字牌
-	for i := 0; i < 7; i++ {
-		num := (handCode[3] >> (i * 3)) & 0x7
-		if num >= 3 {
-			ptm++
-		} else if num >= 2 {
-			ptt = 1 // 可以作为雀头
-		}
-	}
-
-	// 调整
-	maxFuShou := 4 - nCallGroups
-	for ptm+ptt > maxFuShou && ptt > 0 {
-		ptt--
+// handFromCode 将编码后的手牌还原为 Hand34
+func handFromCode(handCode [4]uint32) Hand34 {
+	var h Hand34
+	for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+		h.Counts[tile] = uint8((handCode[tile/9] >> (tile % 9 * 3)) & 0x7)
 	}
-	for ptm+ptt > maxFuShou {
-		ptm--
-	}
-
-	return 9 - ptm*2 - ptt - nCallGroups*2
+	return h
 }
 
 // NormalRoundToWin 计算普通牌型的向听数
@@ -265,8 +232,9 @@ func (s *Syanten) NormalRoundToWin(hand []*Tile, nCallGroups int) int {
 	return s.CheckNormal(code, nCallGroups)
 }
 
-// CalculateRoundToWin 计算手牌距离和牌还有几步(向听数)
+// CalculateRoundToWin 计算手牌距离和牌还有几步(一般形的向听数，0为和牌)
+// 由 Hand34.NormalShanten 计算，不依赖 syanten.dat
 func CalculateRoundToWin(hand []*Tile, callGroupCount int) int {
-	syanten := GetSyanten()
-	return syanten.NormalRoundToWin(hand, callGroupCount)
+	h := NewHand34(hand)
+	return h.NormalShanten(callGroupCount) + 1
 }
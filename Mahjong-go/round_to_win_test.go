@@ -2,20 +2,31 @@ package mahjong
 
 import "testing"
 
-// TestLoadSyanten 验证 syanten.dat 可以加载且计算不会崩溃
+// TestLoadSyanten 验证向听数（0为和牌），没有 syanten.dat 时也必须正确
 func TestLoadSyanten(t *testing.T) {
-	// 构造简单手牌：1m1m 2m3m4m 1p1p1p 2s3s4s
-	hand := []*Tile{
-		{Tile: _1m}, {Tile: _1m},
-		{Tile: _2m}, {Tile: _3m}, {Tile: _4m},
-		{Tile: _1p}, {Tile: _1p}, {Tile: _1p},
-		{Tile: _2s}, {Tile: _3s}, {Tile: _4s},
-		{Tile: _5s}, {Tile: _6s}, {Tile: _7s},
+	cases := []struct {
+		hand       string
+		callGroups int
+		want       int
+	}{
+		{"11234m111p234567s", 0, 0},
+		{"1123m111p234567s", 0, 1},
+		{"11234m111p2345s", 1, 0},
+		{"1234m111p234567s", 0, 1},
+		{"159m159p159s1234z", 0, 9},
+		{"13579m2468p1357s", 0, 5},
 	}
-
-	// 调用 CalculateRoundToWin（会加载 syanten.dat）
-	res := CalculateRoundToWin(hand, 0)
-	if res < 0 {
-		t.Fatalf("CalculateRoundToWin returned negative: %d", res)
+	for _, c := range cases {
+		hand, err := ParseTiles(c.hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := CalculateRoundToWin(hand, c.callGroups); got != c.want {
+			t.Errorf("CalculateRoundToWin(%s, %d) = %d, expected %d", c.hand, c.callGroups, got, c.want)
+		}
+		syanten := GetSyanten()
+		if got := syanten.NormalRoundToWin(hand, c.callGroups); got != c.want {
+			t.Errorf("NormalRoundToWin(%s, %d) = %d, expected %d (loaded=%v)", c.hand, c.callGroups, got, c.want, syanten.IsLoaded())
+		}
 	}
 }
@@ -94,16 +94,17 @@ type TileSplitter struct {
 	allCompleted   []CompletedTiles // 所有完成的牌型
 }
 
-// GetInstance 获取TileSplitter的单例
-var tileSplitterInstance *TileSplitter
-
-func init() {
-	tileSplitterInstance = &TileSplitter{}
+// NewTileSplitter 创建拆分器
+// 拆分器在递归中保存状态，不能在多个 goroutine 间共享，每次拆分使用各自的实例
+func NewTileSplitter() *TileSplitter {
+	return &TileSplitter{}
 }
 
 // GetTileSplitter 获取TileSplitter实例
+//
+// Deprecated: 拆分器不再是单例，每次调用都返回新的实例，请使用 NewTileSplitter
 func GetTileSplitter() *TileSplitter {
-	return tileSplitterInstance
+	return NewTileSplitter()
 }
 
 // Reset 重置拆分器状态
@@ -114,118 +115,15 @@ func (ts *TileSplitter) Reset() {
 }
 
 // GetAllCompletedTiles 获取所有可能的完成牌型
+// 拆分由 DecomposeTiles 完成，这里返回可以修改的副本
 func (ts *TileSplitter) GetAllCompletedTiles(tiles []BaseTile) []CompletedTiles {
 	ts.Reset()
-
-	if len(tiles) == 0 {
-		return []CompletedTiles{ts.completedTiles}
-	}
-
-	// 排序输入的牌
-	sortedTiles := make([]BaseTile, len(tiles))
-	copy(sortedTiles, tiles)
-	sort.Slice(sortedTiles, func(i, j int) bool { return sortedTiles[i] < sortedTiles[j] })
-
-	result := ts.getAllCompletedTilesRecursive(sortedTiles)
-	// 对每个结果进行排序和去重将在调用方处理；此处直接返回
-	return result
-}
-
-// getAllCompletedTilesRecursive 递归地获取所有完成的牌型
-func (ts *TileSplitter) getAllCompletedTilesRecursive(tiles []BaseTile) []CompletedTiles {
-	if len(tiles) == 0 {
-		// 返回当前记录的 completedTiles
-		return []CompletedTiles{ts.completedTiles}
-	}
-
-	result := []CompletedTiles{}
-	processed := make(map[BaseTile]bool)
-
-	for _, tile := range tiles {
-		if processed[tile] {
-			continue
-		}
-		processed[tile] = true
-
-		// 1. 尝试作为对子（雀头）
-		if !ts.hasHead && countInSlice(tiles, tile) >= 2 {
-			tmpTiles := removeFromSlice(tiles, tile, 2)
-			// 设置雀头
-			ts.completedTiles.Head = TileGroup{Type: Toitsu, Tiles: []BaseTile{tile, tile}}
-			ts.hasHead = true
-
-			subResults := ts.getAllCompletedTilesRecursive(tmpTiles)
-			result = append(result, subResults...)
-
-			// 恢复状态
-			ts.hasHead = false
-			ts.completedTiles.Head = TileGroup{}
-		}
-
-		// 2. 尝试作为刻子
-		if countInSlice(tiles, tile) >= 3 {
-			tmpTiles := removeFromSlice(tiles, tile, 3)
-			grp := TileGroup{Type: Koutsu, Tiles: []BaseTile{tile, tile, tile}}
-
-			// 添加到 body
-			ts.completedTiles.Body = append(ts.completedTiles.Body, grp)
-			subResults := ts.getAllCompletedTilesRecursive(tmpTiles)
-			result = append(result, subResults...)
-			// 恢复 body
-			ts.completedTiles.Body = ts.completedTiles.Body[:len(ts.completedTiles.Body)-1]
-		}
-
-		// 3. 尝试作为顺子
-		if !isShuntsuBadHead(tile) && IsIn(tiles, tile+1) && IsIn(tiles, tile+2) {
-			tmpTiles := removeFromSlice(tiles, tile, 1)
-			tmpTiles = removeFromSlice(tmpTiles, tile+1, 1)
-			tmpTiles = removeFromSlice(tmpTiles, tile+2, 1)
-
-			grp := TileGroup{Type: Shuntsu, Tiles: []BaseTile{tile, tile + 1, tile + 2}}
-			ts.completedTiles.Body = append(ts.completedTiles.Body, grp)
-			subResults := ts.getAllCompletedTilesRecursive(tmpTiles)
-			result = append(result, subResults...)
-			ts.completedTiles.Body = ts.completedTiles.Body[:len(ts.completedTiles.Body)-1]
-		}
-	}
-
-	return result
-}
-
-// isShuntsuBadHead 检查是否为不能作为顺子开头的牌
-func isShuntsuBadHead(tile BaseTile) bool {
-	badHeads := []BaseTile{_8m, _9m, _8p, _9p, _8s, _9s, _1z, _2z, _3z, _4z, _5z, _6z, _7z}
-	for _, h := range badHeads {
-		if tile == h {
-			return true
-		}
-	}
-	return false
-}
-
-// countInSlice 计算某个元素在切片中出现的次数
-func countInSlice(tiles []BaseTile, tile BaseTile) int {
-	count := 0
-	for _, t := range tiles {
-		if t == tile {
-			count++
-		}
-	}
-	return count
-}
-
-// removeFromSlice 从切片中移除指定个数的元素
-func removeFromSlice(tiles []BaseTile, tile BaseTile, count int) []BaseTile {
-	result := make([]BaseTile, 0, len(tiles))
-	removed := 0
-	for _, t := range tiles {
-		if t == tile && removed < count {
-			removed++
-			continue
-		}
-		result = append(result, t)
+	shared := DecomposeTiles(tiles)
+	ts.allCompleted = make([]CompletedTiles, len(shared))
+	for i := range shared {
+		ts.allCompleted[i] = cloneCompletedTiles(&shared[i])
 	}
-	return result
+	return ts.allCompleted
 }
 
 // CheckColorCount 检查颜色数量的有效性（用于初步筛选胡牌型）
@@ -298,9 +196,11 @@ func NewCompletedTiles(head *TileGroup, body []*TileGroup) *CompletedTiles {
 
 // CallGroup 表示鸣牌组（吃、碰、杠）
 type CallGroup struct {
-	Type   TileGroupType // 组类型
-	Tiles  []BaseTile    // 鸣牌的牌
-	IsOpen bool          // 是否为明牌
+	Type    TileGroupType // 组类型
+	Tiles   []BaseTile    // 鸣牌的牌
+	IsOpen  bool          // 是否为明牌
+	Take    int           // 鸣入的牌在 Tiles 中的位置（吃碰大明杠有效）
+	RedDora int           // 组中赤宝牌的张数
 }
 
 // String 返回CallGroup的字符串表示
@@ -35,8 +35,7 @@ func HasCompletedTiles(tiles []BaseTile) bool {
 		return false
 	}
 
-	splitter := &TileSplitter{}
-	completed := splitter.GetAllCompletedTiles(tiles)
+	completed := DecomposeTiles(tiles)
 	return len(completed) > 0
 }
 
@@ -50,8 +49,7 @@ func FindAllWinTiles(tiles []BaseTile) []BaseTile {
 		// 尝试加入这张牌
 		testTiles := append(tiles, testTile)
 		if len(testTiles) == 14 {
-			splitter := &TileSplitter{}
-			completed := splitter.GetAllCompletedTiles(testTiles)
+			completed := DecomposeTiles(testTiles)
 			if len(completed) > 0 {
 				winTiles = append(winTiles, testTile)
 			}
@@ -61,7 +59,7 @@ func FindAllWinTiles(tiles []BaseTile) []BaseTile {
 	return winTiles
 }
 
-// GetTenpaiPattern 获取听牌的模式
+// GetTenpaiPattern 获取听牌的模式（只有和牌，听牌形见 AnalyzeWaits）
 func GetTenpaiPattern(tiles []BaseTile) []BaseTile {
 	tenpaiTiles := make([]BaseTile, 0)
 
@@ -74,8 +72,7 @@ func GetTenpaiPattern(tiles []BaseTile) []BaseTile {
 		testTile := BaseTile(i)
 		testTiles := append(tiles, testTile)
 
-		splitter := &TileSplitter{}
-		completed := splitter.GetAllCompletedTiles(testTiles)
+		completed := DecomposeTiles(testTiles)
 		if len(completed) > 0 {
 			tenpaiTiles = append(tenpaiTiles, testTile)
 		}
@@ -364,7 +361,8 @@ func GetComplimentaryTiles(tiles []BaseTile) []BaseTile {
 	return FindAllWinTiles(tiles)
 }
 
-// EstimateHandSafety 估计手牌安全性（简化版）
+// EstimateHandSafety 估计手牌安全性（简化版，只看是否为现物）
+// 按对手分别考虑现物、筋、壁的分析见 AnalyzeDanger
 func EstimateHandSafety(tiles []BaseTile, riverTiles []BaseTile) int {
 	// 0 = 危险, 1 = 中等, 2 = 安全
 
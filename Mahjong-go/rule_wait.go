@@ -0,0 +1,222 @@
+package mahjong
+
+import "sort"
+
+// WaitShape 表示一张和牌在某种拆分下的听牌形
+type WaitShape int
+
+const (
+	Ryanmen     WaitShape = iota // 两面
+	Kanchan                      // 坎张
+	Penchan                      // 边张
+	Tanki                        // 单骑（含七对子）
+	Shanpon                      // 双碰
+	KokushiWait                  // 国士无双
+)
+
+// String 返回听牌形的名称
+func (w WaitShape) String() string {
+	switch w {
+	case Ryanmen:
+		return "ryanmen"
+	case Kanchan:
+		return "kanchan"
+	case Penchan:
+		return "penchan"
+	case Tanki:
+		return "tanki"
+	case Shanpon:
+		return "shanpon"
+	case KokushiWait:
+		return "kokushi"
+	}
+	return "unknown"
+}
+
+// CompoundWaitShape 表示由多种听牌形组合成的复合形
+type CompoundWaitShape int
+
+const (
+	Nobetan    CompoundWaitShape = iota // 延单，如 1234 听 1、4
+	Sanmenchan                          // 三面，如 23456 听 1、4、7
+	Entotsu                             // 烟囱，如 33345+77 听 3、6、7
+)
+
+// String 返回复合形的名称
+func (c CompoundWaitShape) String() string {
+	switch c {
+	case Nobetan:
+		return "nobetan"
+	case Sanmenchan:
+		return "sanmenchan"
+	case Entotsu:
+		return "entotsu"
+	}
+	return "unknown"
+}
+
+// WaitInfo 一张和牌在一种拆分下的听牌形
+type WaitInfo struct {
+	Tile       BaseTile       // 和牌
+	Shape      WaitShape      // 听牌形
+	Completed  CompletedTiles // 加入和牌后的拆分（只读）；七对子、国士无双时为空
+	Group      int            // 和牌所在的组在 Completed.Body 中的下标，和在雀头时为 -1
+	SevenPairs bool           // 是否为七对子的单骑
+	Fu         int            // 听牌符：坎张、边张、单骑为2（七对子固定25符，不计）
+	Pinfu      bool           // 是否满足平和的牌形条件：两面且面子全为顺子（雀头是否为役牌、是否门清另行判断）
+}
+
+// CompoundWait 复合形及其和牌
+type CompoundWait struct {
+	Shape CompoundWaitShape
+	Waits []BaseTile
+}
+
+// WaitAnalysis 听牌形的分析结果
+type WaitAnalysis struct {
+	Waits    []WaitInfo     // 按和牌从小到大、拆分顺序排列
+	Compound []CompoundWait // 识别出的复合形
+}
+
+// AnalyzeWaits 分析 3n+1 张手牌（不含副露）的听牌形
+// 对每张和牌列出所有拆分下的解释，已有4张的牌不算听牌；不听牌时结果为空
+func AnalyzeWaits(tiles []BaseTile) WaitAnalysis {
+	var analysis WaitAnalysis
+	h := NewHand34FromBaseTiles(tiles)
+	for _, tile := range h.AppendAgariTiles(nil) {
+		h.Add(tile)
+		for _, ct := range h.Decompose() {
+			analysis.Waits = appendWaitInfos(analysis.Waits, tile, ct)
+		}
+		if h.Len() == 14 && h.ChiitoiShanten() == -1 {
+			analysis.Waits = append(analysis.Waits, WaitInfo{Tile: tile, Shape: Tanki, Group: -1, SevenPairs: true})
+		}
+		if h.Len() == 14 && h.KokushiShanten() == -1 {
+			analysis.Waits = append(analysis.Waits, WaitInfo{Tile: tile, Shape: KokushiWait, Group: -1})
+		}
+		h.Remove(tile)
+	}
+	analysis.Compound = findCompoundWaits(analysis.Waits)
+	return analysis
+}
+
+// appendWaitInfos 追加和牌在一种拆分下的所有解释，相同的组只计一次
+func appendWaitInfos(waits []WaitInfo, tile BaseTile, ct CompletedTiles) []WaitInfo {
+	if len(ct.Head.Tiles) > 0 && ct.Head.Tiles[0] == tile {
+		waits = append(waits, WaitInfo{Tile: tile, Shape: Tanki, Completed: ct, Group: -1, Fu: 2})
+	}
+	allShuntsu := true
+	for _, g := range ct.Body {
+		if g.Type != Shuntsu {
+			allShuntsu = false
+		}
+	}
+	for i, g := range ct.Body {
+		pos := g.Find(tile)
+		if pos < 0 || (i > 0 && g.Type == ct.Body[i-1].Type && g.Tiles[0] == ct.Body[i-1].Tiles[0]) {
+			continue
+		}
+		info := WaitInfo{Tile: tile, Completed: ct, Group: i}
+		switch {
+		case g.Type != Shuntsu:
+			info.Shape = Shanpon
+		case pos == 1:
+			info.Shape = Kanchan
+		case (pos == 0 && int(g.Tiles[0])%9 == 6) || (pos == 2 && int(g.Tiles[0])%9 == 0):
+			info.Shape = Penchan
+		default:
+			info.Shape = Ryanmen
+			info.Pinfu = allShuntsu
+		}
+		if info.Shape == Kanchan || info.Shape == Penchan {
+			info.Fu = 2
+		}
+		waits = append(waits, info)
+	}
+	return waits
+}
+
+// findCompoundWaits 根据各和牌的解释识别复合形
+// 延单：同色相差3的两张单骑；三面：同色依次相差3的三张两面；
+// 烟囱：一张牌既是双碰又是两面，另有相差3的两面和另一张双碰
+func findCompoundWaits(waits []WaitInfo) []CompoundWait {
+	var shapes [NBaseTiles][KokushiWait + 1]bool
+	for _, w := range waits {
+		if !w.SevenPairs {
+			shapes[w.Tile][w.Shape] = true
+		}
+	}
+	has := func(tile int, shape WaitShape) bool {
+		return tile >= 0 && tile < int(_1z) && shapes[tile][shape]
+	}
+	sameSuit := func(a, b int) bool { return a/9 == b/9 }
+
+	var result []CompoundWait
+	for t := 0; t < int(_1z); t++ {
+		if has(t, Tanki) && has(t+3, Tanki) && sameSuit(t, t+3) {
+			result = append(result, CompoundWait{Shape: Nobetan, Waits: []BaseTile{BaseTile(t), BaseTile(t + 3)}})
+		}
+		if has(t, Ryanmen) && has(t+3, Ryanmen) && has(t+6, Ryanmen) && sameSuit(t, t+6) {
+			result = append(result, CompoundWait{Shape: Sanmenchan, Waits: []BaseTile{BaseTile(t), BaseTile(t + 3), BaseTile(t + 6)}})
+		}
+		if !has(t, Shanpon) || !has(t, Ryanmen) {
+			continue
+		}
+		for _, partner := range []int{t - 3, t + 3} {
+			if !has(partner, Ryanmen) || !sameSuit(t, partner) {
+				continue
+			}
+			for other := BaseTile(0); other < NBaseTiles; other++ {
+				if int(other) != t && shapes[other][Shanpon] {
+					waits := []BaseTile{BaseTile(t), BaseTile(partner), other}
+					sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+					result = append(result, CompoundWait{Shape: Entotsu, Waits: waits})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Tiles 返回所有和牌
+func (a *WaitAnalysis) Tiles() []BaseTile {
+	tiles := make([]BaseTile, 0)
+	for _, w := range a.Waits {
+		if len(tiles) == 0 || tiles[len(tiles)-1] != w.Tile {
+			tiles = append(tiles, w.Tile)
+		}
+	}
+	return tiles
+}
+
+// ForTile 返回一张和牌的所有解释
+func (a *WaitAnalysis) ForTile(tile BaseTile) []WaitInfo {
+	var result []WaitInfo
+	for _, w := range a.Waits {
+		if w.Tile == tile {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// CanPinfu 和这张牌时是否有满足平和牌形条件的解释
+func (a *WaitAnalysis) CanPinfu(tile BaseTile) bool {
+	for _, w := range a.Waits {
+		if w.Tile == tile && w.Pinfu {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitFu 和这张牌时各解释中最大的听牌符（算分时取得分最高的解释）
+func (a *WaitAnalysis) WaitFu(tile BaseTile) int {
+	fu := 0
+	for _, w := range a.Waits {
+		if w.Tile == tile {
+			fu = max(fu, w.Fu)
+		}
+	}
+	return fu
+}
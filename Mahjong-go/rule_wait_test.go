@@ -0,0 +1,97 @@
+package mahjong
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeWaitsShapes(t *testing.T) {
+	for _, c := range []struct {
+		hand   string
+		tile   string
+		shapes []WaitShape
+		fu     int
+		pinfu  bool
+	}{
+		{"123m456p789s13s11z", "2s", []WaitShape{Kanchan}, 2, false},
+		{"123m456p789s12s11z", "3s", []WaitShape{Penchan}, 2, false},
+		{"123m456p789s89s11z", "7s", []WaitShape{Penchan}, 2, false},
+		{"123m456p789s23s11z", "4s", []WaitShape{Ryanmen}, 0, true},
+		{"123m456p111s23s11z", "4s", []WaitShape{Ryanmen}, 0, false},
+		{"123m456p789s111z5s", "5s", []WaitShape{Tanki}, 2, false},
+		{"123m456p789s11z55s", "1z", []WaitShape{Shanpon}, 0, false},
+		// 234+345 中的3既可以看作坎张也可以看作两面
+		{"123m456p11z23445s", "3s", []WaitShape{Kanchan, Ryanmen}, 2, true},
+	} {
+		analysis := AnalyzeWaits(mustBaseTiles(t, c.hand))
+		tile := mustBaseTiles(t, c.tile)[0]
+		var shapes []WaitShape
+		for _, w := range analysis.ForTile(tile) {
+			shapes = append(shapes, w.Shape)
+		}
+		if !slices.Equal(shapes, c.shapes) {
+			t.Fatalf("%s + %s: shapes %v, expected %v", c.hand, c.tile, shapes, c.shapes)
+		}
+		if analysis.WaitFu(tile) != c.fu || analysis.CanPinfu(tile) != c.pinfu {
+			t.Fatalf("%s + %s: wait fu %d pinfu %v, expected %d %v",
+				c.hand, c.tile, analysis.WaitFu(tile), analysis.CanPinfu(tile), c.fu, c.pinfu)
+		}
+	}
+}
+
+func TestAnalyzeWaitsCompound(t *testing.T) {
+	for _, c := range []struct {
+		hand  string
+		shape CompoundWaitShape
+		waits string
+	}{
+		{"123456789m1234p", Nobetan, "14p"},
+		{"123m456p11z23456s", Sanmenchan, "147s"},
+		{"123m456p33345s77z", Entotsu, "36s7z"},
+	} {
+		analysis := AnalyzeWaits(mustBaseTiles(t, c.hand))
+		waits := mustBaseTiles(t, c.waits)
+		if !slices.Equal(analysis.Tiles(), waits) {
+			t.Fatalf("%s: waits %v, expected %v", c.hand, analysis.Tiles(), waits)
+		}
+		found := false
+		for _, compound := range analysis.Compound {
+			found = found || (compound.Shape == c.shape && slices.Equal(compound.Waits, waits))
+		}
+		if !found {
+			t.Fatalf("%s: expected %v on %v, got %+v", c.hand, c.shape, waits, analysis.Compound)
+		}
+	}
+}
+
+func TestAnalyzeWaitsSpecialHands(t *testing.T) {
+	analysis := AnalyzeWaits(mustBaseTiles(t, "1122m3344p5566s7z"))
+	if w := analysis.ForTile(_7z); len(w) != 1 || !w[0].SevenPairs || w[0].Shape != Tanki || w[0].Fu != 0 {
+		t.Fatalf("unexpected seven pairs wait %+v", w)
+	}
+	analysis = AnalyzeWaits(mustBaseTiles(t, "19m19p19s1234567z"))
+	if len(analysis.Waits) != 13 || analysis.Waits[0].Shape != KokushiWait {
+		t.Fatalf("expected a 13-sided kokushi wait, got %+v", analysis.Waits)
+	}
+	if analysis := AnalyzeWaits(mustBaseTiles(t, "147m258p369s1234z")); len(analysis.Waits) != 0 || len(analysis.Tiles()) != 0 {
+		t.Fatalf("a hand that is not tenpai should have no waits")
+	}
+}
+
+func TestAnalyzeWaitsMatchesAtariHai(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	for i := 0; i < 1000; i++ {
+		h := randomHand34(rng, 13, true)
+		tiles := h.BaseTiles()
+		analysis := AnalyzeWaits(tiles)
+		if want := GetAtariHai(tiles, nil); !slices.Equal(analysis.Tiles(), want) {
+			t.Fatalf("%s: waits %v, expected %v", h.String(), analysis.Tiles(), want)
+		}
+		for _, w := range analysis.Waits {
+			if w.Pinfu && w.Shape != Ryanmen {
+				t.Fatalf("%s: %v wait cannot be pinfu", h.String(), w.Shape)
+			}
+		}
+	}
+}
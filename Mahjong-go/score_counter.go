@@ -11,8 +11,14 @@ type ScoreCounterResult struct {
 	BaseScore  int    // 基础分
 	TsumoScore [3]int // 自摸时的分数（非庄、非庄、非庄）
 	RonScore   int    // 荣和时的分数
-	IsYakuman  bool   // 是否为役满
+	Yakuman    int    // 役满倍数（0表示非役满）
 	Yakus      []Yaku // 所有成立的役
+	Fans       []int  // 与 Yakus 一一对应的番数（役满为13×倍数）
+}
+
+// IsYakuman 判断结果是否为役满
+func (r *ScoreCounterResult) IsYakuman() bool {
+	return r.Yakuman > 0
 }
 
 // ScoreCounter 是麻将计分器
@@ -22,7 +28,22 @@ type ScoreCounter struct {
 	CallGroups  []CallGroup // 鸣牌组
 	WinTile     BaseTile    // 胡牌（第14张）
 	IsSevenPair bool        // 是否为七对子形式
+	Tsumo       bool        // 是否为自摸
 	Table       *Table      // 游戏桌（用于场风等信息）
+	RonTileRed  bool        // 荣和的牌是否为赤宝牌（荣和牌不在手牌中）
+
+	// 当前评估的拆分与和牌位置（由 evaluateVariant 设置）
+	variant      *CompletedTiles
+	winOnHead    bool
+	winBodyIndex int // 和牌所在的手中面子下标（-1表示不在手中面子）
+}
+
+// rule 返回当前牌桌的规则，没有牌桌时使用默认规则
+func (s *ScoreCounter) rule() *GameRule {
+	if s.Table != nil && s.Table.Rule != nil {
+		return s.Table.Rule
+	}
+	return DefaultGameRule()
 }
 
 // CalculateScore 计算分数
@@ -42,9 +63,10 @@ func (s *ScoreCounter) CalculateScore(
 	s.CallGroups = callGroups
 	s.WinTile = winTile
 	s.IsSevenPair = isSevenPair
+	// 自摸时和牌已在手中（手牌为3n+2张），荣和时和牌来自他家
+	s.Tsumo = len(player.Hand)%3 == 2
 
-	splitter := GetTileSplitter()
-	completedList := splitter.GetAllCompletedTiles(s.Tiles)
+	completedList := DecomposeTiles(s.Tiles)
 
 	var candidates []*ScoreCounterResult
 
@@ -100,6 +122,34 @@ func (s *ScoreCounter) CalculateScore(
 		}
 	}
 
+	// 七对子、国士无双（及十三不塔）无法由拆分得到，单独构造变体
+	if len(callGroups) == 0 && IsSevenPairPattern(tiles) {
+		ct := CompletedTiles{Body: make([]TileGroup, 0, 7)}
+		counts := make(map[BaseTile]int)
+		for _, t := range tiles {
+			counts[t]++
+		}
+		for tile := BaseTile(0); tile <= _7z; tile++ {
+			if counts[tile] == 2 {
+				ct.Body = append(ct.Body, TileGroup{Type: Toitsu, Tiles: []BaseTile{tile, tile}})
+			}
+		}
+		for i, g := range ct.Body {
+			if g.Tiles[0] == winTile {
+				if variant := s.evaluateVariant(&ct, callGroups, false, 0, i, -1); variant != nil {
+					candidates = append(candidates, variant)
+				}
+			}
+		}
+	}
+	if len(callGroups) == 0 && (IsKokushiPattern(tiles) ||
+		s.rule().IsLocalYakuEnabled(Shiisanpuutaa) && IsShiisanpuutaaPattern(tiles)) {
+		ct := CompletedTiles{}
+		if variant := s.evaluateVariant(&ct, callGroups, false, 0, -1, -1); variant != nil {
+			candidates = append(candidates, variant)
+		}
+	}
+
 	// 从候选结果中选择最佳
 	best := s.GetBestResult(candidates)
 	return best
@@ -124,49 +174,106 @@ func (s *ScoreCounter) evaluateVariant(ct *CompletedTiles, callGroups []CallGrou
 		s.CallGroups = prevCallGroups
 		s.WinTile = prevWin
 		s.IsSevenPair = prevIsSeven
+		s.variant = nil
+		s.winOnHead = false
+		s.winBodyIndex = -1
 	}()
 
 	// s.Tiles 已经是用于拆分的整副牌（含和牌），保持不变
 	s.CallGroups = callGroups
 	s.IsSevenPair = (len(ct.Body) == 7 && len(ct.Head.Tiles) == 0)
-
-	// 识别 tsumo：若 winTile 在玩家手中，则认为是自摸
-	tsumo := false
-	if CountTile(s.Tiles, s.WinTile) > 0 {
-		tsumo = true
+	s.variant = ct
+	s.winOnHead = hasHead && combinedIndex == 0
+	s.winBodyIndex = -1
+	if combinedIndex >= bodyOffset && combinedIndex-bodyOffset < len(ct.Body) {
+		s.winBodyIndex = combinedIndex - bodyOffset
 	}
 
-	// 收集成立的役（包含役满）
+	tsumo := s.Tsumo
+
+	// 收集成立的役（包含役满），副露时不成立的门清限定役不计入
+	menzen := s.Player.IsMenzen()
+	rule := s.rule()
 	yakus := make([]Yaku, 0)
 	for y := Yaku(0); y < MaxYaku; y++ {
-		if s.CheckYaku(y) {
+		if rule.YakuFan(y, menzen) > 0 && s.CheckYaku(y) {
 			yakus = append(yakus, y)
 		}
 	}
+	for _, y := range statusYakus {
+		if rule.YakuFan(y, menzen) > 0 && s.CheckYaku(y) {
+			yakus = append(yakus, y)
+		}
+	}
+	yakus = RemoveSupersededYakus(yakus)
+
+	// 每个役的番数与役满倍数，注册的自定义役与内置役一同计算
+	fans := make([]int, 0, len(yakus))
+	multiples := make([]int, 0, len(yakus))
+	for _, y := range yakus {
+		fans = append(fans, rule.YakuFan(y, menzen))
+		multiples = append(multiples, YakumanMultiple(y, rule))
+	}
+	for _, cr := range s.checkCustomYakus(ct) {
+		yakus = append(yakus, cr.yaku)
+		fans = append(fans, cr.fan)
+		multiples = append(multiples, cr.yakuman)
+	}
 	if len(yakus) == 0 {
 		return nil
 	}
 
+	// 役满成立时只计役满
+	yakuman := 0
+	for _, m := range multiples {
+		if rule.MultipleYakuman {
+			yakuman += m
+		} else if m > yakuman {
+			yakuman = m
+		}
+	}
+	fan := 0
+	if yakuman > 0 {
+		only := make([]Yaku, 0, len(yakus))
+		onlyFans := make([]int, 0, len(yakus))
+		for i, y := range yakus {
+			if multiples[i] > 0 {
+				only = append(only, y)
+				onlyFans = append(onlyFans, 13*multiples[i])
+			}
+		}
+		yakus, fans = only, onlyFans
+		fan = 13 * yakuman
+	} else {
+		for _, f := range fans {
+			fan += f
+		}
+		// 宝牌不是役，有役时才计入番数
+		doraYakus, doraFans := s.doraYakus()
+		yakus = append(yakus, doraYakus...)
+		fans = append(fans, doraFans...)
+		for _, f := range doraFans {
+			fan += f
+		}
+	}
+
 	// 计算符数（基于当前拆分与和牌位置）
 	fu := s.calculateFuForVariant(ct, callGroups, tsumo, hasHead, bodyOffset, combinedIndex, shuntsuPos)
 
-	fan := s.CalculateFan(yakus)
-
 	res := &ScoreCounterResult{
-		Fan:   fan,
-		Fu:    fu,
-		Yakus: yakus,
-	}
-	if fan >= 13 {
-		res.IsYakuman = true
-		res.BaseScore = 8000
-		res.RonScore = 12000
-		res.TsumoScore = [3]int{4000, 4000, 4000}
+		Fan:     fan,
+		Fu:      fu,
+		Yakuman: yakuman,
+		Yakus:   yakus,
+		Fans:    fans,
+	}
+	if yakuman > 0 {
+		res.BaseScore = s.CalculateYakumanBaseScore(yakuman)
 	} else {
 		res.BaseScore = s.CalculateBaseScore(fan, fu)
-		res.RonScore = s.CalculateRonScore(res.BaseScore)
-		res.TsumoScore = s.CalculateTsumoScore(res.BaseScore)
 	}
+	res.RonScore = s.CalculateRonScore(res.BaseScore)
+	res.TsumoScore = s.CalculateTsumoScore(res.BaseScore)
 
 	return res
 }
@@ -335,24 +442,9 @@ func (s *ScoreCounter) calculateFuForVariant(ct *CompletedTiles, callGroups []Ca
 	return fu
 }
 
-// CalculateFan 计算番数
+// CalculateFan 计算番数（按玩家是否门清取对应的番数，役满每倍记为13番）
 func (s *ScoreCounter) CalculateFan(yakus []Yaku) int {
-	fanCount := 0
-	yakumanCount := 0
-
-	for _, yaku := range yakus {
-		fanVal := GetFanCount(yaku)
-		if fanVal >= 13 {
-			yakumanCount++
-		} else {
-			fanCount += fanVal
-		}
-	}
-
-	if yakumanCount > 0 {
-		return 13 * yakumanCount
-	}
-	return fanCount
+	return TotalFanWithRule(yakus, s.Player.IsMenzen(), s.rule())
 }
 
 // CalculateFu 计算符数
@@ -372,8 +464,7 @@ func (s *ScoreCounter) CalculateFu() int {
 	bestFu := 0
 
 	// 遍历所有可能的拆牌（不同拆法可能导致不同的符数），选择最大的符数作为安全值
-	splitter := GetTileSplitter()
-	completedList := splitter.GetAllCompletedTiles(s.Tiles)
+	completedList := DecomposeTiles(s.Tiles)
 	if len(completedList) == 0 {
 		// 兜底：返回最小的符（进位后）
 		fu := baseFu
@@ -517,9 +608,13 @@ func (s *ScoreCounter) CalculateFu() int {
 }
 
 // CalculateBaseScore 计算基础分
+// 13番以上按规则计为数役满或以三倍满封顶
 func (s *ScoreCounter) CalculateBaseScore(fan int, fu int) int {
 	if fan >= 13 {
-		return 8000 // 役满
+		if s.rule().KazoeYakuman {
+			return 8000 // 数役满
+		}
+		return 6000 // 三倍满封顶
 	} else if fan >= 11 {
 		return 6000 // 三倍满
 	} else if fan >= 8 {
@@ -528,39 +623,31 @@ func (s *ScoreCounter) CalculateBaseScore(fan int, fu int) int {
 		return 3000 // 跳满
 	} else if fan >= 5 {
 		return 2000 // 满贯
-	} else if fan == 4 {
-		baseScore := fu * 1000
-		if baseScore > 2000 {
-			return 2000
-		}
-		return baseScore
-	} else if fan == 3 {
-		baseScore := fu * 500
-		if baseScore > 2000 {
-			return 2000
-		}
-		return baseScore
-	} else if fan == 2 {
-		baseScore := fu * 250
-		if baseScore > 2000 {
-			return 2000
-		}
-		return baseScore
-	} else {
-		baseScore := fu * 100
-		if baseScore > 2000 {
-			return 2000
-		}
-		return baseScore
 	}
+	// 基础分 = 符 × 2^(番+2)，不超过满贯
+	baseScore := fu << uint(fan+2)
+	if baseScore > 2000 {
+		return 2000
+	}
+	return baseScore
+}
+
+// CalculateYakumanBaseScore 计算役满的基础分（每倍8000）
+func (s *ScoreCounter) CalculateYakumanBaseScore(multiple int) int {
+	return 8000 * multiple
+}
+
+// roundUp100 将点数向上取整到100点
+func roundUp100(score int) int {
+	return (score + 99) / 100 * 100
 }
 
 // CalculateRonScore 计算荣和分
 func (s *ScoreCounter) CalculateRonScore(baseScore int) int {
 	if s.Player.Oya {
-		return baseScore * 6
+		return roundUp100(baseScore * 6)
 	}
-	return baseScore * 4
+	return roundUp100(baseScore * 4)
 }
 
 // CalculateTsumoScore 计算自摸分
@@ -568,14 +655,14 @@ func (s *ScoreCounter) CalculateTsumoScore(baseScore int) [3]int {
 	var tsumoScore [3]int
 	if s.Player.Oya {
 		// 庄家自摸，每家都付2倍基础分
-		tsumoScore[0] = baseScore * 2
-		tsumoScore[1] = baseScore * 2
-		tsumoScore[2] = baseScore * 2
+		tsumoScore[0] = roundUp100(baseScore * 2)
+		tsumoScore[1] = roundUp100(baseScore * 2)
+		tsumoScore[2] = roundUp100(baseScore * 2)
 	} else {
 		// 子家自摸，庄家付2倍基础分，其他付1倍基础分
-		tsumoScore[0] = baseScore * 2
-		tsumoScore[1] = baseScore
-		tsumoScore[2] = baseScore
+		tsumoScore[0] = roundUp100(baseScore * 2)
+		tsumoScore[1] = roundUp100(baseScore)
+		tsumoScore[2] = roundUp100(baseScore)
 	}
 	return tsumoScore
 }
@@ -591,8 +678,16 @@ func (s *ScoreCounter) CheckYaku(yaku Yaku) bool {
 		return s.CheckIipeikou()
 	case Ryanpeikou:
 		return s.CheckRyanpeikou()
-	case Yakuhai, YakuhaiWind, YakuhaiWhiteBoard, YakuhaiGreenBoard, YakuhaiRedBoard:
-		return s.CheckYakuhai()
+	case Yakuhai:
+		return s.Table != nil && s.hasKoutsuOf(_1z+BaseTile(s.Table.GameWind))
+	case YakuhaiWind:
+		return s.hasKoutsuOf(_1z + BaseTile(s.Player.Wind))
+	case YakuhaiWhiteBoard:
+		return s.hasKoutsuOf(_5z)
+	case YakuhaiGreenBoard:
+		return s.hasKoutsuOf(_6z)
+	case YakuhaiRedBoard:
+		return s.hasKoutsuOf(_7z)
 	case Honitsu:
 		return s.CheckHonitsu()
 	case Chinitsu:
@@ -601,6 +696,10 @@ func (s *ScoreCounter) CheckYaku(yaku Yaku) bool {
 		return s.CheckChanta()
 	case Honchanta:
 		return s.CheckHonchanta()
+	case Junchan:
+		return s.CheckJunchan()
+	case Sanshokusequence:
+		return s.CheckSanshokudoujun()
 	case Ittsu:
 		return s.CheckIkkitsuukan()
 	case Sanshokudoukou:
@@ -630,24 +729,50 @@ func (s *ScoreCounter) CheckYaku(yaku Yaku) bool {
 	case Shosuushi:
 		return s.CheckShousuushi()
 	case Tenhou:
-		return s.CheckTenhou()
+		return s.Tsumo && s.CheckTenhou()
 	case Chihou:
-		return s.CheckChihou()
+		return s.Tsumo && s.CheckChihou()
 	case Churen:
 		return s.CheckChuren()
+	case RiichiYaku:
+		return s.CheckRiichi()
+	case IppatsuYaku:
+		return s.CheckIppatsu()
 	case Dabururiichi:
 		return s.CheckDabururiichi()
 	case Menzentsumo:
-		return s.CheckMenzentsumo()
+		return s.Tsumo && s.CheckMenzentsumo()
+	case SuankouTanki:
+		return s.CheckSuankouTanki()
+	case ChurenJunsei:
+		return s.CheckChurenJunsei()
+	case Kokushi13:
+		return s.CheckKokushi13()
+	case Renhou:
+		return s.CheckRenhou()
+	case Sanrenkou:
+		return s.CheckSanrenkou()
+	case IsshokuSanjun:
+		return s.CheckIsshokuSanjun()
+	case Daisharin:
+		return s.CheckDaisharin()
+	case Shiisanpuutaa:
+		return s.CheckShiisanpuutaa()
+	case OpenRiichi:
+		return s.CheckOpenRiichi()
+	case Tsubamegaeshi:
+		return s.CheckTsubamegaeshi()
+	case Kanburi:
+		return s.CheckKanburi()
 	default:
 		return false
 	}
 }
 
-// CheckTanyao 检查断幺
+// CheckTanyao 检查断幺（副露的牌也不能含幺九牌）
 func (s *ScoreCounter) CheckTanyao() bool {
-	for _, tile := range s.Tiles {
-		if Is1hai(tile) || Is9hai(tile) {
+	for _, tile := range s.allTiles() {
+		if IsYaochuhai(tile) {
 			return false
 		}
 	}
@@ -666,9 +791,7 @@ func (s *ScoreCounter) CheckPinfu() bool {
 	// - 所有面子为顺子
 	// - 雀头不是役牌
 	// - 胡牌为两面听（在某顺子中移除胡牌后剩下两张非幺九且相差1）
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
+	for _, ct := range s.decompositions() {
 		if len(ct.Head.Tiles) == 0 {
 			continue
 		}
@@ -688,9 +811,9 @@ func (s *ScoreCounter) CheckPinfu() bool {
 		if !allShuntsu {
 			continue
 		}
-		// 查找包含胡牌的顺子并验证为两面听
-		for _, g := range ct.Body {
-			if g.Type != Shuntsu {
+		// 查找包含胡牌的顺子并验证为两面听，评估变体时只看和牌所在的面子
+		for k, g := range ct.Body {
+			if g.Type != Shuntsu || (s.variant != nil && k != s.winBodyIndex) {
 				continue
 			}
 			idx := -1
@@ -737,9 +860,7 @@ func (s *ScoreCounter) CheckIipeikou() bool {
 	if s.IsSevenPair {
 		return false
 	}
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
+	for _, ct := range s.decompositions() {
 		// 统计相同顺子的出现次数
 		seqCount := make(map[string]int)
 		for _, g := range ct.Body {
@@ -766,9 +887,7 @@ func (s *ScoreCounter) CheckRyanpeikou() bool {
 	if s.IsSevenPair {
 		return false
 	}
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
+	for _, ct := range s.decompositions() {
 		seqCount := make(map[string]int)
 		for _, g := range ct.Body {
 			if g.Type != Shuntsu {
@@ -790,27 +909,32 @@ func (s *ScoreCounter) CheckRyanpeikou() bool {
 	return false
 }
 
-// CheckYakuhai 检查役牌（役牌对子）
+// CheckYakuhai 检查役牌（场风、自风或三元牌的刻子）
 func (s *ScoreCounter) CheckYakuhai() bool {
-	if s.Table == nil {
-		// 无法判断场风，退化为检查手牌中是否有三元牌或自家风/场风对子（保守实现）
+	for tile := _1z; tile <= _7z; tile++ {
+		if s.Table != nil && IsYakuhai(tile, s.Table.GameWind, s.Player.Wind) || Is567z(tile) {
+			if s.hasKoutsuOf(tile) {
+				return true
+			}
+		}
 	}
-	tileCount := make(map[BaseTile]int)
-	for _, t := range s.Tiles {
-		tileCount[t]++
+	return false
+}
+
+// hasKoutsuOf 判断当前拆分（或副露）中是否有 tile 的刻子或杠子
+// 没有拆分时按手牌中是否有3张判断
+func (s *ScoreCounter) hasKoutsuOf(tile BaseTile) bool {
+	for _, cg := range s.CallGroups {
+		if (cg.Type == Koutsu || cg.Type == Kantsu) && len(cg.Tiles) > 0 && cg.Tiles[0] == tile {
+			return true
+		}
 	}
-	for tile, cnt := range tileCount {
-		if cnt >= 2 {
-			if s.Table != nil {
-				if IsYakuhai(tile, s.Table.GameWind, s.Player.Wind) {
-					return true
-				}
-			} else {
-				// 只检查三元牌（白、发、中）作为保底
-				if tile == _5z || tile == _6z || tile == _7z {
-					return true
-				}
-			}
+	if s.variant == nil {
+		return CountTile(s.Tiles, tile) >= 3
+	}
+	for _, g := range s.variant.Body {
+		if (g.Type == Koutsu || g.Type == Kantsu) && g.Tiles[0] == tile {
+			return true
 		}
 	}
 	return false
@@ -818,11 +942,8 @@ func (s *ScoreCounter) CheckYakuhai() bool {
 
 // CheckHonitsu 检查混一色
 func (s *ScoreCounter) CheckHonitsu() bool {
-	if s.IsSevenPair {
-		return false
-	}
-	// 检查是否只含有一种花色和字牌
-	types := CountTileType(s.Tiles)
+	// 检查是否只含有一种花色和字牌（含副露）
+	types := CountTileType(s.allTiles())
 	numberTypes := 0
 	for i := 0; i < 3; i++ {
 		if types[i] > 0 {
@@ -834,11 +955,8 @@ func (s *ScoreCounter) CheckHonitsu() bool {
 
 // CheckChinitsu 检查清一色
 func (s *ScoreCounter) CheckChinitsu() bool {
-	if s.IsSevenPair {
-		return false
-	}
-	// 检查是否只含有一种花色
-	types := CountTileType(s.Tiles)
+	// 检查是否只含有一种花色（含副露）
+	types := CountTileType(s.allTiles())
 	numberTypes := 0
 	for i := 0; i < 3; i++ {
 		if types[i] > 0 {
@@ -871,6 +989,9 @@ func (s *ScoreCounter) GetBestResult(results []*ScoreCounterResult) *ScoreCounte
 // ToString 返回计分结果的字符串表示
 func (r *ScoreCounterResult) ToString() string {
 	str := fmt.Sprintf("番: %d, 符: %d\n", r.Fan, r.Fu)
+	if r.Yakuman > 0 {
+		str += fmt.Sprintf("役满: %d倍\n", r.Yakuman)
+	}
 	str += fmt.Sprintf("基础分: %d, 荣和: %d\n", r.BaseScore, r.RonScore)
 	str += fmt.Sprintf("自摸: %d-%d-%d\n", r.TsumoScore[0], r.TsumoScore[1], r.TsumoScore[2])
 	str += "役: "
@@ -0,0 +1,158 @@
+package mahjong
+
+// 地方役判定，只有在 GameRule.LocalYaku 中启用时才会被计分
+
+// CheckRenhou 检查人和（子家在第一次摸牌前荣和）
+func (s *ScoreCounter) CheckRenhou() bool {
+	return !s.Tsumo && !s.Player.Oya && s.Player.FirstRound && s.Player.IsMenzen()
+}
+
+// CheckSanrenkou 检查三连刻（同一花色数字相连的三个刻子）
+func (s *ScoreCounter) CheckSanrenkou() bool {
+	if s.IsSevenPair {
+		return false
+	}
+	for _, ct := range s.decompositions() {
+		koutsu := make(map[BaseTile]bool)
+		for _, g := range s.groupsWithCalls(&ct) {
+			if (g.Type == Koutsu || g.Type == Kantsu) && len(g.Tiles) > 0 {
+				koutsu[g.Tiles[0]] = true
+			}
+		}
+		for tile := range koutsu {
+			if IsTsuhai(tile) || int(tile)%9 > 6 {
+				continue
+			}
+			if koutsu[tile+1] && koutsu[tile+2] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckIsshokuSanjun 检查一色三顺（三个完全相同的顺子）
+func (s *ScoreCounter) CheckIsshokuSanjun() bool {
+	if s.IsSevenPair {
+		return false
+	}
+	for _, ct := range s.decompositions() {
+		seqCount := make(map[BaseTile]int)
+		for _, g := range s.groupsWithCalls(&ct) {
+			if g.Type == Shuntsu && len(g.Tiles) > 0 {
+				seqCount[g.Tiles[0]]++
+			}
+		}
+		for _, v := range seqCount {
+			if v >= 3 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckDaisharin 检查大车轮（门清 22334455667788 筒）
+func (s *ScoreCounter) CheckDaisharin() bool {
+	if !s.Player.IsMenzen() || len(s.Tiles) != 14 {
+		return false
+	}
+	counts := make(map[BaseTile]int)
+	for _, tile := range s.Tiles {
+		counts[tile]++
+	}
+	for tile := _2p; tile <= _8p; tile++ {
+		if counts[tile] != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckShiisanpuutaa 检查十三不塔（第一巡自摸，手牌只有一个对子且无任何搭子）
+func (s *ScoreCounter) CheckShiisanpuutaa() bool {
+	if !s.Tsumo || !s.Player.FirstRound || !s.Player.IsMenzen() {
+		return false
+	}
+	return IsShiisanpuutaaPattern(s.Tiles)
+}
+
+// IsShiisanpuutaaPattern 判断14张牌是否为十三不塔牌型
+// 恰好一个对子，其余各不相同，且同花色的数牌之间间隔至少为3
+func IsShiisanpuutaaPattern(tiles []BaseTile) bool {
+	if len(tiles) != 14 {
+		return false
+	}
+	counts := make(map[BaseTile]int)
+	for _, tile := range tiles {
+		counts[tile]++
+	}
+	pairs := 0
+	for _, c := range counts {
+		if c > 2 {
+			return false
+		}
+		if c == 2 {
+			pairs++
+		}
+	}
+	if pairs != 1 {
+		return false
+	}
+	for tile := range counts {
+		if IsTsuhai(tile) {
+			continue
+		}
+		num := int(tile) % 9
+		for d := 1; d <= 2 && num+d < 9; d++ {
+			if counts[tile+BaseTile(d)] > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CheckOpenRiichi 检查开立直（公开手牌的立直，在立直之上追加）
+func (s *ScoreCounter) CheckOpenRiichi() bool {
+	return s.Player.OpenRiichi && s.Player.IsRiichi()
+}
+
+// CheckTsubamegaeshi 检查燕返（荣和他家的立直宣言牌）
+func (s *ScoreCounter) CheckTsubamegaeshi() bool {
+	discarder := s.discarder()
+	if discarder == nil {
+		return false
+	}
+	river := discarder.River.River
+	n := len(river)
+	if n == 0 || river[n-1].Tile == nil || river[n-1].Tile.Tile != s.WinTile {
+		return false
+	}
+	// 宣言牌为河中第一张带立直标记的牌
+	return river[n-1].Riichi && (n == 1 || !river[n-2].Riichi)
+}
+
+// CheckKanburi 检查杠振（荣和他家杠后打出的牌）
+func (s *ScoreCounter) CheckKanburi() bool {
+	if s.discarder() == nil {
+		return false
+	}
+	return s.Table.KanDiscard
+}
+
+// discarder 返回荣和时放铳的玩家，自摸或无法确定时返回 nil
+func (s *ScoreCounter) discarder() *Player {
+	if s.Tsumo || s.Table == nil {
+		return nil
+	}
+	idx := s.Table.LastActor
+	if idx < 0 || idx >= NPlayers {
+		idx = s.Table.Turn
+	}
+	p := s.Table.Players[idx]
+	if p == nil || p == s.Player {
+		return nil
+	}
+	return p
+}
@@ -7,23 +7,58 @@ import (
 // 以下是从score_counter.go补充的新增役判定方法
 // 原有的方法（CheckTanyao等）已在score_counter.go中实现，不再重复
 
-// CheckIkkitsuukan 检查一通贯（123456789的连续顺子）
-func (s *ScoreCounter) CheckIkkitsuukan() bool {
-	// 检查是否包含一通贯序列
-	mCount := make(map[int]int)
-	for _, tile := range s.Tiles {
-		if int(tile)/9 == 0 { // 万
-			mCount[int(tile)%9]++
-		}
+// allTiles 返回手牌与副露中的全部牌，用于只关心牌种构成的役（断幺、混一色等）
+func (s *ScoreCounter) allTiles() []BaseTile {
+	tiles := make([]BaseTile, 0, len(s.Tiles)+4*len(s.CallGroups))
+	tiles = append(tiles, s.Tiles...)
+	for _, cg := range s.CallGroups {
+		tiles = append(tiles, cg.Tiles...)
 	}
+	return tiles
+}
 
-	// 应该有1-9各一张（可能多张）
-	for i := 0; i < 9; i++ {
-		if mCount[i] == 0 {
-			return false
+// groupsWithCalls 返回拆牌中的面子与副露面子的合并序列（不含雀头）
+func (s *ScoreCounter) groupsWithCalls(ct *CompletedTiles) []TileGroup {
+	groups := make([]TileGroup, 0, len(ct.Body)+len(s.CallGroups))
+	groups = append(groups, ct.Body...)
+	for _, cg := range s.CallGroups {
+		groups = append(groups, TileGroup{Type: cg.Type, Tiles: cg.Tiles})
+	}
+	return groups
+}
+
+// decompositions 返回役判定所用的拆分：评估变体时只有当前拆分，否则为全部拆分
+// 七对子、国士无双等没有雀头的变体不是一般形，返回空
+func (s *ScoreCounter) decompositions() []CompletedTiles {
+	if s.variant == nil {
+		return DecomposeTiles(s.Tiles)
+	}
+	if len(s.variant.Head.Tiles) == 0 {
+		return nil
+	}
+	return []CompletedTiles{*s.variant}
+}
+
+// CheckIkkitsuukan 检查一通贯（同一花色的123、456、789三个顺子）
+func (s *ScoreCounter) CheckIkkitsuukan() bool {
+	if s.IsSevenPair {
+		return false
+	}
+	for _, ct := range s.decompositions() {
+		starts := make(map[BaseTile]bool)
+		for _, g := range s.groupsWithCalls(&ct) {
+			if g.Type == Shuntsu && len(g.Tiles) > 0 {
+				starts[g.Tiles[0]] = true
+			}
+		}
+		for suit := 0; suit < 3; suit++ {
+			base := BaseTile(suit * 9)
+			if starts[base] && starts[base+3] && starts[base+6] {
+				return true
+			}
 		}
 	}
-	return true
+	return false
 }
 
 // CheckChanta 检查全带幺（所有面子都含有幺九牌）
@@ -32,47 +67,76 @@ func (s *ScoreCounter) CheckChanta() bool {
 		return false
 	}
 	// 需要拆分牌型后检查
-	// 所有面子都必须包含1或9
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
-		if CheckCompletedTilesHasYaochu(&ct) {
+	// 所有面子（含副露）都必须包含幺九牌或字牌
+	for _, ct := range s.decompositions() {
+		full := CompletedTiles{Head: ct.Head, Body: s.groupsWithCalls(&ct)}
+		if CheckCompletedTilesHasYaochu(&full) {
 			return true
 		}
 	}
 	return false
 }
 
-// CheckHonchanta 检查混全带幺（所有面子都含有幺九牌或字牌）
+// CheckHonchanta 检查混全带幺（全带幺且含有字牌）
 func (s *ScoreCounter) CheckHonchanta() bool {
 	if s.IsSevenPair {
 		return false
 	}
-	// 需要拆分牌型后检查
-	return s.CheckChanta() // 简化：混全带幺和全带幺在这里返回值相同
+	return s.CheckChanta() && CountTileType(s.allTiles())[3] > 0
 }
 
-// CheckSanshokudoukou 检查三色同刻（万、筒、索各有一个相同的刻子）
-func (s *ScoreCounter) CheckSanshokudoukou() bool {
+// CheckJunchan 检查纯全带幺（所有面子都含有老头牌，不含字牌）
+func (s *ScoreCounter) CheckJunchan() bool {
 	if s.IsSevenPair {
 		return false
 	}
-	// 需要拆分牌型后检查
-	// 统计各花色的刻子（对子和刻子）
-	// 如果存在相同数字的刻子在三个不同花色中，则成立
+	for _, ct := range s.decompositions() {
+		full := CompletedTiles{Head: ct.Head, Body: s.groupsWithCalls(&ct)}
+		if s.CheckJunchanWithCompletedTiles(&full) {
+			return true
+		}
+	}
+	return false
+}
 
-	tileCount := make(map[BaseTile]int)
-	for _, tile := range s.Tiles {
-		tileCount[tile]++
+// CheckSanshokudoujun 检查三色同顺（万、筒、索各有一个相同数字的顺子）
+func (s *ScoreCounter) CheckSanshokudoujun() bool {
+	if s.IsSevenPair {
+		return false
 	}
+	for _, ct := range s.decompositions() {
+		starts := make(map[BaseTile]bool)
+		for _, g := range s.groupsWithCalls(&ct) {
+			if g.Type == Shuntsu && len(g.Tiles) > 0 {
+				starts[g.Tiles[0]] = true
+			}
+		}
+		for num := 0; num < 7; num++ {
+			if starts[BaseTile(num)] && starts[BaseTile(9+num)] && starts[BaseTile(18+num)] {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	// 检查每个数字是否在三个花色中都有3张或以上的牌
-	for num := 0; num < 9; num++ {
-		m := tileCount[BaseTile(0*9+num)]
-		p := tileCount[BaseTile(1*9+num)]
-		s_tiles := tileCount[BaseTile(2*9+num)]
-		if m >= 3 && p >= 3 && s_tiles >= 3 {
-			return true
+// CheckSanshokudoukou 检查三色同刻（万、筒、索各有一个相同数字的刻子）
+func (s *ScoreCounter) CheckSanshokudoukou() bool {
+	if s.IsSevenPair {
+		return false
+	}
+	// 需要拆分牌型后检查，副露的刻子与杠子同样计入
+	for _, ct := range s.decompositions() {
+		koutsu := make(map[BaseTile]bool)
+		for _, g := range s.groupsWithCalls(&ct) {
+			if (g.Type == Koutsu || g.Type == Kantsu) && len(g.Tiles) > 0 {
+				koutsu[g.Tiles[0]] = true
+			}
+		}
+		for num := 0; num < 9; num++ {
+			if koutsu[BaseTile(num)] && koutsu[BaseTile(9+num)] && koutsu[BaseTile(18+num)] {
+				return true
+			}
 		}
 	}
 	return false
@@ -81,21 +145,18 @@ func (s *ScoreCounter) CheckSanshokudoukou() bool {
 // CheckToitoi 检查对对和（所有面子都是刻子，包括对子）
 func (s *ScoreCounter) CheckToitoi() bool {
 	if s.IsSevenPair {
-		return true // 七对子本质上是对对和
+		return false
 	}
 	// 需要拆分牌型后检查
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-
-	for _, ct := range allCompleted {
+	for _, ct := range s.decompositions() {
 		allKoutsuOrToitsu := true
 		// 检查雀头是对子
 		if ct.Head.Type != Toitsu {
 			allKoutsuOrToitsu = false
 		}
-		// 检查所有身子都是刻子
+		// 检查所有身子（含副露）都是刻子
 		if allKoutsuOrToitsu {
-			for _, group := range ct.Body {
+			for _, group := range s.groupsWithCalls(&ct) {
 				if group.Type != Koutsu && group.Type != Kantsu {
 					allKoutsuOrToitsu = false
 					break
@@ -109,35 +170,53 @@ func (s *ScoreCounter) CheckToitoi() bool {
 	return false
 }
 
-// CheckSanankou 检查三暗刻（三个暗刻）
-func (s *ScoreCounter) CheckSanankou() bool {
-	// 三暗刻：存在至少3个暗刻（暗刻包括手中形成的刻子或暗杠）
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
-		nAnkou := 0
-		// 统计手中（闭合）刻子/暗杠
-		for _, g := range ct.Body {
-			if g.Type == Koutsu || g.Type == Kantsu {
-				nAnkou++
-			}
+// countAnkou 统计拆分中的暗刻数（含暗杠）
+// 荣和时被和牌完成的刻子视为明刻
+func (s *ScoreCounter) countAnkou(ct *CompletedTiles) int {
+	nAnkou := 0
+	// 统计手中（闭合）刻子/暗杠
+	for i, g := range ct.Body {
+		if g.Type != Koutsu && g.Type != Kantsu {
+			continue
 		}
-		// 统计暗杠（在 CallGroups 中以 IsOpen==false 标记）
-		for _, cg := range s.CallGroups {
-			if cg.Type == Kantsu && !cg.IsOpen {
-				nAnkou++
-			}
+		if ct == s.variant && !s.Tsumo && i == s.winBodyIndex {
+			continue
 		}
-		if nAnkou >= 3 {
-			return true
+		nAnkou++
+	}
+	// 统计暗杠（在 CallGroups 中以 IsOpen==false 标记）
+	for _, cg := range s.CallGroups {
+		if cg.Type == Kantsu && !cg.IsOpen {
+			nAnkou++
 		}
 	}
-	return false
+	return nAnkou
+}
+
+// maxAnkou 返回当前变体（若无则为所有拆分中）的最大暗刻数
+func (s *ScoreCounter) maxAnkou() int {
+	if s.variant != nil {
+		return s.countAnkou(s.variant)
+	}
+	best := 0
+	allCompleted := DecomposeTiles(s.Tiles)
+	for i := range allCompleted {
+		if n := s.countAnkou(&allCompleted[i]); n > best {
+			best = n
+		}
+	}
+	return best
+}
+
+// CheckSanankou 检查三暗刻（三个暗刻）
+func (s *ScoreCounter) CheckSanankou() bool {
+	// 三暗刻：存在至少3个暗刻（暗刻包括手中形成的刻子或暗杠）
+	return s.maxAnkou() >= 3
 }
 
 // CheckTsuisou 检查字一色（全是字牌）
 func (s *ScoreCounter) CheckTsuisou() bool {
-	for _, tile := range s.Tiles {
+	for _, tile := range s.allTiles() {
 		if int(tile)/9 != 3 { // 不是字牌
 			return false
 		}
@@ -153,10 +232,10 @@ func (s *ScoreCounter) CheckRyuuisou() bool {
 		_4s: true,
 		_6s: true,
 		_8s: true,
-		_5z: true, // 绿三元牌
+		_6z: true, // 绿三元牌（发）
 	}
 
-	for _, tile := range s.Tiles {
+	for _, tile := range s.allTiles() {
 		if !greenTiles[tile] {
 			return false
 		}
@@ -166,7 +245,7 @@ func (s *ScoreCounter) CheckRyuuisou() bool {
 
 // CheckChinroutou 检查清老头（全是幺九牌）
 func (s *ScoreCounter) CheckChinroutou() bool {
-	for _, tile := range s.Tiles {
+	for _, tile := range s.allTiles() {
 		if !Is1hai(tile) && !Is9hai(tile) {
 			return false
 		}
@@ -176,7 +255,7 @@ func (s *ScoreCounter) CheckChinroutou() bool {
 
 // CheckHonroutou 检查混老头（幺九牌和字牌）
 func (s *ScoreCounter) CheckHonroutou() bool {
-	for _, tile := range s.Tiles {
+	for _, tile := range s.allTiles() {
 		tileType := int(tile) / 9
 		tileNum := int(tile) % 9
 
@@ -218,43 +297,15 @@ func (s *ScoreCounter) CheckKokushi() bool {
 	if !s.Player.IsMenzen() {
 		return false
 	}
+	return IsKokushiPattern(s.Tiles)
+}
 
-	terminalHonors := []BaseTile{_1m, _9m, _1s, _9s, _1p, _9p, _1z, _2z, _3z, _4z, _5z, _6z, _7z}
-
-	if len(s.Tiles) != 14 {
-		return false
-	}
-
-	// 计数
-	tileCount := make(map[BaseTile]int)
-	for _, tile := range s.Tiles {
-		tileCount[tile]++
-		// 如果包含非幺九牌，直接返回false
-		found := false
-		for _, t := range terminalHonors {
-			if tile == t {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	// 检查是否有13种幺九牌各至少一张
-	hasAll := true
-	for _, t := range terminalHonors {
-		if tileCount[t] == 0 {
-			hasAll = false
-			break
-		}
-	}
-
-	return hasAll
+// CheckKokushi13 检查国士无双十三面（和牌前13种幺九牌各一张）
+func (s *ScoreCounter) CheckKokushi13() bool {
+	return s.CheckKokushi() && CountTile(s.Tiles, s.WinTile) == 2
 }
 
-// CheckChuren 检查九莲宝灯（一种花色的1-9各一张，加一张1-9）
+// CheckChuren 检查九莲宝灯（一种花色的1112345678999，加一张同花色牌）
 func (s *ScoreCounter) CheckChuren() bool {
 	if !s.Player.IsMenzen() {
 		return false
@@ -264,11 +315,6 @@ func (s *ScoreCounter) CheckChuren() bool {
 		return false
 	}
 
-	// 检查是否为单一花色
-	if len(s.Tiles) == 0 {
-		return false
-	}
-
 	firstType := int(s.Tiles[0]) / 9
 	if firstType >= 3 { // 字牌不符合
 		return false
@@ -284,11 +330,11 @@ func (s *ScoreCounter) CheckChuren() bool {
 		counts[tile]++
 	}
 
-	// 检查1和9各至少有2张，2-8各至少1张
+	// 检查1和9各至少有3张，2-8各至少1张
 	for i := 1; i <= 9; i++ {
 		baseTile := BaseTile(firstType*9 + i - 1)
 		if i == 1 || i == 9 {
-			if counts[baseTile] < 2 {
+			if counts[baseTile] < 3 {
 				return false
 			}
 		} else {
@@ -301,6 +347,29 @@ func (s *ScoreCounter) CheckChuren() bool {
 	return true
 }
 
+// CheckChurenJunsei 检查纯正九莲宝灯（和牌前为1112345678999九面听）
+func (s *ScoreCounter) CheckChurenJunsei() bool {
+	if !s.CheckChuren() {
+		return false
+	}
+	base := int(s.Tiles[0]) / 9 * 9
+	counts := make(map[BaseTile]int)
+	for _, tile := range s.Tiles {
+		counts[tile]++
+	}
+	counts[s.WinTile]--
+	for i := 0; i < 9; i++ {
+		want := 1
+		if i == 0 || i == 8 {
+			want = 3
+		}
+		if counts[BaseTile(base+i)] != want {
+			return false
+		}
+	}
+	return true
+}
+
 // CheckTenhou 检查天胡（庄家第一手自摸）
 func (s *ScoreCounter) CheckTenhou() bool {
 	return s.Player.Oya && s.Player.FirstRound
@@ -317,7 +386,7 @@ func (s *ScoreCounter) CheckDaisangen() bool {
 	greenCount := 0
 	redCount := 0
 
-	for _, tile := range s.Tiles {
+	for _, tile := range s.allTiles() {
 		switch tile {
 		case _5z: // 白板
 			whiteCount++
@@ -337,25 +406,7 @@ func (s *ScoreCounter) CheckSiiankou() bool {
 		return false
 	}
 	// 四暗刻：闭合手中存在4个暗刻（包含暗杠）
-	splitter := GetTileSplitter()
-	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
-	for _, ct := range allCompleted {
-		nAnkou := 0
-		for _, g := range ct.Body {
-			if g.Type == Koutsu || g.Type == Kantsu {
-				nAnkou++
-			}
-		}
-		for _, cg := range s.CallGroups {
-			if cg.Type == Kantsu && !cg.IsOpen {
-				nAnkou++
-			}
-		}
-		if nAnkou >= 4 {
-			return true
-		}
-	}
-	return false
+	return s.maxAnkou() >= 4
 }
 
 // CheckSuankou 检查四暗刻（别称）
@@ -363,12 +414,18 @@ func (s *ScoreCounter) CheckSuankou() bool {
 	return s.CheckSiiankou()
 }
 
+// CheckSuankouTanki 检查四暗刻单骑（四暗刻且和在雀头）
+func (s *ScoreCounter) CheckSuankouTanki() bool {
+	return s.winOnHead && s.CheckSiiankou()
+}
+
 // CheckDaisuushi 检查大四喜（四个风牌各一刻）
 func (s *ScoreCounter) CheckDaisuushi() bool {
 	windTiles := []BaseTile{_1z, _2z, _3z, _4z}
+	all := s.allTiles()
 	for _, tile := range windTiles {
 		count := 0
-		for _, t := range s.Tiles {
+		for _, t := range all {
 			if t == tile {
 				count++
 			}
@@ -386,14 +443,15 @@ func (s *ScoreCounter) CheckShousuushi() bool {
 	kokakuCount := 0
 	pairCount := 0
 
+	all := s.allTiles()
 	for _, tile := range windTiles {
 		count := 0
-		for _, t := range s.Tiles {
+		for _, t := range all {
 			if t == tile {
 				count++
 			}
 		}
-		if count == 3 {
+		if count >= 3 {
 			kokakuCount++
 		} else if count == 2 {
 			pairCount++
@@ -414,9 +472,9 @@ func (s *ScoreCounter) CheckSankantsu() bool {
 	return kanCount >= 3
 }
 
-// CheckRiichi 检查立直
+// CheckRiichi 检查立直（两立直时只计两立直）
 func (s *ScoreCounter) CheckRiichi() bool {
-	return s.Player.Riichi
+	return s.Player.Riichi && !s.Player.DoubleRiichi
 }
 
 // CheckDabururiichi 检查双立直
@@ -426,7 +484,61 @@ func (s *ScoreCounter) CheckDabururiichi() bool {
 
 // CheckIppatsu 检查一发
 func (s *ScoreCounter) CheckIppatsu() bool {
-	return s.Player.Ippatsu
+	return s.Player.Ippatsu && s.Player.IsRiichi()
+}
+
+// countDora 统计手牌与副露中宝牌的张数，同一宝牌被翻出多次时重复计数
+func (s *ScoreCounter) countDora(doras []BaseTile) int {
+	n := 0
+	for _, tile := range s.allTiles() {
+		for _, dora := range doras {
+			if tile == dora {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// countAkadora 统计手牌、副露与荣和牌中赤宝牌的张数
+func (s *ScoreCounter) countAkadora() int {
+	n := CountRedDora(s.Player.Hand)
+	for _, cg := range s.CallGroups {
+		n += cg.RedDora
+	}
+	if !s.Tsumo && s.RonTileRed {
+		n++
+	}
+	return n
+}
+
+// doraYakus 返回宝牌、里宝牌与赤宝牌及其番数，里宝牌只对立直和牌者计算
+func (s *ScoreCounter) doraYakus() ([]Yaku, []int) {
+	var yakus []Yaku
+	var fans []int
+	if n := s.countAkadora(); n > 0 {
+		yakus = append(yakus, AkadoraYaku)
+		fans = append(fans, n)
+	}
+	if s.Table == nil {
+		return yakus, fans
+	}
+	if n := s.countDora(s.Table.GetDora()); n > 0 {
+		yakus = append(yakus, DoraYaku)
+		fans = append(fans, n)
+	}
+	if s.Player.IsRiichi() {
+		uras := s.Table.GetUraDora()
+		// 不承认杠里宝牌时只看第一张里宝牌指示牌
+		if !s.rule().KanUraDora && len(uras) > 1 {
+			uras = uras[:1]
+		}
+		if n := s.countDora(uras); n > 0 {
+			yakus = append(yakus, UradoraYaku)
+			fans = append(fans, n)
+		}
+	}
+	return yakus, fans
 }
 
 // CheckMenzentsumo 检查门清自摸
@@ -596,7 +708,7 @@ func SortBaseTiles(tiles []BaseTile) {
 
 // GetBestCompletedTiles 从所有可能的拆牌中选择最佳（最高番数）的
 func (s *ScoreCounter) GetBestCompletedTiles() *CompletedTiles {
-	splitter := GetTileSplitter()
+	splitter := NewTileSplitter()
 	allCompleted := splitter.GetAllCompletedTiles(s.Tiles)
 
 	if len(allCompleted) == 0 {
@@ -0,0 +1,178 @@
+package mahjong
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// AgentFactory 为一局中的某个座位创建智能体，seed 由该局的种子派生
+type AgentFactory func(seat int, seed int64) Agent
+
+// RandomAgentFactory 创建随机智能体
+func RandomAgentFactory(seat int, seed int64) Agent {
+	return NewRandomAgent(seed)
+}
+
+// SelfPlayConfig 自我对局的配置
+type SelfPlayConfig struct {
+	Games   int                    // 对局数
+	Seed    int64                  // 基础种子，每局的种子由 GameSeed 派生
+	Workers int                    // 并行的 goroutine 数，不大于0时使用 GOMAXPROCS
+	Agents  [NPlayers]AgentFactory // 各座位的智能体，为空时使用随机智能体
+	Rule    *GameRule              // 规则，为空时使用默认规则
+
+	// Profiler 不为空时每局使用一个独立的作用域计时
+	Profiler *Profiler
+}
+
+// SelfPlayResult 一局自我对局的结果
+type SelfPlayResult struct {
+	Game    int               // 对局序号（从0开始）
+	Seed    int64             // 该局的种子
+	Info    EpisodeInfo       // 对局信息
+	Payoffs [NPlayers]float64 // 四家的点数变化
+	Log     *GameLogRecord    // 牌谱
+}
+
+// SelfPlaySink 接收自我对局的结果
+// Consume 只会在 RunSelfPlay 的调用者 goroutine 中依次调用，结果按完成顺序而不是序号到达
+type SelfPlaySink interface {
+	Consume(result *SelfPlayResult) error
+}
+
+// SelfPlaySinkFunc 将函数适配为 SelfPlaySink
+type SelfPlaySinkFunc func(result *SelfPlayResult) error
+
+// Consume 实现 SelfPlaySink
+func (f SelfPlaySinkFunc) Consume(result *SelfPlayResult) error {
+	return f(result)
+}
+
+// WriterSink 将每局的结果与牌谱以文本写入 W
+type WriterSink struct {
+	W       io.Writer
+	WithLog bool // 是否写入完整牌谱
+}
+
+// Consume 实现 SelfPlaySink
+func (s *WriterSink) Consume(result *SelfPlayResult) error {
+	_, err := fmt.Fprintf(s.W, "game %d seed %d oya %d %s payoffs %v\n",
+		result.Game, result.Seed, result.Info.Oya, result.Info.Result.Type, result.Payoffs)
+	if err == nil && s.WithLog {
+		_, err = fmt.Fprintf(s.W, "%s\n\n", result.Log)
+	}
+	return err
+}
+
+// GameSeed 由基础种子与对局序号派生该局的种子（splitmix64），与并行方式无关
+func GameSeed(base int64, game int) int64 {
+	z := uint64(base) + uint64(game+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
+
+// PlaySelfPlayGame 进行第 game 局自我对局，结果只由 config 与 game 决定
+func PlaySelfPlayGame(config SelfPlayConfig, game int) (*SelfPlayResult, error) {
+	seed := GameSeed(config.Seed, game)
+	var agents [NPlayers]Agent
+	for seat, factory := range config.Agents {
+		if factory == nil {
+			factory = RandomAgentFactory
+		}
+		agents[seat] = factory(seat, GameSeed(seed, seat))
+	}
+
+	env := NewMahjongEnv(seed)
+	env.Rule = config.Rule
+	if config.Profiler != nil {
+		env.Profile = config.Profiler.NewScope(fmt.Sprintf("game %d", game))
+		defer env.Profile.Close()
+	}
+	opts := DefaultResetOptions()
+	opts.Oya = game % NPlayers
+	opts.HasSeed = true
+	opts.Seed = seed
+	env.ResetWithOptions(opts)
+
+	for !env.IsOver() {
+		player := env.CurrentPlayer()
+		action := agents[player].SelectAction(env.GetObs(player))
+		if err := env.Step(player, action); err != nil {
+			return nil, fmt.Errorf("game %d seat %d: %w", game, player, err)
+		}
+	}
+	return &SelfPlayResult{
+		Game:    game,
+		Seed:    seed,
+		Info:    env.Info(),
+		Payoffs: env.GetPayoffs(),
+		Log:     env.Table.GameLog,
+	}, nil
+}
+
+// RunSelfPlay 在多个 goroutine 中进行 config.Games 局自我对局，并把结果交给 sink
+// 遇到对局错误、sink 返回错误或 ctx 取消时停止，返回对应的错误
+func RunSelfPlay(ctx context.Context, config SelfPlayConfig, sink SelfPlaySink) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	type outcome struct {
+		result *SelfPlayResult
+		err    error
+	}
+	games := make(chan int)
+	outcomes := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range games {
+				result, err := PlaySelfPlayGame(config, game)
+				select {
+				case outcomes <- outcome{result, err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(games)
+		for game := 0; game < config.Games; game++ {
+			select {
+			case games <- game:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	for o := range outcomes {
+		err := o.err
+		if err == nil {
+			err = sink.Consume(o.result)
+		}
+		if err != nil {
+			cancel()
+			for range outcomes {
+			}
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,117 @@
+package mahjong
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func collectSelfPlay(t *testing.T, config SelfPlayConfig) map[int]*SelfPlayResult {
+	t.Helper()
+	results := make(map[int]*SelfPlayResult)
+	err := RunSelfPlay(context.Background(), config, SelfPlaySinkFunc(func(r *SelfPlayResult) error {
+		if _, ok := results[r.Game]; ok {
+			t.Fatalf("game %d reported twice", r.Game)
+		}
+		results[r.Game] = r
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestRunSelfPlayDeterministic(t *testing.T) {
+	config := SelfPlayConfig{Games: 8, Seed: 2024, Workers: 1}
+	first := collectSelfPlay(t, config)
+	second := collectSelfPlay(t, config)
+	if len(first) != config.Games {
+		t.Fatalf("expected %d games, got %d", config.Games, len(first))
+	}
+	for game, r := range first {
+		if r.Info.Result == nil || r.Info.Oya != game%NPlayers || r.Seed != GameSeed(config.Seed, game) {
+			t.Fatalf("game %d: bad result %+v", game, r.Info)
+		}
+		if r.Payoffs != second[game].Payoffs || r.Log.String() != second[game].Log.String() {
+			t.Fatalf("game %d differs between runs", game)
+		}
+	}
+
+	// 单独重放某一局得到相同的结果
+	replay, err := PlaySelfPlayGame(config, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Log.String() != first[5].Log.String() {
+		t.Fatalf("replaying game 5 should reproduce it")
+	}
+}
+
+func TestGameSeedDistinct(t *testing.T) {
+	seen := make(map[int64]bool)
+	for game := 0; game < 1000; game++ {
+		seed := GameSeed(1, game)
+		if seen[seed] {
+			t.Fatalf("duplicated seed for game %d", game)
+		}
+		seen[seed] = true
+	}
+	if GameSeed(1, 0) == GameSeed(2, 0) {
+		t.Fatalf("different base seeds should give different game seeds")
+	}
+}
+
+func TestRunSelfPlayCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	consumed := 0
+	err := RunSelfPlay(ctx, SelfPlayConfig{Games: 100, Workers: 1}, SelfPlaySinkFunc(func(r *SelfPlayResult) error {
+		consumed++
+		if consumed == 2 {
+			cancel()
+		}
+		return nil
+	}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if consumed >= 100 {
+		t.Fatalf("cancellation should stop the runner early")
+	}
+}
+
+func TestRunSelfPlayErrors(t *testing.T) {
+	sinkErr := errors.New("sink full")
+	err := RunSelfPlay(context.Background(), SelfPlayConfig{Games: 4, Workers: 1}, SelfPlaySinkFunc(func(r *SelfPlayResult) error {
+		return sinkErr
+	}))
+	if !errors.Is(err, sinkErr) {
+		t.Fatalf("expected the sink error, got %v", err)
+	}
+
+	config := SelfPlayConfig{Games: 4, Workers: 1}
+	config.Agents[2] = func(seat int, seed int64) Agent { return badAgent{} }
+	err = RunSelfPlay(context.Background(), config, SelfPlaySinkFunc(func(r *SelfPlayResult) error { return nil }))
+	if err == nil || !strings.Contains(err.Error(), "seat 2") {
+		t.Fatalf("expected an error from seat 2, got %v", err)
+	}
+}
+
+// badAgent 总是选择不合法的动作
+type badAgent struct{}
+
+func (badAgent) SelectAction(view *PlayerView) int { return -1 }
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &WriterSink{W: &buf, WithLog: true}
+	if err := RunSelfPlay(context.Background(), SelfPlayConfig{Games: 2, Workers: 1}, sink); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Count(out, "game ") != 2 || !strings.Contains(out, "Discard") {
+		t.Fatalf("unexpected sink output:\n%s", out)
+	}
+}
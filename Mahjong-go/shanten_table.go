@@ -0,0 +1,216 @@
+package mahjong
+
+import "sync"
+
+// 一般形向听数查表
+// 万、筒、索与字牌分别以各牌张数为5进制数字（该花色的第一种牌为最低位）作为键，
+// 表中记录这一花色在有无雀头、面子与搭子合计至少 k 块时最多的面子数，合并四个花色即得向听数：
+// 向听数 = 2*need - 雀头 - 面子 - min(面子+搭子, need)，与 searchNormalShanten 相同。
+// 表在第一次使用时生成，之后只读，可以在多个 goroutine 中同时使用
+
+const (
+	maxTableTiles = 14 // 查表的一个花色最多的张数
+	maxBlocks     = 4  // 面子数与计入向听的搭子数的上限
+)
+
+// blockEntry 生成查表时一个花色的结果：(雀头数 h, 面子数 m) 对应的位置 3*(5h+m) 上存最多的搭子数加1，0 表示拆不出
+// 整个值为0表示该键不在表中（超过 maxTableTiles 张）
+type blockEntry uint32
+
+func (e blockEntry) taatsu(head, mentsu int) int {
+	return int(e>>(3*(5*head+mentsu))&7) - 1
+}
+
+func (e *blockEntry) improve(head, mentsu, taatsu int) {
+	if e.taatsu(head, mentsu) >= taatsu {
+		return
+	}
+	shift := 3 * (5*head + mentsu)
+	*e = *e&^(7<<shift) | blockEntry(taatsu+1)<<shift
+}
+
+// suitBlocks 一个花色在 [雀头数][k] 下，面子与搭子合计至少 k 块时最多的面子数，-1 表示拆不出
+// 对 k 单调不增
+type suitBlocks [2][maxBlocks + 1]int8
+
+// blockTable 查表：键对应 blocks 中的下标，0 表示不在表中
+type blockTable struct {
+	index  []uint16
+	blocks []suitBlocks
+}
+
+var (
+	blockTablesOnce sync.Once
+	suitBlockTable  *blockTable // 数牌，键为9位5进制数
+	honorBlockTable *blockTable // 字牌，键为7位5进制数
+)
+
+// blockTableFor 返回第 s 个花色（0~2 数牌，3 字牌）的查表与牌的种数，第一次调用时生成
+func blockTableFor(s int) (*blockTable, int) {
+	blockTablesOnce.Do(func() {
+		suitBlockTable = buildBlockTable(9, true)
+		honorBlockTable = buildBlockTable(7, false)
+	})
+	if s == 3 {
+		return honorBlockTable, 7
+	}
+	return suitBlockTable, 9
+}
+
+// buildBlockTable 生成 n 种牌的查表，sequences 为 false 时没有顺子与顺子的搭子（字牌）
+// 按键从小到大生成：取出含最小一张牌的块之后键变小，结果已在表中
+func buildBlockTable(n int, sequences bool) *blockTable {
+	pow := make([]int, n+1)
+	pow[0] = 1
+	for i := 1; i <= n; i++ {
+		pow[i] = pow[i-1] * 5
+	}
+	entries := make([]blockEntry, pow[n])
+	entries[0].improve(0, 0, 0)
+
+	counts := make([]int, n)
+	sum := 0
+	for key := 1; key < pow[n]; key++ {
+		// 键加1：低位进位
+		for i := 0; ; i++ {
+			if counts[i] < 4 {
+				counts[i]++
+				sum++
+				break
+			}
+			counts[i] = 0
+			sum -= 4
+		}
+		if sum > maxTableTiles {
+			continue
+		}
+		p := 0
+		for counts[p] == 0 {
+			p++
+		}
+		e := &entries[key]
+		add := func(sub, head, mentsu, taatsu int) {
+			from := entries[key-sub]
+			for h := 0; h+head <= 1; h++ {
+				for m := 0; m+mentsu <= maxBlocks; m++ {
+					if t := from.taatsu(h, m); t >= 0 {
+						e.improve(h+head, m+mentsu, min(t+taatsu, maxBlocks))
+					}
+				}
+			}
+		}
+		add(pow[p], 0, 0, 0) // 孤张
+		if counts[p] >= 2 {
+			add(2*pow[p], 1, 0, 0) // 雀头
+			add(2*pow[p], 0, 0, 1) // 对子搭子
+		}
+		if counts[p] >= 3 {
+			add(3*pow[p], 0, 1, 0) // 刻子
+		}
+		if sequences && p+1 < n && counts[p+1] > 0 {
+			add(pow[p]+pow[p+1], 0, 0, 1) // 两面、边张
+			if p+2 < n && counts[p+2] > 0 {
+				add(pow[p]+pow[p+1]+pow[p+2], 0, 1, 0) // 顺子
+			}
+		}
+		if sequences && p+2 < n && counts[p+2] > 0 {
+			add(pow[p]+pow[p+2], 0, 0, 1) // 嵌张
+		}
+	}
+
+	// 不同的结果只有几百种，表中只存下标
+	table := &blockTable{index: make([]uint16, len(entries)), blocks: make([]suitBlocks, 1)}
+	seen := make(map[blockEntry]uint16)
+	for key, e := range entries {
+		if e == 0 {
+			continue
+		}
+		i, ok := seen[e]
+		if !ok {
+			var b suitBlocks
+			for h := range b {
+				for k := range b[h] {
+					b[h][k] = -1
+					for m := 0; m <= maxBlocks; m++ {
+						if t := e.taatsu(h, m); t >= 0 && m+t >= k {
+							b[h][k] = int8(m)
+						}
+					}
+				}
+			}
+			i = uint16(len(table.blocks))
+			table.blocks = append(table.blocks, b)
+			seen[e] = i
+		}
+		table.index[key] = i
+	}
+	return table
+}
+
+// pow5 5的幂，第 i 种牌在键中的权重
+var pow5 = [9]int{1, 5, 25, 125, 625, 3125, 15625, 78125, 390625}
+
+// lookup 返回键对应的结果，不在表中时返回 nil
+func (t *blockTable) lookup(key int) *suitBlocks {
+	if i := t.index[key]; i != 0 {
+		return &t.blocks[i]
+	}
+	return nil
+}
+
+// blockEntries 返回手牌四个花色的查表结果，有花色不在表中时返回 false
+func (h *Hand34) blockEntries() (blocks [4]*suitBlocks, ok bool) {
+	for s := 0; s < 4; s++ {
+		table, n := blockTableFor(s)
+		key := 0
+		for i := n - 1; i >= 0; i-- {
+			c := h.Counts[9*s+i]
+			if c > 4 {
+				return blocks, false
+			}
+			key = key*5 + int(c)
+		}
+		if blocks[s] = table.lookup(key); blocks[s] == nil {
+			return blocks, false
+		}
+	}
+	return blocks, true
+}
+
+// noBlocks 没有任何拆法的结果，合并时的初始值
+var noBlocks = suitBlocks{{-1, -1, -1, -1, -1}, {-1, -1, -1, -1, -1}}
+
+// mergeBlocks 合并两组花色的结果：块数 k 分给两边，面子数相加
+func mergeBlocks(a, b *suitBlocks) suitBlocks {
+	merged := noBlocks
+	for h1 := 0; h1 <= 1; h1++ {
+		for h2 := 0; h1+h2 <= 1; h2++ {
+			row, ra, rb := &merged[h1+h2], &a[h1], &b[h2]
+			for k1 := 0; k1 <= maxBlocks && ra[k1] >= 0; k1++ {
+				for k2 := 0; k1+k2 <= maxBlocks && rb[k2] >= 0; k2++ {
+					row[k1+k2] = max(row[k1+k2], ra[k1]+rb[k2])
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// shantenFromBlocks 合并四个花色的查表结果，返回需要 need 个面子时的一般形向听数
+// 两组合并后直接取块数不超过 need 的最优值
+func shantenFromBlocks(blocks *[4]*suitBlocks, need int) int {
+	a := mergeBlocks(blocks[0], blocks[1])
+	b := mergeBlocks(blocks[2], blocks[3])
+	best := 0
+	for h1 := 0; h1 <= 1; h1++ {
+		for h2 := 0; h1+h2 <= 1; h2++ {
+			ra, rb := &a[h1], &b[h2]
+			for k1 := 0; k1 <= need && ra[k1] >= 0; k1++ {
+				for k2 := 0; k1+k2 <= need && rb[k2] >= 0; k2++ {
+					best = max(best, h1+h2+int(ra[k1]+rb[k2])+k1+k2)
+				}
+			}
+		}
+	}
+	return 2*need - best
+}
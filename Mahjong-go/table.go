@@ -3,6 +3,7 @@ package mahjong
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -17,6 +18,7 @@ type Table struct {
 	// 牌和宝牌相关
 	Tiles            [NTiles]*Tile // 所有牌的数组
 	NActiveDora      int           // 翻开的宝牌指示牌数量
+	PendingKanDora   int           // 明杠、加杠后尚未翻开的宝牌数量
 	DoraIndicator    []*Tile       // 宝牌指示牌
 	UraDoraIndicator []*Tile       // 里宝牌指示牌
 	Yama             []*Tile       // 牌山（剩余的牌）
@@ -25,10 +27,23 @@ type Table struct {
 	Players    [NPlayers]*Player // 四个玩家
 	Turn       int               // 当前玩家的回合
 	LastAction BaseAction        // 上一个行动
+	KanDiscard bool              // 上一张弃牌是否为杠后打出（杠振）
 	GameWind   Wind              // 场风（东、南、西、北）
 	Oya        int               // 庄家索引
 	Honba      int               // 本场数
 	Kyoutaku   int               // 供托数
+	Rule       *GameRule         // 可选规则
+
+	// 流程控制（与 C++ 的 phase/self_actions/response_actions 对应）
+	Phase           Phase             // 当前阶段
+	SelfActions     []*SelfAction     // 回合玩家可选的自主行动
+	ResponseActions []*ResponseAction // 当前响应玩家可选的响应
+	SelectedAction  *SelfAction       // 回合玩家选定的自主行动
+	SelectedTile    *Tile             // 打出或杠出的牌
+	Responses       []*ResponseAction // 已收集的各家响应（下标为玩家索引）
+	FinalAction     BaseAction        // 各家响应中优先级最高的动作
+	RiverCounter    int               // 全场弃牌序号
+	Result          *GameResult       // 本局结果（GameOver 后有效）
 
 	// 随机数生成器
 	Rand    *rand.Rand // 随机数生成器
@@ -40,6 +55,7 @@ type Table struct {
 	SelectionLog []int          // 选择日志
 	GameLog      *GameLogRecord // 游戏日志记录器
 	LastActor    int            // 上一个执行动作的玩家索引（用于响应阶段）
+	Profile      *ProfileScope  // 性能分析作用域，为空时不计时
 }
 
 // NewTable 创建一个新的Table实例
@@ -57,6 +73,8 @@ func NewTable() *Table {
 		SelectionLog: make([]int, 0),
 		GameLog:      NewGameLogRecord(),
 		LastActor:    -1,
+		Rule:         DefaultGameRule(),
+		Phase:        PhaseUninitialized,
 	}
 
 	// 初始化玩家
@@ -69,7 +87,29 @@ func NewTable() *Table {
 
 // NewDora 翻出新的宝牌
 func (t *Table) NewDora() {
+	if t.NActiveDora >= len(t.DoraIndicator) && len(t.DoraIndicator) > 0 {
+		return
+	}
 	t.NActiveDora++
+	if t.GameLog != nil && t.NActiveDora <= len(t.DoraIndicator) {
+		t.GameLog.AddActionLog(-1, -1, LogDoraReveal, t.DoraIndicator[t.NActiveDora-1], nil)
+	}
+}
+
+// addKanDora 杠成立后按规则立即翻开新宝牌，或留到打牌后翻开
+func (t *Table) addKanDora(action BaseAction) {
+	if action != AnKan && t.rule().KanDoraDelayed {
+		t.PendingKanDora++
+		return
+	}
+	t.NewDora()
+}
+
+// revealKanDora 翻开之前明杠、加杠留下的宝牌
+func (t *Table) revealKanDora() {
+	for ; t.PendingKanDora > 0; t.PendingKanDora-- {
+		t.NewDora()
+	}
 }
 
 // GetDora 获取所有已翻开的宝牌
@@ -123,26 +163,20 @@ func (t *Table) InitTiles() {
 	}
 }
 
-// InitRedDora3 初始化3张赤宝牌
+// InitRedDora3 初始化3张赤宝牌（与 C++ 一致，每种5的第一张：ID 16、52、88）
 func (t *Table) InitRedDora3() {
 	for color := 0; color < 3; color++ {
 		baseTile := BaseTile(_5m + BaseTile(color*9))
-		idx := t.Rand.Intn(4)
-		t.Tiles[int(baseTile)*4+idx].RedDora = true
+		t.Tiles[int(baseTile)*4].RedDora = true
 	}
 }
 
-// ShuffleTiles 洗牌
+// ShuffleTiles 洗牌（打乱牌山，Tiles 保持按 ID 排列以便导入牌山）
 func (t *Table) ShuffleTiles() {
-	tiles := make([]*Tile, NTiles)
-	copy(tiles, t.Tiles[:])
-
-	for i := NTiles - 1; i > 0; i-- {
+	for i := len(t.Yama) - 1; i > 0; i-- {
 		j := t.Rand.Intn(i + 1)
-		tiles[i], tiles[j] = tiles[j], tiles[i]
+		t.Yama[i], t.Yama[j] = t.Yama[j], t.Yama[i]
 	}
-
-	copy(t.Tiles[:], tiles)
 }
 
 // InitYama 初始化牌山
@@ -155,6 +189,7 @@ func (t *Table) InitYama() {
 }
 
 // InitDora 初始化宝牌指示牌 - 初始化5组宝牌和里宝牌
+// 从牌山末尾摸牌，开头的14张为王牌
 func (t *Table) InitDora() {
 	t.DoraIndicator = make([]*Tile, 0, 5)
 	t.UraDoraIndicator = make([]*Tile, 0, 5)
@@ -166,25 +201,26 @@ func (t *Table) InitDora() {
 
 	for _, pos := range doraPositions {
 		if len(t.Yama) > pos {
-			t.DoraIndicator = append(t.DoraIndicator, t.Yama[len(t.Yama)-pos-1])
+			t.DoraIndicator = append(t.DoraIndicator, t.Yama[pos])
 		}
 	}
 
 	for _, pos := range uraPositions {
 		if len(t.Yama) > pos {
-			t.UraDoraIndicator = append(t.UraDoraIndicator, t.Yama[len(t.Yama)-pos-1])
+			t.UraDoraIndicator = append(t.UraDoraIndicator, t.Yama[pos])
 		}
 	}
 
 	t.NActiveDora = 1 // 初始只翻1张
+	t.PendingKanDora = 0
 }
 
 // InitBeforePlaying 在开始游戏前的初始化
 func (t *Table) InitBeforePlaying() {
 	t.InitTiles()
 	t.InitRedDora3()
-	t.ShuffleTiles()
 	t.InitYama()
+	t.ShuffleTiles()
 	t.InitDora()
 }
 
@@ -214,24 +250,16 @@ func (t *Table) SetSeed(seed int64) {
 	t.Rand = rand.New(rand.NewSource(seed))
 }
 
-// DrawTenhouStyle 按照天凤风格摸牌
+// DrawTenhouStyle 按照天凤风格配牌
+// 从庄家开始每人每次摸4张共3轮，再每人摸1张，庄家的第14张在第一回合摸
 func (t *Table) DrawTenhouStyle() {
 	for round := 0; round < 3; round++ {
 		for i := 0; i < NPlayers; i++ {
-			if len(t.Yama) > 0 {
-				tile := t.Yama[len(t.Yama)-1]
-				t.Yama = t.Yama[:len(t.Yama)-1]
-				idx := (t.Oya + i) % NPlayers
-				t.Players[idx].Hand = append(t.Players[idx].Hand, tile)
-				t.YamaLog = append(t.YamaLog, tile.ID)
-			}
+			t.DrawNNormal((t.Oya+i)%NPlayers, 4)
 		}
 	}
-	if len(t.Yama) > 0 {
-		tile := t.Yama[len(t.Yama)-1]
-		t.Yama = t.Yama[:len(t.Yama)-1]
-		t.Players[t.Oya].Hand = append(t.Players[t.Oya].Hand, tile)
-		t.YamaLog = append(t.YamaLog, tile.ID)
+	for i := 0; i < NPlayers; i++ {
+		t.DrawNormalNoRecord((t.Oya + i) % NPlayers)
 	}
 }
 
@@ -241,7 +269,6 @@ func (t *Table) DrawNormal(playerIndex int) {
 		tile := t.Yama[len(t.Yama)-1]
 		t.Yama = t.Yama[:len(t.Yama)-1]
 		t.Players[playerIndex].Hand = append(t.Players[playerIndex].Hand, tile)
-		t.YamaLog = append(t.YamaLog, tile.ID)
 		if t.GameLog != nil {
 			t.GameLog.AddActionLog(playerIndex, -1, LogDrawNormal, tile, nil)
 		}
@@ -265,10 +292,15 @@ func (t *Table) DrawNNormal(playerIndex int, nTiles int) {
 }
 
 // DrawRinshan 从岭上摸牌
+// 岭上牌两张一叠，剩余杠数为偶数时摸上面一张（Yama[1]）
 func (t *Table) DrawRinshan(playerIndex int) {
 	if len(t.Yama) > 14 {
-		tile := t.Yama[0]
-		t.Yama = t.Yama[1:]
+		idx := 0
+		if t.GetRemainKanTile()%2 == 0 {
+			idx = 1
+		}
+		tile := t.Yama[idx]
+		t.Yama = append(t.Yama[:idx], t.Yama[idx+1:]...)
 		t.Players[playerIndex].Hand = append(t.Players[playerIndex].Hand, tile)
 		if t.GameLog != nil {
 			t.GameLog.AddActionLog(playerIndex, -1, LogDrawRinshan, tile, nil)
@@ -276,11 +308,6 @@ func (t *Table) DrawRinshan(playerIndex int) {
 	}
 }
 
-// NextTurn 推进到下一个回合
-func (t *Table) NextTurn(nextTurn int) {
-	t.Turn = nextTurn
-}
-
 // SetDebugMode 设置调试模式
 // mode: 0 = no debug, 1 = debug by buffer, 2 = debug by stdout
 func (t *Table) SetDebugMode(mode int) {
@@ -316,468 +343,616 @@ func (t *Table) SortPlayerHands() {
 	}
 }
 
+// Phase 牌桌流程阶段，与 C++ 的 PhaseEnum 对应
+// 响应阶段按绝对座位编号，回合玩家自己也会经过（只能选择 Pass）
+type Phase int
+
+const (
+	P1Action Phase = iota // 0-3: 回合玩家的自主行动
+	P2Action
+	P3Action
+	P4Action
+	P1Response // 4-7: 对弃牌的响应
+	P2Response
+	P3Response
+	P4Response
+	P1ChankanResponse // 8-11: 对加杠的响应（抢杠）
+	P2ChankanResponse
+	P3ChankanResponse
+	P4ChankanResponse
+	P1ChanankanResponse // 12-15: 对暗杠的响应（国士抢暗杠）
+	P2ChanankanResponse
+	P3ChanankanResponse
+	P4ChanankanResponse
+	GameOver           // 16: 本局结束
+	PhaseUninitialized // 尚未开始
+)
+
 // GetPhase 获取当前游戏阶段
 // 返回值与 C++ PhaseEnum 对应: 0-3为自动作, 4-7为响应, 8-11为抢杠响应, 12-15为抢暗杠响应, 16为游戏结束
 func (t *Table) GetPhase() int {
-	// If game over
-	if t.GetRemainTile() <= 0 {
-		return 16 // 游戏结束
-	}
+	return int(t.Phase)
+}
 
-	// If the last action was a discard, enter response phase for next players
-	if t.LastAction == Discard {
-		// response phases 4-7, map to next player (turn+1 ..)
-		next := (t.Turn + 1) % NPlayers
-		return 4 + next
-	}
+// IsGameOver 判断本局是否已结束
+func (t *Table) IsGameOver() bool {
+	return t.Phase == GameOver
+}
 
-	// If last action was a kan, some players may have chankan opportunity
-	if t.LastAction == Kan || t.LastAction == AnKan || t.LastAction == KaKan {
-		next := (t.Turn + 1) % NPlayers
-		return 8 + next
+// GetSelfActions 获取回合玩家当前可选的自主行动
+func (t *Table) GetSelfActions() []*SelfAction {
+	return t.SelfActions
+}
+
+// GetResponseActions 获取当前响应玩家可选的响应
+func (t *Table) GetResponseActions() []*ResponseAction {
+	return t.ResponseActions
+}
+
+// WhoMakeSelection 返回当前需要做出选择的玩家，本局结束时返回 -1
+func (t *Table) WhoMakeSelection() int {
+	switch {
+	case t.Phase <= P4Action:
+		return t.Turn
+	case t.Phase < GameOver:
+		return int(t.Phase-P1Response) % NPlayers
+	default:
+		return -1
 	}
+}
 
-	// Default: autonomous action phase for current player (0-3)
-	return t.Turn
+// GameStart 在配牌完成后开始本局
+func (t *Table) GameStart() {
+	for i := 0; i < NPlayers; i++ {
+		p := t.Players[i]
+		p.Wind = t.GetCurrentPlayerWind(i)
+		p.Oya = i == t.Oya
+		p.SortHand()
+		span := t.Profile.Start("UpdateAtariTiles")
+		p.UpdateAtariTiles()
+		span.End()
+	}
+	t.Turn = t.Oya
+	t.LastAction = Pass
+	t.LastActor = -1
+	t.KanDiscard = false
+	t.RiverCounter = 0
+	t.Result = nil
+	t.FromBeginning()
 }
 
-// MakeSelection 根据选择执行游戏逻辑
+// MakeSelection 当前阶段的玩家做出选择
+// 自主行动阶段从 SelfActions 中选择，响应阶段从 ResponseActions 中选择，选择无效时返回 false
 func (t *Table) MakeSelection(selection int) bool {
-	phase := t.GetPhase()
-
-	// helper: action priority map (higher is higher priority)
-	actionPriority := func(a BaseAction) int {
-		switch a {
-		case Ron:
-			return 4
-		case Kan:
-			return 3
-		case Pon:
-			return 2
-		case Chi:
-			return 1
-		default:
-			return 0
+	switch {
+	case t.Phase <= P4Action:
+		if selection < 0 || selection >= len(t.SelfActions) {
+			return false
 		}
-	}
-
-	// 自动作阶段（0-3）
-	if phase >= 0 && phase <= 3 {
-		playerIdx := t.Turn
-		player := t.Players[playerIdx]
-		if player == nil {
+		t.SelectionLog = append(t.SelectionLog, selection)
+		t.SelectedAction = t.SelfActions[selection]
+		t.SelfActions = nil
+		t.handleSelfAction()
+		return true
+	case t.Phase < GameOver:
+		if selection < 0 || selection >= len(t.ResponseActions) {
 			return false
 		}
+		t.SelectionLog = append(t.SelectionLog, selection)
+		t.handleResponse(t.ResponseActions[selection])
+		return true
+	default:
+		return false
+	}
+}
 
-		// 构造可能的自主行动（与 PaipuReplayer.GetSelfActions 保持一致）
-		actions := make([]*SelfAction, 0)
-		actions = append(actions, player.GetDiscard(false)...)
-		actions = append(actions, player.GetAnkan()...)
-		actions = append(actions, player.GetKakan()...)
+// FromBeginning 回合开始
+// 处理流局判定、摸牌、生成行动列表等
+func (t *Table) FromBeginning() {
+	// 途中流局：四风连打、四家立直、四杠散了
+	rule := t.rule()
+	if rule.Suufonrenda && t.isSuufonrenda() {
+		t.gameOver(GenerateResultSuufonrenda())
+		return
+	}
+	if rule.SuuchaRiichi && t.isSuuchaRiichi() {
+		t.gameOver(GenerateResultSuuchaRiichi())
+		return
+	}
+	if rule.Suukaikan && t.isSuukaikan() {
+		t.gameOver(GenerateResultSuukaikan())
+		return
+	}
 
-		if player.IsTenpai() {
-			actions = append(actions, player.GetTsumo(t)...)
-		}
+	// 检查是否没有牌了（荒牌流局）
+	if t.GetRemainTile() == 0 {
+		t.gameOver(t.notileResult())
+		return
+	}
+
+	// 整理所有玩家的手牌，摸到的牌始终在手牌末尾
+	t.SortPlayerHands()
+
+	// 杠后从岭上摸牌，吃碰后不摸牌
+	switch t.LastAction {
+	case Kan, AnKan, KaKan:
+		t.DrawRinshan(t.Turn)
+	case Chi, Pon:
+	default:
+		t.DrawNormal(t.Turn)
+	}
 
-		if player.IsMenzen() && !player.IsRiichi() {
-			actions = append(actions, player.GetRiichi()...)
+	t.SelfActions = t.generateSelfActions()
+	t.Phase = Phase(t.Turn)
+}
+
+// generateSelfActions 生成回合玩家的自主行动
+func (t *Table) generateSelfActions() []*SelfAction {
+	defer t.Profile.Start("generateSelfActions").End()
+	p := t.Players[t.Turn]
+	actions := make([]*SelfAction, 0)
+	if p.IsRiichi() {
+		// 立直后只能摸切、自摸或不改变听牌的暗杠
+		if t.GetRemainKanTile() > 0 {
+			actions = append(actions, p.RiichiGetAnkan()...)
+		}
+		actions = append(actions, p.RiichiGetDiscard()...)
+		actions = append(actions, p.GetTsumo(t)...)
+	} else {
+		afterChipon := t.LastAction == Chi || t.LastAction == Pon
+		if t.rule().Kyushukyuhai {
+			actions = append(actions, p.GetKyushukyuhai()...)
+		}
+		actions = append(actions, p.GetDiscard(afterChipon)...)
+		if !afterChipon && t.GetRemainKanTile() > 0 {
+			actions = append(actions, p.GetAnkan()...)
+			actions = append(actions, p.GetKakan()...)
+		}
+		actions = append(actions, p.GetTsumo(t)...)
+		actions = append(actions, p.GetRiichiWithTable(t)...)
+	}
+	SortSelfActions(actions)
+	return actions
+}
+
+// generateResponseActions 生成某个玩家对当前打出（或杠出）的牌的响应
+func (t *Table) generateResponseActions(playerIdx int) []*ResponseAction {
+	defer t.Profile.Start("generateResponseActions").End()
+	actions := []*ResponseAction{{Action{Action: Pass}}}
+	if playerIdx == t.Turn {
+		return actions
+	}
+	p := t.Players[playerIdx]
+	tile := t.SelectedTile
+	switch {
+	case t.Phase <= P4Response:
+		actions = append(actions, p.GetRon(t, tile)...)
+		if !p.IsRiichi() && t.GetRemainTile() != 0 {
+			actions = append(actions, p.GetPon(tile)...)
+			if t.GetRemainKanTile() > 0 {
+				actions = append(actions, p.GetKan(tile)...)
+			}
+			if playerIdx == (t.Turn+1)%NPlayers {
+				actions = append(actions, p.GetChi(tile)...)
+			}
 		}
+	case t.Phase <= P4ChankanResponse:
+		actions = append(actions, p.GetChankan(t, tile)...)
+	default:
+		actions = append(actions, p.GetChanAnkan(tile)...)
+	}
+	SortResponseActions(actions)
+	return actions
+}
 
-		if player.FirstRound {
-			actions = append(actions, player.GetKyushukyuhai()...)
+// handleSelfAction 执行回合玩家选定的自主行动
+func (t *Table) handleSelfAction() {
+	t.LastActor = t.Turn
+	switch t.SelectedAction.GetAction() {
+	case Discard:
+		t.discardImpl(false)
+	case Riichi:
+		t.discardImpl(true)
+	case Tsumo:
+		t.tsumoImpl()
+	case Kyushukyuhai:
+		if t.GameLog != nil {
+			t.GameLog.AddActionLog(t.Turn, -1, LogKyushukyuhai, nil, nil)
 		}
+		t.gameOver(GenerateResultKyushukyuhai(t.Turn))
+	case AnKan:
+		t.kanImpl(AnKan)
+	case KaKan:
+		t.kanImpl(KaKan)
+	}
+}
 
-		if selection < 0 || selection >= len(actions) {
-			return false
+// discardImpl 打牌（或立直宣言打牌），进入响应阶段
+func (t *Table) discardImpl(riichi bool) {
+	p := t.Players[t.Turn]
+	tile := t.SelectedAction.CorrespondTiles[0]
+	p.Ippatsu = false
+
+	t.KanDiscard = t.LastAction == Kan || t.LastAction == AnKan || t.LastAction == KaKan
+
+	afterChipon := t.LastAction == Chi || t.LastAction == Pon
+	tsumogiri := !afterChipon && len(p.Hand) > 0 && p.Hand[len(p.Hand)-1] == tile
+	p.ExecuteDiscard(tile, &t.RiverCounter, riichi, !tsumogiri)
+
+	if t.GameLog != nil {
+		var action LogAction
+		switch {
+		case riichi && tsumogiri:
+			action = LogRiichiDiscardFromTsumo
+		case riichi:
+			action = LogRiichiDiscardFromHand
+		case tsumogiri:
+			action = LogDiscardFromTsumo
+		default:
+			action = LogDiscardFromHand
 		}
+		t.GameLog.AddActionLog(t.Turn, -1, action, tile, nil)
+	}
+	// 明杠、加杠的新宝牌在打牌后翻开，早于其他家的响应
+	t.revealKanDora()
 
-		act := actions[selection]
+	t.SelectedTile = tile
+	t.startResponses(P1Response)
+}
 
-		// record actor
-		t.LastActor = playerIdx
+// kanImpl 暗杠或加杠宣言，先让其他玩家决定是否抢杠，杠在响应结束后执行
+func (t *Table) kanImpl(action BaseAction) {
+	p := t.Players[t.Turn]
+	tiles := t.SelectedAction.CorrespondTiles
 
-		switch act.GetAction() {
-		case Discard:
-			tile := act.GetCorrespondTiles()[0]
-			// 添加到河中并从手中移除
-			rt := RiverTile{Tile: tile, Number: 0, Riichi: player.IsRiichi(), Remain: true, FromHand: true}
-			player.River.PushBack(rt)
-			player.RemoveFromHand(tile)
+	// 连续开杠时，上一个明杠、加杠的宝牌在此时翻开
+	t.revealKanDora()
+	p.FirstRound = false
 
-			// 更新振听状态
-			if player.IsRiichi() && player.IsTenpai() {
-				if BaseTileInSlice(tile.Tile, player.AtariTiles) {
-					player.Minogashi()
-				}
-			}
+	if action == AnKan {
+		if t.GameLog != nil {
+			t.GameLog.AddActionLog(t.Turn, -1, LogAnKan, tiles[0], tiles)
+		}
+		t.SelectedTile = tiles[0]
+		t.startResponses(P1ChanankanResponse)
+		return
+	}
 
-			// 记录弃牌日志
-			if t.GameLog != nil {
-				actionLog := LogDiscardFromHand
-				if player.IsRiichi() {
-					actionLog = LogRiichiDiscardFromHand
-				}
-				t.GameLog.AddActionLog(playerIdx, -1, actionLog, tile, nil)
-			}
+	if t.GameLog != nil {
+		t.GameLog.AddActionLog(t.Turn, -1, LogKaKan, tiles[0], tiles)
+	}
+	t.SelectedTile = tiles[0]
+	t.startResponses(P1ChankanResponse)
+}
 
-			t.LastAction = Discard
-			// 不立即推进回合，等待响应阶段处理（由上层控制器或接下来的 MakeSelection 响应阶段触发）
-			return true
+// tsumoImpl 自摸和牌
+func (t *Table) tsumoImpl() {
+	p := t.Players[t.Turn]
+	winTile := p.Hand[len(p.Hand)-1]
+	// 岭上开花时，杠的新宝牌先翻开再计分
+	t.revealKanDora()
+	tiles := ConvertTilesToBaseTiles(p.Hand)
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+	counter := &ScoreCounter{}
+	span := t.Profile.Start("CalculateScore")
+	score := counter.CalculateScore(t, p, tiles, p.CallGroups, winTile.Tile, IsSevenPairPattern(tiles))
+	span.End()
+	if score == nil {
+		// 生成自摸选项时已确认有役，这里不应出现
+		return
+	}
 
-		case AnKan:
-			// 暗杠（使用第一个对应牌的基牌）
-			tiles := act.GetCorrespondTiles()
-			if len(tiles) == 0 {
-				return false
-			}
-			base := tiles[0].Tile
-			player.ExecuteAnkan(base)
-			t.NewDora()
-			t.DrawRinshan(playerIdx)
-			if t.GameLog != nil {
-				t.GameLog.AddActionLog(playerIdx, -1, LogAnKan, nil, nil)
-			}
-			t.LastAction = AnKan
-			return true
+	result := GenerateResultTsumo(t.Turn, t.Oya, score)
+	result.AwardHonba(t.Honba)
+	result.AwardKyoutaku(t.Turn, t.Kyoutaku)
+	t.Kyoutaku = 0
+	if t.GameLog != nil {
+		t.GameLog.AddActionLog(t.Turn, -1, LogTsumo, winTile, nil)
+	}
+	t.gameOver(result)
+}
 
-		case KaKan:
-			tiles := act.GetCorrespondTiles()
-			if len(tiles) == 0 {
-				return false
-			}
-			tile := tiles[0]
-			player.ExecuteKakan(tile)
-			t.NewDora()
-			t.DrawRinshan(playerIdx)
-			if t.GameLog != nil {
-				t.GameLog.AddActionLog(playerIdx, -1, LogKan, nil, nil)
-			}
-			t.LastAction = KaKan
-			return true
+// startResponses 从玩家0开始依次收集响应
+func (t *Table) startResponses(phase Phase) {
+	t.Responses = make([]*ResponseAction, 0, NPlayers)
+	t.FinalAction = Pass
+	t.Phase = phase
+	t.ResponseActions = t.generateResponseActions(0)
+}
 
-		case Tsumo:
-			if player.IsTenpai() {
-				counter := &ScoreCounter{}
-				baseTiles := ConvertTilesToBaseTiles(player.Hand)
-				isSevenPair := IsSevenPairPattern(baseTiles)
-				result := counter.CalculateScore(t, player, baseTiles, player.CallGroups, baseTiles[len(baseTiles)-1], isSevenPair)
-				if result != nil {
-					gameResult := GenerateResultTsumo(playerIdx, result)
-					// 应用分数变化
-					var playersArr [4]*Player
-					for i := 0; i < 4; i++ {
-						playersArr[i] = t.Players[i]
-					}
-					gameResult.ApplyScoreChanges(playersArr)
-
-					if t.GameLog != nil {
-						var winTile *Tile = nil
-						if len(player.Hand) > 0 {
-							winTile = player.Hand[len(player.Hand)-1]
-						}
-						t.GameLog.AddActionLog(playerIdx, -1, LogTsumo, winTile, nil)
-						scores := [NPlayers]int{}
-						for i := 0; i < NPlayers; i++ {
-							scores[i] = t.Players[i].Score
-						}
-						t.GameLog.AddScoreLog(scores)
-					}
-				}
-			}
-			return true
+// handleResponse 记录当前响应玩家的选择，四家都响应后执行优先级最高的动作
+func (t *Table) handleResponse(chosen *ResponseAction) {
+	playerIdx := t.WhoMakeSelection()
 
-		case Riichi:
-			player.Riichi = true
-			player.Ippatsu = true
-			if t.GameLog != nil {
-				t.GameLog.AddActionLog(playerIdx, -1, LogRiichiSuccess, nil, nil)
-			}
-			return true
+	// 见逃和牌进入振听
+	if t.missedWin(playerIdx, chosen) {
+		t.Players[playerIdx].Minogashi(t.SelectedTile)
+	}
 
-		case Kyushukyuhai:
-			gameResult := GenerateResultKyushukyuhai(playerIdx)
-			var playersArr [4]*Player
-			for i := 0; i < 4; i++ {
-				playersArr[i] = t.Players[i]
-			}
-			gameResult.ApplyScoreChanges(playersArr)
-			if t.GameLog != nil {
-				t.GameLog.AddActionLog(playerIdx, -1, LogKyushukyuhai, nil, nil)
-			}
+	t.Responses = append(t.Responses, chosen)
+	if chosen.GetAction() > t.FinalAction {
+		t.FinalAction = chosen.GetAction()
+	}
+
+	if playerIdx < NPlayers-1 {
+		t.Phase++
+		t.ResponseActions = t.generateResponseActions(playerIdx + 1)
+		return
+	}
+	t.ResponseActions = nil
+
+	switch {
+	case t.Phase <= P4Response:
+		t.finishDiscardResponses()
+	case t.Phase <= P4ChankanResponse:
+		t.finishKanResponses(KaKan)
+	default:
+		t.finishKanResponses(AnKan)
+	}
+}
+
+// missedWin 判断响应玩家是否见逃了和牌
+// 能荣和（包括抢杠）却没有选择，或打出的牌是和牌、只因无役而不能荣和，都算见逃
+func (t *Table) missedWin(playerIdx int, chosen *ResponseAction) bool {
+	switch chosen.GetAction() {
+	case Ron, ChanKan, ChanAnKan:
+		return false
+	}
+	for _, a := range t.ResponseActions {
+		switch a.GetAction() {
+		case Ron, ChanKan, ChanAnKan:
 			return true
-		default:
-			return false
 		}
 	}
+	if playerIdx == t.Turn || t.Phase > P4Response {
+		return false
+	}
+	return IsIn(t.Players[playerIdx].AtariTiles, t.SelectedTile.Tile)
+}
 
-	// 响应阶段处理: 处理他人弃牌后的响应（吃/碰/杠/荣和/抢杠）
-	// 处理范围：4-7 为常规响应（对弃牌），8-11 为抢杠(chankan)，12-15 为抢暗杠(chan ankan)
-	if phase >= 4 && phase <= 15 {
-		if t.LastActor < 0 || t.LastActor >= NPlayers {
-			return false
+// finishDiscardResponses 执行对弃牌的最终响应
+func (t *Table) finishDiscardResponses() {
+	riichi := t.SelectedAction.GetAction() == Riichi
+	switch t.FinalAction {
+	case Pass:
+		if riichi {
+			t.riichiSuccess(true)
+		}
+		t.Players[t.Turn].FirstRound = false
+		t.LastAction = t.SelectedAction.GetAction()
+		t.nextTurn((t.Turn + 1) % NPlayers)
+		t.FromBeginning()
+
+	case Chi, Pon, Kan:
+		// 被鸣牌时立直宣言成立，但没有一发
+		if riichi {
+			t.riichiSuccess(false)
+		}
+		caller := -1
+		for d := 1; d < NPlayers; d++ {
+			idx := (t.Turn + d) % NPlayers
+			if t.Responses[idx].GetAction() == t.FinalAction {
+				caller = idx
+				break
+			}
 		}
+		resp := t.Responses[caller]
+		t.Players[t.Turn].River.SetNotRemain()
+		t.Players[caller].ExecuteNakiWithTiles(resp.CorrespondTiles, t.SelectedTile, (t.Turn-caller+NPlayers)%NPlayers)
 
-		// 获取被弃的牌（LastActor 最后丢出的牌）
-		la := t.Players[t.LastActor]
-		if la == nil || la.River.Size() == 0 {
-			return false
+		if t.GameLog != nil {
+			var action LogAction
+			switch t.FinalAction {
+			case Chi:
+				action = LogChi
+			case Pon:
+				action = LogPon
+			default:
+				action = LogKan
+			}
+			t.GameLog.AddActionLog(caller, t.Turn, action, t.SelectedTile, resp.CorrespondTiles)
 		}
-		discarded := la.River.River[la.River.Size()-1].Tile
-
-		// 收集每个玩家可用的响应，并选出优先级最高的动作
-		type resp struct {
-			player int
-			action BaseAction
+		if t.FinalAction == Kan {
+			t.addKanDora(Kan)
 		}
-		responses := make([]resp, 0)
 
-		// 按座位顺序从弃牌者下一家开始收集
-		for d := 1; d <= 3; d++ {
-			idx := (t.LastActor + d) % NPlayers
-			p := t.Players[idx]
-			if p == nil {
-				continue
-			}
+		// 鸣牌使所有人的第一巡与一发失效
+		t.clearFirstRoundAndIppatsu()
+		t.LastAction = t.FinalAction
+		t.nextTurn(caller)
+		t.FromBeginning()
 
-			// 生成响应动作列表（针对弃牌）
-			candidateActions := make([]BaseAction, 0)
-			// 荣和
-			ron := p.GetRon(t, discarded)
-			if ron != nil && len(ron) > 0 {
-				candidateActions = append(candidateActions, Ron)
-			}
-			// 抢杠/抢暗杠相关会在chankan/chanankan阶段处理（这里忽略）
-			// 杠/碰/吃
-			kan := p.GetKan(discarded)
-			if kan != nil && len(kan) > 0 {
-				candidateActions = append(candidateActions, Kan)
-			}
-			pon := p.GetPon(discarded)
-			if pon != nil && len(pon) > 0 {
-				candidateActions = append(candidateActions, Pon)
-			}
-			chi := p.GetChi(discarded)
-			if chi != nil && len(chi) > 0 {
-				candidateActions = append(candidateActions, Chi)
-			}
+	default:
+		t.ronImpl()
+	}
+}
 
-			// 选择该玩家优先级最高的响应
-			best := Pass
-			bestPri := 0
-			for _, a := range candidateActions {
-				pri := actionPriority(a)
-				if pri > bestPri {
-					best = a
-					bestPri = pri
-				}
-			}
-			responses = append(responses, resp{player: idx, action: best})
-		}
+// finishKanResponses 无人抢杠时执行杠
+func (t *Table) finishKanResponses(action BaseAction) {
+	if t.FinalAction == Pass {
+		p := t.Players[t.Turn]
+		if action == AnKan {
+			p.ExecuteAnkan(t.SelectedTile.Tile)
+		} else {
+			p.ExecuteKakan(t.SelectedTile)
+		}
+		t.addKanDora(action)
+		t.clearFirstRoundAndIppatsu()
+		t.LastAction = action
+		t.nextTurn(t.Turn)
+		t.FromBeginning()
+		return
+	}
+	t.ronImpl()
+}
 
-		// 计算最终最高优先级
-		finalAction := Pass
-		finalPri := 0
-		for _, r := range responses {
-			pri := actionPriority(r.action)
-			if pri > finalPri {
-				finalPri = pri
-				finalAction = r.action
-			}
+// ronImpl 荣和（含抢杠），从放铳者的下家开始依次结算，本场棒与供托归第一个和牌者
+func (t *Table) ronImpl() {
+	nRon := 0
+	for _, r := range t.Responses {
+		if r.GetAction() == Ron || r.GetAction() == ChanKan || r.GetAction() == ChanAnKan {
+			nRon++
 		}
+	}
+	if nRon == 3 && t.rule().Sanchahou {
+		t.gameOver(GenerateResultSanchahou())
+		return
+	}
 
-		if finalAction == Pass {
-			// 所有人都放弃 -> 将弃牌标记为不可取，并推进到下一家摸牌
-			la.River.SetNotRemain()
-			// 清除 last action 后推进回合
-			t.LastAction = Pass
-			t.NextTurn((t.LastActor + 1) % NPlayers)
-			return true
+	result := NewGameResult()
+	for d := 1; d < NPlayers; d++ {
+		idx := (t.Turn + d) % NPlayers
+		switch t.Responses[idx].GetAction() {
+		case Ron, ChanKan, ChanAnKan:
+		default:
+			continue
 		}
-
-		// 在有最终动作的情况下，找到优先的玩家（同优先级则靠近弃牌者的玩家优先）
-		winner := -1
-		for d := 1; d <= 3; d++ {
-			idx := (t.LastActor + d) % NPlayers
-			for _, r := range responses {
-				if r.player == idx && r.action == finalAction {
-					winner = idx
-					break
-				}
-			}
-			if winner >= 0 {
-				break
-			}
+		span := t.Profile.Start("CalculateScore")
+		score := t.Players[idx].ronScore(t, t.SelectedTile)
+		span.End()
+		if score == nil {
+			continue
 		}
-		if winner < 0 {
-			return false
+		result.AddRonAgari(idx, t.Turn, score)
+		if t.GameLog != nil {
+			t.GameLog.AddActionLog(idx, t.Turn, LogRon, t.SelectedTile, nil)
 		}
+	}
+	if len(result.Winners) > 0 {
+		result.AwardHonba(t.Honba)
+		result.AwardKyoutaku(result.Winners[0], t.Kyoutaku)
+		t.Kyoutaku = 0
+	}
+	t.gameOver(result)
+}
 
-		// 执行最终响应
-		switch finalAction {
-		case Ron:
-			// 胡牌：winner 胡 LastActor 的弃牌
-			winnerP := t.Players[winner]
-			if winnerP == nil {
-				return false
-			}
-			// 构造正确的牌组用于计分
-			baseTiles := ConvertTilesToBaseTiles(winnerP.Hand)
-			// 将弃牌作为最后一张
-			baseTiles = append(baseTiles, discarded.Tile)
-			isSeven := IsSevenPairPattern(baseTiles)
-			counter := &ScoreCounter{}
-			result := counter.CalculateScore(t, winnerP, baseTiles, winnerP.CallGroups, discarded.Tile, isSeven)
-			var gameResult *GameResult
-			if result != nil {
-				gameResult = GenerateResultRon(winner, t.LastActor, result)
-				var playersArr [4]*Player
-				for i := 0; i < 4; i++ {
-					playersArr[i] = t.Players[i]
-				}
-				gameResult.ApplyScoreChanges(playersArr)
-			} else {
-				// 没有役（异常情况），返回 false
-				return false
-			}
-			// 记录日志
-			if t.GameLog != nil {
-				t.GameLog.AddActionLog(winner, t.LastActor, LogRon, discarded, nil)
-				scores := [NPlayers]int{}
-				for i := 0; i < NPlayers; i++ {
-					scores[i] = t.Players[i].Score
-				}
-				t.GameLog.AddScoreLog(scores)
-			}
-			t.LastAction = Ron
-			return true
+// riichiSuccess 立直宣言牌通过后，立直成立并支付供托
+func (t *Table) riichiSuccess(ippatsu bool) {
+	p := t.Players[t.Turn]
+	if p.FirstRound {
+		p.DoubleRiichi = true
+	}
+	p.Riichi = true
+	p.Ippatsu = ippatsu
+	p.Score -= 1000
+	t.Kyoutaku++
+	if t.GameLog != nil {
+		t.GameLog.AddActionLog(t.Turn, -1, LogRiichiSuccess, nil, nil)
+	}
+}
 
-		case Chi, Pon, Kan:
-			// 执行鸣牌（吃/碰/大明杠）
-			caller := t.Players[winner]
-			if caller == nil {
-				return false
-			}
-			// 从被弃牌家的河中删除该牌（标记为已取走）
-			la.River.SetNotRemain()
-			// 执行鸣牌
-			caller.ExecuteNaki(discarded, finalAction)
-			// 记录日志
-			if t.GameLog != nil {
-				var logAction LogAction
-				switch finalAction {
-				case Chi:
-					logAction = LogChi
-				case Pon:
-					logAction = LogPon
-				case Kan:
-					logAction = LogKan
-				}
-				t.GameLog.AddActionLog(winner, t.LastActor, logAction, discarded, nil)
-			}
-			// 消除第一巡与一发
-			for i := 0; i < NPlayers; i++ {
-				if t.Players[i] != nil {
-					t.Players[i].FirstRound = false
-					t.Players[i].Ippatsu = false
-				}
-			}
-			// 设置当前回合为鸣牌者
-			t.Turn = winner
-			t.LastAction = finalAction
-			return true
-		}
+// clearFirstRoundAndIppatsu 鸣牌或开杠后清除所有玩家的第一巡与一发
+func (t *Table) clearFirstRoundAndIppatsu() {
+	for i := 0; i < NPlayers; i++ {
+		t.Players[i].FirstRound = false
+		t.Players[i].Ippatsu = false
 	}
+}
 
-	return false
+// NextTurn 直接设置回合玩家，不改变阶段与听牌、振听（流程中由 nextTurn 切换回合）
+func (t *Table) NextTurn(nextTurn int) {
+	t.Turn = nextTurn
 }
 
-// FromBeginning 游戏主循环的开始
-// 处理流局判定、摸牌、生成行动列表等
-func (t *Table) FromBeginning() {
-	// 检查游戏是否已结束
-	// TODO: 添加阶段检查
+// nextTurn 更新上一回合玩家的听牌与振听，并切换到下一个回合玩家
+func (t *Table) nextTurn(next int) {
+	p := t.Players[t.Turn]
+	if t.SelectedAction != nil {
+		span := t.Profile.Start("UpdateAtariTiles")
+		switch t.SelectedAction.GetAction() {
+		case Discard, Riichi:
+			p.UpdateAtariTiles()
+			p.UpdateFuritenRiver()
+		case AnKan, KaKan:
+			p.UpdateAtariTiles()
+			p.RemoveAtariTiles(t.SelectedTile.Tile)
+			p.UpdateFuritenRiver()
+		}
+		span.End()
+	}
+	t.Turn = next
+	t.Phase = Phase(next)
+	t.Players[next].ClearFuritenRound()
+}
 
-	// 检查四风连打（四个玩家都弃了相同的字牌）
-	if t.isAbaortedFourWind() {
-		// 游戏结束
-		return
+// notileResult 荒牌流局：有流局满贯时按流局满贯结算，否则支付不听罚符
+func (t *Table) notileResult() *GameResult {
+	var tenpai, nagashi [NPlayers]bool
+	hasNagashi := false
+	for i := 0; i < NPlayers; i++ {
+		tenpai[i] = t.Players[i].IsTenpai()
+		nagashi[i] = isNagashiMangan(&t.Players[i].River)
+		hasNagashi = hasNagashi || nagashi[i]
 	}
-
-	// 检查四立直
-	if t.isFourRiichi() {
-		// 游戏结束
-		return
+	if hasNagashi {
+		return GenerateResultNagashiMangan(nagashi, t.Oya)
 	}
+	return GenerateResultNotile(tenpai)
+}
 
-	// 检查四杠散了
-	if t.isFourKanAborted() {
-		// 游戏结束
-		return
+// isNagashiMangan 河中全是幺九牌且没有被鸣走
+func isNagashiMangan(river *River) bool {
+	if len(river.River) == 0 {
+		return false
 	}
-
-	// 检查是否没有牌了（流局）
-	if t.GetRemainTile() == 0 {
-		// 游戏结束
-		return
+	for _, rt := range river.River {
+		if !IsYaochuhai(rt.Tile.Tile) || !rt.Remain {
+			return false
+		}
 	}
+	return true
+}
 
-	// 整理所有玩家的手牌
-	t.SortPlayerHands()
-
-	// 根据上一个行动决定是否摸牌
-	if t.LastAction == Kan || t.LastAction == AnKan || t.LastAction == KaKan {
-		// 杠后从岭上摸牌
-		t.DrawRinshan(t.Turn)
-	} else if t.LastAction != Chi && t.LastAction != Pon {
-		// 吃碰后不摸牌，其他时候摸牌
-		t.DrawNormal(t.Turn)
+// rule 返回当前牌桌的规则
+func (t *Table) rule() *GameRule {
+	if t.Rule == nil {
+		return DefaultGameRule()
 	}
+	return t.Rule
+}
 
-	// 更新听牌
-	if t.Players[t.Turn] != nil {
-		t.Players[t.Turn].UpdateAtariTiles()
-	}
-
-	// 生成本回合的可能行动列表（基础实现，供外部调用者查询）
-	// 包括弃牌、自摸、暗杠、加杠、立直、九种九牌
-	// 具体选择由调用方（如 PaipuReplayer 或 UI）执行 MakeSelection
-	// 这里只做简单的可行性检查并记录（SelectionLog用于调试）
-	player := t.Players[t.Turn]
-	if player != nil {
-		// 清理之前的选择日志
-		t.SelectionLog = t.SelectionLog[:0]
-		// 如果有暗杠选项，则记下
-		ankan := player.GetAnkan()
-		if len(ankan) > 0 {
-			t.SelectionLog = append(t.SelectionLog, int(AnKan))
-		}
-		// 加杠
-		kakan := player.GetKakan()
-		if len(kakan) > 0 {
-			t.SelectionLog = append(t.SelectionLog, int(KaKan))
-		}
-		// 自摸
-		if player.IsTenpai() {
-			t.SelectionLog = append(t.SelectionLog, int(Tsumo))
-		}
-		// 立直
-		if player.IsMenzen() && !player.IsRiichi() {
-			t.SelectionLog = append(t.SelectionLog, int(Riichi))
-		}
-		// 九种九牌
-		if player.FirstRound {
-			ky := player.GetKyushukyuhai()
-			if len(ky) > 0 {
-				t.SelectionLog = append(t.SelectionLog, int(Kyushukyuhai))
+// gameOver 结束本局，结算点数并记录下一局的连庄、本场与供托
+func (t *Table) gameOver(result *GameResult) {
+	switch result.Type {
+	case RonAgari, TsumoAgari:
+		result.Renchan = false
+		for _, w := range result.Winners {
+			if w == t.Oya {
+				result.Renchan = true
 			}
 		}
+		result.Honba = 0
+		if result.Renchan {
+			result.Honba = t.Honba + 1
+		}
+	case RyukyokuNotile, NagashiMangan:
+		result.Renchan = t.Players[t.Oya].IsTenpai()
+		result.Honba = t.Honba + 1
+	default:
+		// 途中流局一律连庄
+		result.Renchan = true
+		result.Honba = t.Honba + 1
+	}
+	result.Kyoutaku = t.Kyoutaku
+
+	result.ApplyScoreChanges(t.Players)
+	if t.GameLog != nil {
+		scores := [NPlayers]int{}
+		for i := 0; i < NPlayers; i++ {
+			scores[i] = t.Players[i].Score
+		}
+		t.GameLog.AddScoreLog(scores)
 	}
+	t.Result = result
+	t.SelfActions = nil
+	t.ResponseActions = nil
+	t.Phase = GameOver
 }
 
 // 辅助方法
 
-// isAbaortedFourWind 判断是否为四风连打流局
-func (t *Table) isAbaortedFourWind() bool {
+// isSuufonrenda 判断是否为四风连打（第一巡四家打出同一种风牌，且无人鸣牌）
+func (t *Table) isSuufonrenda() bool {
 	if len(t.Players[0].River.River) == 1 &&
 		len(t.Players[1].River.River) == 1 &&
 		len(t.Players[2].River.River) == 1 &&
@@ -799,8 +974,8 @@ func (t *Table) isAbaortedFourWind() bool {
 	return false
 }
 
-// isFourRiichi 判断是否为四立直
-func (t *Table) isFourRiichi() bool {
+// isSuuchaRiichi 判断是否为四家立直
+func (t *Table) isSuuchaRiichi() bool {
 	richiCount := 0
 	for i := 0; i < NPlayers; i++ {
 		if t.Players[i].IsRiichi() {
@@ -810,8 +985,8 @@ func (t *Table) isFourRiichi() bool {
 	return richiCount == NPlayers
 }
 
-// isFourKanAborted 判断是否为四杠散了
-func (t *Table) isFourKanAborted() bool {
+// isSuukaikan 判断是否为四杠散了（岭上牌已摸完，且杠出自两家以上）
+func (t *Table) isSuukaikan() bool {
 	if t.GetRemainKanTile() != 0 {
 		return false
 	}
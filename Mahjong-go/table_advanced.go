@@ -21,6 +21,13 @@ func (t *Table) GameInitWithConfig(config GameConfig) {
 		t.GameWind = East
 	}
 
+	// 设置规则
+	if config.Rule != nil {
+		t.Rule = config.Rule
+	} else {
+		t.Rule = DefaultGameRule()
+	}
+
 	// 设置供托与本场
 	if config.Kyoutaku >= 0 {
 		t.Kyoutaku = config.Kyoutaku
@@ -33,27 +40,30 @@ func (t *Table) GameInitWithConfig(config GameConfig) {
 		t.Honba = 0
 	}
 
+	// 使用种子（如果有）在生成赤宝与洗牌前设置
+	if config.HasSeed {
+		t.SetSeed(config.Seed)
+	}
+
 	// 初始化牌/赤宝
 	t.InitTiles()
 	t.InitRedDora3()
 
-	// 如果提供了牌山日志则导入，否则随机化（可能使用种子）
+	// 如果提供了牌山日志则导入，否则随机化
 	if len(config.YamaLog) == NTiles {
 		t.ImportYama(config.YamaLog)
 	} else if len(config.YamaLog) == 0 {
-		// 使用种子（如果有）在洗牌前设置
-		if config.HasSeed {
-			t.SetSeed(config.Seed)
-		}
 		t.InitYama()
 		t.ShuffleTiles()
 	} else {
 		panic("Yama size is not 136")
 	}
 
-	// 记录牌山日志（可能为空）
-	t.YamaLog = make([]int, len(config.YamaLog))
-	copy(t.YamaLog, config.YamaLog)
+	// 记录牌山日志，便于重放
+	t.YamaLog = make([]int, 0, NTiles)
+	for _, tile := range t.Yama {
+		t.YamaLog = append(t.YamaLog, tile.ID)
+	}
 
 	// 初始化宝牌并发牌
 	t.InitDora()
@@ -72,8 +82,8 @@ func (t *Table) GameInitWithConfig(config GameConfig) {
 		panic("init_scores size is not 4.")
 	}
 
-	// 最后准备开始游戏
-	t.InitBeforePlaying()
+	// 开始本局：庄家摸第14张并生成自主行动
+	t.GameStart()
 }
 
 // GameConfig 游戏配置结构
@@ -87,6 +97,7 @@ type GameConfig struct {
 	GameWind   Wind
 	Oya        int
 	Rules      string
+	Rule       *GameRule // 可选规则，为空时使用默认规则
 }
 
 // GameInitWithMetadata 使用元数据初始化游戏
@@ -130,11 +141,7 @@ func (t *Table) GameInitForReplay(yamaLog []int, initScores []int, oya int, honb
 		copy(t.YamaLog, yamaLog)
 	}
 
-	// 初始化宝牌指示并按天凤风格发牌（使用已导入的 Yama）
-	t.InitDora()
-	t.DrawTenhouStyle()
-
-	// 设置庄家/本场/供托
+	// 设置庄家/本场/供托（配牌从庄家开始，需先设置庄家）
 	if oya >= 0 && oya < NPlayers {
 		t.Oya = oya
 	} else {
@@ -151,6 +158,10 @@ func (t *Table) GameInitForReplay(yamaLog []int, initScores []int, oya int, honb
 		t.Kyoutaku = 0
 	}
 
+	// 初始化宝牌指示并按天凤风格发牌（使用已导入的 Yama）
+	t.InitDora()
+	t.DrawTenhouStyle()
+
 	// 初始化分数
 	if len(initScores) == NPlayers {
 		for i := 0; i < NPlayers; i++ {
@@ -177,6 +188,7 @@ func (t *Table) GameInitForReplay(yamaLog []int, initScores []int, oya int, honb
 	} else {
 		t.GameLog.Clear()
 	}
+	t.GameStart()
 }
 
 // GameMetadata 游戏元数据结构
@@ -264,17 +276,19 @@ func (t *Table) RestoreGameState(state GameState) {
 // CheckGameEnd 检查游戏是否结束
 func (t *Table) CheckGameEnd() bool {
 	// 与 FromBeginning 中的结束判定保持一致：
-	// - 四风连打
-	// - 四立直
-	// - 四杠散了
+	// - 启用的途中流局（四风连打、四家立直、四杠散了）
 	// - 牌库耗尽（流局）
-	if t.isAbaortedFourWind() {
+	if t.IsGameOver() {
 		return true
 	}
-	if t.isFourRiichi() {
+	rule := t.rule()
+	if rule.Suufonrenda && t.isSuufonrenda() {
 		return true
 	}
-	if t.isFourKanAborted() {
+	if rule.SuuchaRiichi && t.isSuuchaRiichi() {
+		return true
+	}
+	if rule.Suukaikan && t.isSuukaikan() {
 		return true
 	}
 	if t.GetRemainTile() == 0 {
@@ -322,35 +336,31 @@ type PlayerRanking struct {
 
 // CalculateGameResult 计算游戏结果
 func (t *Table) CalculateGameResult() *GameResult {
+	// 本局已经由流程结束时直接返回结果
+	if t.Result != nil {
+		return t.Result
+	}
 	// 更完整的结果生成，尽量与 C++ 的 generate_result_* 行为对应
 	// 优先判定特殊流局
-	if t.isAbaortedFourWind() {
-		return GenerateResultSuzukaflush()
+	rule := t.rule()
+	if rule.Suufonrenda && t.isSuufonrenda() {
+		return GenerateResultSuufonrenda()
 	}
-	if t.isFourRiichi() {
-		return GenerateResultSuuchahan()
+	if rule.SuuchaRiichi && t.isSuuchaRiichi() {
+		return GenerateResultSuuchaRiichi()
 	}
-	if t.isFourKanAborted() {
-		return GenerateResultSufonrenda()
-	}
-
-	// 九种九牌判定：若任一玩家满足九种九牌
-	for i := 0; i < NPlayers; i++ {
-		if t.Players[i] != nil {
-			if len(t.Players[i].GetKyushukyuhai()) > 0 {
-				return GenerateResultKyushukyuhai(i)
-			}
-		}
+	if rule.Suukaikan && t.isSuukaikan() {
+		return GenerateResultSuukaikan()
 	}
 
-	// 牌库流局（含流局满贯判断在 GenerateResultNotile 中处理）
+	// 荒牌流局（含流局满贯与不听罚符）
 	if t.GetRemainTile() == 0 {
-		return GenerateResultNotile()
+		return t.notileResult()
 	}
 
 	// 若无特殊流局，返回当前分数快照（保持兼容旧行为）
 	result := NewGameResult()
-	result.SetRyukyokuNotile() // 标记为流局占位（使 Message 可用）
+	result.SetRyukyokuNotile([4]bool{}) // 标记为流局占位（使 Message 可用）
 	// 构造快照消息和分数变化为0（保留最终分数信息在 Message 中）
 	sb := strings.Builder{}
 	sb.WriteString("最终分数: ")
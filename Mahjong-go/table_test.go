@@ -0,0 +1,950 @@
+package mahjong
+
+import (
+	"slices"
+	"testing"
+)
+
+// buildYama 按配牌与摸牌顺序构造牌山（庄家为0）
+// hands 为四家的13张配牌，draws 为之后依次摸到的牌，其余位置用未使用的牌填充
+func buildYama(t *testing.T, hands [NPlayers][]BaseTile, draws []BaseTile) []int {
+	t.Helper()
+	var used [NBaseTiles]int
+	take := func(tile BaseTile) int {
+		if used[tile] >= 4 {
+			t.Fatalf("more than four copies of %s", BaseTileToString(tile))
+		}
+		id := int(tile)*4 + used[tile]
+		used[tile]++
+		return id
+	}
+
+	order := make([]int, 0, NTiles)
+	for round := 0; round < 3; round++ {
+		for p := 0; p < NPlayers; p++ {
+			for _, tile := range hands[p][round*4 : round*4+4] {
+				order = append(order, take(tile))
+			}
+		}
+	}
+	for p := 0; p < NPlayers; p++ {
+		order = append(order, take(hands[p][12]))
+	}
+	for _, tile := range draws {
+		order = append(order, take(tile))
+	}
+
+	// 从牌山末尾摸牌，先摸的牌放在最后
+	yama := make([]int, 0, NTiles)
+	for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+		for used[tile] < 4 {
+			yama = append(yama, take(tile))
+		}
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		yama = append(yama, order[i])
+	}
+	return yama
+}
+
+// newScenarioTable 用构造的牌山开始一局
+func newScenarioTable(t *testing.T, hands [NPlayers][]BaseTile, draws []BaseTile, scores []int, kyoutaku int) *Table {
+	t.Helper()
+	return newScenarioTableWithConfig(t, hands, draws, GameConfig{InitScores: scores, Kyoutaku: kyoutaku})
+}
+
+// newScenarioTableWithConfig 在给定配置（规则、本场等）下用构造的牌山开始一局
+func newScenarioTableWithConfig(t *testing.T, hands [NPlayers][]BaseTile, draws []BaseTile, config GameConfig) *Table {
+	t.Helper()
+	config.HasSeed = true
+	config.Seed = 1
+	config.YamaLog = buildYama(t, hands, draws)
+	table := NewTable()
+	table.GameInitWithConfig(config)
+	return table
+}
+
+// selectSelf 选择回合玩家的某个自主行动
+func selectSelf(t *testing.T, table *Table, action BaseAction, tile BaseTile) {
+	t.Helper()
+	for i, a := range table.SelfActions {
+		if a.GetAction() == action && (len(a.CorrespondTiles) == 0 || a.CorrespondTiles[0].Tile == tile) {
+			table.MakeSelection(i)
+			return
+		}
+	}
+	t.Fatalf("player %d cannot %s %s", table.Turn, BaseActionToString(action), BaseTileToString(tile))
+}
+
+// selectResponse 当前响应玩家选择某个响应
+func selectResponse(t *testing.T, table *Table, action BaseAction) {
+	t.Helper()
+	for i, a := range table.ResponseActions {
+		if a.GetAction() == action {
+			table.MakeSelection(i)
+			return
+		}
+	}
+	t.Fatalf("player %d cannot respond with %s", table.WhoMakeSelection(), BaseActionToString(action))
+}
+
+// passResponses 剩余的响应玩家全部选择 Pass
+func passResponses(table *Table) {
+	for table.Phase > P4Action && table.Phase < GameOver {
+		table.MakeSelection(0)
+	}
+}
+
+func hasSelfAction(table *Table, action BaseAction) bool {
+	for _, a := range table.SelfActions {
+		if a.GetAction() == action {
+			return true
+		}
+	}
+	return false
+}
+
+// 子家的配牌，不会对东家的打牌产生鸣牌或荣和
+var riichiScenarioOthers = [NPlayers - 1][]BaseTile{
+	{_1s, _3s, _5s, _7s, _9s, _1z, _2z, _3z, _4z, _6z, _2p, _6p, _8p},
+	{_9p, _9p, _2s, _4s, _6s, _8s, _4p, _7p, _1z, _2z, _3z, _4z, _7z},
+	{_2m, _5m, _8m, _3p, _6p, _1s, _4s, _7s, _9s, _5z, _6z, _7z, _1z},
+}
+
+func riichiScenarioHands(oya []BaseTile) [NPlayers][]BaseTile {
+	return [NPlayers][]BaseTile{oya, riichiScenarioOthers[0], riichiScenarioOthers[1], riichiScenarioOthers[2]}
+}
+
+func TestRiichiDeclarationAndStick(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+	table := newScenarioTable(t, hands, []BaseTile{_9s, _9p}, nil, 0)
+
+	if !hasSelfAction(table, Riichi) {
+		t.Fatalf("tenpai dealer should be able to declare riichi")
+	}
+	selectSelf(t, table, Riichi, _9s)
+	p0 := table.Players[0]
+	if p0.IsRiichi() || p0.Score != 25000 || table.Kyoutaku != 0 {
+		t.Fatalf("riichi must not be established before the declaration tile passes")
+	}
+	passResponses(table)
+
+	if !p0.Riichi || !p0.DoubleRiichi {
+		t.Fatalf("first-turn riichi should be a double riichi")
+	}
+	if p0.Score != 24000 || table.Kyoutaku != 1 {
+		t.Fatalf("expected the 1000 point stick to be paid, score=%d kyoutaku=%d", p0.Score, table.Kyoutaku)
+	}
+	if !p0.Ippatsu {
+		t.Fatalf("ippatsu should be available after declaring")
+	}
+	if !p0.River.River[0].Riichi {
+		t.Fatalf("declaration tile should be marked in the river")
+	}
+
+	// 子家打出9p被碰，一发消失
+	if table.Turn != 1 {
+		t.Fatalf("expected player 1 to move, got %d", table.Turn)
+	}
+	selectSelf(t, table, Discard, _9p)
+	selectResponse(t, table, Pass)
+	selectResponse(t, table, Pass)
+	selectResponse(t, table, Pon)
+	selectResponse(t, table, Pass)
+	if table.Turn != 2 || table.LastAction != Pon {
+		t.Fatalf("expected player 2 to pon, turn=%d last=%v", table.Turn, table.LastAction)
+	}
+	if p0.Ippatsu {
+		t.Fatalf("a call must cancel ippatsu")
+	}
+	if !p0.IsRiichi() {
+		t.Fatalf("riichi should stay after a call")
+	}
+}
+
+func TestRiichiDeclarationTileRon(t *testing.T) {
+	hands := [NPlayers][]BaseTile{
+		{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z},
+		{_7z, _7z, _7z, _2s, _3s, _4s, _5p, _6p, _7p, _7s, _8s, _2z, _2z},
+		{_1m, _4m, _7m, _4p, _7p, _9p, _1s, _4s, _1z, _3z, _4z, _6z, _6z},
+		{_2m, _5m, _8m, _3p, _8p, _2s, _5s, _6s, _1z, _3z, _4z, _2z, _5z},
+	}
+	table := newScenarioTable(t, hands, []BaseTile{_9s}, nil, 1)
+
+	selectSelf(t, table, Riichi, _9s)
+	selectResponse(t, table, Pass)
+	selectResponse(t, table, Ron)
+	passResponses(table)
+
+	if !table.IsGameOver() || table.Result == nil || table.Result.Type != RonAgari {
+		t.Fatalf("expected a ron, got %v", table.Result)
+	}
+	ron := table.Result.Score.RonScore
+	if table.Players[0].IsRiichi() || table.Players[0].Score != 25000-ron {
+		t.Fatalf("ron on the declaration tile must not take the stick, score=%d", table.Players[0].Score)
+	}
+	if table.Players[1].Score != 25000+ron+1000 || table.Kyoutaku != 0 {
+		t.Fatalf("winner should collect the existing stick, score=%d kyoutaku=%d", table.Players[1].Score, table.Kyoutaku)
+	}
+}
+
+func TestRiichiRequirements(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+
+	table := newScenarioTable(t, hands, []BaseTile{_9s}, []int{900, 25000, 25000, 49100}, 0)
+	if hasSelfAction(table, Riichi) || table.Players[0].CanDiscardForRiichiWithTable(table, _9s) {
+		t.Fatalf("riichi needs at least 1000 points")
+	}
+
+	table = newScenarioTable(t, hands, []BaseTile{_9s}, nil, 0)
+	p0 := table.Players[0]
+	if !p0.CanDiscardForRiichiWithTable(table, _9s) || p0.CanDiscardForRiichiWithTable(table, _1m) {
+		t.Fatalf("riichi discard should keep the hand tenpai")
+	}
+	table.Yama = table.Yama[:14+3]
+	if len(p0.GetRiichiWithTable(table)) != 0 || p0.CanDiscardForRiichiWithTable(table, _9s) {
+		t.Fatalf("riichi needs at least four tiles left in the wall")
+	}
+}
+
+func TestRiichiAnkan(t *testing.T) {
+	// 东家立直后，子家依次摸切
+	draws := func(kan BaseTile) []BaseTile {
+		return []BaseTile{_5z, _1p, _5p, _8p, kan}
+	}
+	playUntilKan := func(hand []BaseTile, kan BaseTile) *Table {
+		table := newScenarioTable(t, riichiScenarioHands(hand), draws(kan), nil, 0)
+		selectSelf(t, table, Riichi, _5z)
+		passResponses(table)
+		for _, tile := range []BaseTile{_1p, _5p, _8p} {
+			selectSelf(t, table, Discard, tile)
+			passResponses(table)
+		}
+		if table.Turn != 0 {
+			t.Fatalf("expected the dealer's turn, got %d", table.Turn)
+		}
+		return table
+	}
+
+	// 111m 摸1m：听牌（7z、9m）与拆分都不变，可以暗杠
+	table := playUntilKan([]BaseTile{_1m, _1m, _1m, _2p, _3p, _4p, _5s, _6s, _7s, _7z, _7z, _9m, _9m}, _1m)
+	p0 := table.Players[0]
+	if !p0.Ippatsu {
+		t.Fatalf("ippatsu should survive a round without calls")
+	}
+	selectSelf(t, table, AnKan, _1m)
+	passResponses(table)
+	if !p0.IsRiichi() || p0.Ippatsu {
+		t.Fatalf("ankan keeps riichi but cancels ippatsu")
+	}
+	if len(p0.CallGroups) != 1 || p0.CallGroups[0].Type != Kantsu || p0.CallGroups[0].IsOpen {
+		t.Fatalf("expected a closed kan, got %v", p0.CallGroups)
+	}
+	if !IsSameContainer(p0.AtariTiles, []BaseTile{_9m, _7z}) {
+		t.Fatalf("waits should not change after the kan, got %v", p0.AtariTiles)
+	}
+	if table.Turn != 0 || table.Phase != P1Action || table.NActiveDora != 2 {
+		t.Fatalf("expected a rinshan draw with a new dora, phase=%d dora=%d", table.Phase, table.NActiveDora)
+	}
+
+	// 3334567m 摸3m：听牌从 2m4m5m7m8m 变为 4m7m，不能暗杠
+	table = playUntilKan([]BaseTile{_3m, _3m, _3m, _4m, _5m, _6m, _7m, _2p, _3p, _4p, _7s, _8s, _9s}, _3m)
+	if hasSelfAction(table, AnKan) {
+		t.Fatalf("ankan that changes the waits must be rejected")
+	}
+}
+
+// 北家听 6s9s（中），其余三家的配牌不会和牌
+var furitenScenarioHands = [NPlayers][]BaseTile{
+	{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z},
+	{_1m, _4m, _7m, _4p, _7p, _9p, _1s, _4s, _1z, _3z, _4z, _6z, _6z},
+	{_2m, _5m, _8m, _3p, _8p, _2s, _5s, _6s, _1z, _3z, _4z, _2z, _5z},
+	{_7z, _7z, _7z, _2s, _3s, _4s, _5p, _6p, _7p, _7s, _8s, _2z, _2z},
+}
+
+// discardAndPass 回合玩家打出一张牌，其他玩家都不响应
+func discardAndPass(t *testing.T, table *Table, tile BaseTile) {
+	t.Helper()
+	selectSelf(t, table, Discard, tile)
+	passResponses(table)
+}
+
+func hasResponseAction(table *Table, action BaseAction) bool {
+	for _, a := range table.ResponseActions {
+		if a.GetAction() == action {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFuritenSameTurn(t *testing.T) {
+	table := newScenarioTable(t, furitenScenarioHands, []BaseTile{_9s, _6s, _9p, _1p}, nil, 0)
+	p3 := table.Players[3]
+
+	// 见逃东家的9s
+	selectSelf(t, table, Discard, _9s)
+	for table.WhoMakeSelection() != 3 {
+		table.MakeSelection(0)
+	}
+	if !hasResponseAction(table, Ron) {
+		t.Fatalf("player 3 should be able to ron 9s")
+	}
+	selectResponse(t, table, Pass)
+	info := p3.ExplainFuriten()
+	if info.Kind != FuritenByRound || info.Tile == nil || info.Tile.Tile != _9s {
+		t.Fatalf("declining a win should cause same-turn furiten on 9s, got %v", info)
+	}
+
+	// 同一巡内不能荣和另一张听牌
+	selectSelf(t, table, Discard, _6s)
+	for table.WhoMakeSelection() != 3 {
+		table.MakeSelection(0)
+	}
+	if hasResponseAction(table, Ron) || len(p3.GetRon(table, table.SelectedTile)) != 0 {
+		t.Fatalf("same-turn furiten must block ron")
+	}
+	passResponses(table)
+
+	// 轮到自己后解除
+	discardAndPass(t, table, _9p)
+	if table.Turn != 3 || p3.IsFuriten() {
+		t.Fatalf("same-turn furiten should end on the player's own turn, got %v", p3.ExplainFuriten())
+	}
+}
+
+func TestFuritenNoYakuWait(t *testing.T) {
+	// 北家 5s7s 坎张听 6s，门清无役不能荣和
+	hands := furitenScenarioHands
+	hands[3] = []BaseTile{_1m, _2m, _3m, _7p, _8p, _9p, _2s, _3s, _4s, _5s, _7s, _9m, _9m}
+	table := newScenarioTable(t, hands, []BaseTile{_6s}, nil, 0)
+	p3 := table.Players[3]
+
+	// 生成行动不改变振听状态
+	if len(p3.GetRon(table, table.Tiles[int(_6s)*4])) != 0 || p3.IsFuriten() {
+		t.Fatalf("listing ron actions should neither allow a yakuless ron nor cause furiten")
+	}
+
+	selectSelf(t, table, Discard, _6s)
+	for table.WhoMakeSelection() != 3 {
+		table.MakeSelection(0)
+	}
+	if hasResponseAction(table, Ron) || p3.IsFuriten() {
+		t.Fatalf("a yakuless wait cannot be ronned and furiten starts only after the response")
+	}
+	selectResponse(t, table, Pass)
+	if info := p3.ExplainFuriten(); info.Kind != FuritenByRound || info.Tile.Tile != _6s {
+		t.Fatalf("letting a yakuless wait pass should cause same-turn furiten, got %v", info)
+	}
+}
+
+func TestFuritenRiver(t *testing.T) {
+	table := newScenarioTable(t, furitenScenarioHands, []BaseTile{_1p, _5p, _8p, _6s, _9s}, nil, 0)
+	p3 := table.Players[3]
+
+	discardAndPass(t, table, _1p)
+	discardAndPass(t, table, _5p)
+	discardAndPass(t, table, _8p)
+	// 北家摸到和牌却打出
+	if !hasSelfAction(table, Tsumo) {
+		t.Fatalf("player 3 should be able to tsumo")
+	}
+	discardAndPass(t, table, _6s)
+	info := p3.ExplainFuriten()
+	if info.Kind != FuritenByRiver || info.Tile.Tile != _6s {
+		t.Fatalf("discarding a wait should cause river furiten, got %v", info)
+	}
+
+	selectSelf(t, table, Discard, _9s)
+	for table.WhoMakeSelection() != 3 {
+		table.MakeSelection(0)
+	}
+	if hasResponseAction(table, Ron) {
+		t.Fatalf("river furiten must block ron on every wait")
+	}
+	if !IsSameContainer(p3.GetFuritenTiles(), []BaseTile{_6s}) {
+		t.Fatalf("unexpected furiten tiles %v", p3.GetFuritenTiles())
+	}
+}
+
+func TestFuritenRiichi(t *testing.T) {
+	table := newScenarioTable(t, furitenScenarioHands,
+		[]BaseTile{_1p, _5p, _8p, _1z, _9s, _6s, _9p, _3m}, nil, 0)
+	p3 := table.Players[3]
+
+	discardAndPass(t, table, _1p)
+	discardAndPass(t, table, _5p)
+	discardAndPass(t, table, _8p)
+	selectSelf(t, table, Riichi, _1z)
+	passResponses(table)
+	if !p3.IsRiichi() || p3.IsFuriten() {
+		t.Fatalf("expected a clean riichi")
+	}
+
+	// 立直后见逃
+	selectSelf(t, table, Discard, _9s)
+	for table.WhoMakeSelection() != 3 {
+		table.MakeSelection(0)
+	}
+	selectResponse(t, table, Pass)
+	discardAndPass(t, table, _6s)
+	discardAndPass(t, table, _9p)
+	discardAndPass(t, table, _3m)
+
+	info := p3.ExplainFuriten()
+	if info.Kind != FuritenByRiichi || info.Tile.Tile != _9s {
+		t.Fatalf("declining a win after riichi should last for the hand, got %v", info)
+	}
+	if len(p3.GetRon(table, table.Tiles[int(_6s)*4])) != 0 {
+		t.Fatalf("riichi furiten must block ron")
+	}
+}
+
+func TestKyushukyuhai(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _9m, _1p, _9p, _1s, _9s, _1z, _2z, _3z, _2m, _3m, _4m, _5m})
+	table := newScenarioTableWithConfig(t, hands, []BaseTile{_5m}, GameConfig{Honba: 2, Kyoutaku: 1})
+
+	if !hasSelfAction(table, Kyushukyuhai) {
+		t.Fatalf("nine kinds of terminals should allow kyushukyuhai")
+	}
+	selectSelf(t, table, Kyushukyuhai, _1m)
+	if !table.IsGameOver() || table.Result.Type != RyukyokuKyushukyuhai {
+		t.Fatalf("expected kyushukyuhai draw, got %v", table.Result)
+	}
+	if !table.Result.Renchan || table.Result.Honba != 3 || table.Result.Kyoutaku != 1 {
+		t.Fatalf("abortive draw should keep the dealer and carry sticks, got %+v", table.Result)
+	}
+	for i := 0; i < NPlayers; i++ {
+		if table.Players[i].Score != 25000 {
+			t.Fatalf("abortive draw must not move points, player %d has %d", i, table.Players[i].Score)
+		}
+	}
+
+	rule := DefaultGameRule()
+	rule.Kyushukyuhai = false
+	table = newScenarioTableWithConfig(t, hands, []BaseTile{_5m}, GameConfig{Rule: rule})
+	if hasSelfAction(table, Kyushukyuhai) {
+		t.Fatalf("kyushukyuhai must not be offered when the rule is disabled")
+	}
+}
+
+func TestSuufonrenda(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _3p, _5p, _7p, _1z})
+	draws := []BaseTile{_2m, _3m, _4m, _5m}
+
+	table := newScenarioTable(t, hands, draws, nil, 0)
+	for i := 0; i < NPlayers; i++ {
+		discardAndPass(t, table, _1z)
+	}
+	if !table.IsGameOver() || table.Result.Type != RyukyokuSuufonrenda {
+		t.Fatalf("four identical first-turn winds should abort, got %v", table.Result)
+	}
+
+	rule := DefaultGameRule()
+	rule.Suufonrenda = false
+	table = newScenarioTableWithConfig(t, hands, draws, GameConfig{Rule: rule})
+	for i := 0; i < NPlayers; i++ {
+		discardAndPass(t, table, _1z)
+	}
+	if table.IsGameOver() || table.Phase != P1Action {
+		t.Fatalf("game should continue when suufonrenda is disabled, phase=%d", table.Phase)
+	}
+}
+
+func TestSuuchaRiichi(t *testing.T) {
+	hands := [NPlayers][]BaseTile{
+		{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z},
+		{_1s, _2s, _3s, _4s, _5s, _6s, _7s, _8s, _9s, _4p, _5p, _6p, _6z},
+		{_2m, _3m, _4m, _5m, _6m, _7m, _7p, _8p, _9p, _1z, _1z, _1z, _7z},
+		{_2s, _3s, _4s, _5s, _6s, _7s, _3m, _4m, _5m, _2z, _2z, _2z, _3z},
+	}
+	draws := []BaseTile{_9m, _1p, _1s, _9s}
+
+	table := newScenarioTable(t, hands, draws, nil, 0)
+	riichiAll := func() {
+		for i, tile := range draws {
+			selectSelf(t, table, Riichi, tile)
+			if i < NPlayers-1 {
+				passResponses(table)
+			}
+		}
+	}
+	riichiAll()
+	passResponses(table)
+	if !table.IsGameOver() || table.Result.Type != RyukyokuSuuchaRiichi {
+		t.Fatalf("four riichi should abort, got %v", table.Result)
+	}
+	if table.Result.Kyoutaku != 4 {
+		t.Fatalf("all four riichi sticks should stay on the table, got %d", table.Result.Kyoutaku)
+	}
+	for i := 0; i < NPlayers; i++ {
+		if table.Players[i].Score != 24000 {
+			t.Fatalf("player %d should have paid the riichi stick, score=%d", i, table.Players[i].Score)
+		}
+	}
+
+	rule := DefaultGameRule()
+	rule.SuuchaRiichi = false
+	table = newScenarioTableWithConfig(t, hands, draws, GameConfig{Rule: rule})
+	riichiAll()
+	passResponses(table)
+	if table.IsGameOver() || table.Kyoutaku != 4 {
+		t.Fatalf("game should continue with four sticks when the rule is disabled")
+	}
+}
+
+func TestSuukaikan(t *testing.T) {
+	setup := func(rule *GameRule, kanPlayers []int) *Table {
+		hands := riichiScenarioHands([]BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z})
+		table := newScenarioTableWithConfig(t, hands, []BaseTile{_9s}, GameConfig{Rule: rule})
+		// 直接构造四次杠后的局面：岭上牌取尽且副露中有四个杠子
+		for _, p := range kanPlayers {
+			table.Players[p].CallGroups = append(table.Players[p].CallGroups, CallGroup{Type: Kantsu})
+		}
+		table.Yama = table.Yama[4:]
+		table.FromBeginning()
+		return table
+	}
+
+	table := setup(nil, []int{0, 0, 1, 1})
+	if !table.IsGameOver() || table.Result.Type != RyukyokuSuukaikan {
+		t.Fatalf("four kans by two players should abort, got %v", table.Result)
+	}
+
+	table = setup(nil, []int{2, 2, 2, 2})
+	if table.IsGameOver() {
+		t.Fatalf("four kans by one player must not abort")
+	}
+
+	rule := DefaultGameRule()
+	rule.Suukaikan = false
+	table = setup(rule, []int{0, 0, 1, 1})
+	if table.IsGameOver() {
+		t.Fatalf("game should continue when suukaikan is disabled")
+	}
+}
+
+// 东家打出的9s同时被三家荣和
+var sanchahouScenarioHands = [NPlayers][]BaseTile{
+	{_1z, _1z, _1z, _3z, _3z, _3z, _4z, _4z, _4z, _4m, _5m, _6m, _8p},
+	{_7z, _7z, _7z, _2s, _3s, _4s, _5p, _6p, _7p, _7s, _8s, _2z, _2z},
+	{_6z, _6z, _6z, _2m, _3m, _4m, _4p, _5p, _6p, _7m, _8m, _9m, _9s},
+	{_5z, _5z, _5z, _1m, _2m, _3m, _1p, _2p, _3p, _7s, _8s, _9p, _9p},
+}
+
+func TestSanchahou(t *testing.T) {
+	table := newScenarioTableWithConfig(t, sanchahouScenarioHands, []BaseTile{_9s}, GameConfig{Honba: 1})
+	selectSelf(t, table, Discard, _9s)
+	selectResponse(t, table, Pass)
+	for i := 0; i < NPlayers-1; i++ {
+		selectResponse(t, table, Ron)
+	}
+	if !table.IsGameOver() || table.Result.Type != RyukyokuSanchahou {
+		t.Fatalf("triple ron should abort, got %v", table.Result)
+	}
+	if !table.Result.Renchan || table.Result.Honba != 2 {
+		t.Fatalf("sanchahou should keep the dealer and add a honba, got %+v", table.Result)
+	}
+
+	rule := DefaultGameRule()
+	rule.Sanchahou = false
+	table = newScenarioTableWithConfig(t, sanchahouScenarioHands, []BaseTile{_9s}, GameConfig{Honba: 1, Rule: rule})
+	selectSelf(t, table, Discard, _9s)
+	selectResponse(t, table, Pass)
+	for i := 0; i < NPlayers-1; i++ {
+		selectResponse(t, table, Ron)
+	}
+	result := table.Result
+	if result == nil || result.Type != RonAgari || len(result.Winners) != 3 {
+		t.Fatalf("expected a triple ron, got %v", result)
+	}
+	if result.Winners[0] != 1 {
+		t.Fatalf("honba should go to the first winner after the discarder, got %v", result.Winners)
+	}
+	if result.Renchan || result.Honba != 0 {
+		t.Fatalf("dealer dealt in, expected no renchan, got %+v", result)
+	}
+	total := 0
+	for i := 0; i < NPlayers; i++ {
+		total += table.Players[i].Score
+	}
+	if total != 100000 {
+		t.Fatalf("points must be conserved, total=%d", total)
+	}
+}
+
+func TestRyukyokuNotilePayments(t *testing.T) {
+	var tenpai [NPlayers]bool
+	tenpai[1] = true
+	result := GenerateResultNotile(tenpai)
+	if result.ScoreChanges != [4]int{-1000, 3000, -1000, -1000} {
+		t.Fatalf("single tenpai should receive 3000, got %v", result.ScoreChanges)
+	}
+	tenpai[3] = true
+	result = GenerateResultNotile(tenpai)
+	if result.ScoreChanges != [4]int{-1500, 1500, -1500, 1500} {
+		t.Fatalf("two tenpai should split 3000, got %v", result.ScoreChanges)
+	}
+	result = GenerateResultNotile([NPlayers]bool{true, true, true, true})
+	if result.ScoreChanges != [4]int{} {
+		t.Fatalf("all tenpai should not move points, got %v", result.ScoreChanges)
+	}
+}
+
+// lastLog 返回牌桌的最后一条日志
+func lastLog(table *Table, back int) *BaseGameLog {
+	return table.GameLog.GetLog(table.GameLog.GetLogCount() - 1 - back)
+}
+
+func TestKanDoraAnkanImmediate(t *testing.T) {
+	hands := riichiScenarioHands([]BaseTile{_1m, _1m, _1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _2p, _3p})
+	table := newScenarioTable(t, hands, []BaseTile{_1m}, nil, 0)
+
+	selectSelf(t, table, AnKan, _1m)
+	if table.NActiveDora != 1 {
+		t.Fatalf("dora must not be revealed before chankan responses")
+	}
+	passResponses(table)
+	if table.NActiveDora != 2 || table.PendingKanDora != 0 {
+		t.Fatalf("ankan should reveal the new dora immediately, active=%d", table.NActiveDora)
+	}
+	log := lastLog(table, 1)
+	if log.Action != LogDoraReveal || log.Tile != table.DoraIndicator[1] {
+		t.Fatalf("expected a dora reveal log before the rinshan draw, got %v", log)
+	}
+}
+
+// 东家打出8p被下家大明杠
+var daiminkanScenarioHands = [NPlayers][]BaseTile{
+	{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1p, _2p, _3p, _5z},
+	{_8p, _8p, _8p, _1s, _3s, _5s, _7s, _9s, _1z, _2z, _3z, _4z, _6z},
+	riichiScenarioOthers[1],
+	riichiScenarioOthers[2],
+}
+
+func TestKanDoraDaiminkanDelayed(t *testing.T) {
+	table := newScenarioTable(t, daiminkanScenarioHands, []BaseTile{_8p}, nil, 0)
+	selectSelf(t, table, Discard, _8p)
+	selectResponse(t, table, Pass)
+	selectResponse(t, table, Kan)
+	passResponses(table)
+
+	if table.Turn != 1 || table.NActiveDora != 1 || table.PendingKanDora != 1 {
+		t.Fatalf("daiminkan dora should wait for the discard, active=%d pending=%d", table.NActiveDora, table.PendingKanDora)
+	}
+	selectSelf(t, table, Discard, _1s)
+	if table.NActiveDora != 2 || table.PendingKanDora != 0 {
+		t.Fatalf("daiminkan dora should be revealed after the discard, active=%d", table.NActiveDora)
+	}
+	if lastLog(table, 0).Action != LogDoraReveal || lastLog(table, 1).Action != LogDiscardFromHand {
+		t.Fatalf("dora reveal should be logged right after the discard, got %v / %v", lastLog(table, 1), lastLog(table, 0))
+	}
+
+	rule := DefaultGameRule()
+	rule.KanDoraDelayed = false
+	table = newScenarioTableWithConfig(t, daiminkanScenarioHands, []BaseTile{_8p}, GameConfig{Rule: rule})
+	selectSelf(t, table, Discard, _8p)
+	selectResponse(t, table, Pass)
+	selectResponse(t, table, Kan)
+	passResponses(table)
+	if table.NActiveDora != 2 || table.PendingKanDora != 0 {
+		t.Fatalf("dora should be revealed immediately when delay is disabled, active=%d", table.NActiveDora)
+	}
+}
+
+// physicalTiles 按牌种构造实际的牌，red 中的牌种的第一张为赤宝牌
+func physicalTiles(tiles []BaseTile, red ...BaseTile) []*Tile {
+	var used [NBaseTiles]int
+	result := make([]*Tile, 0, len(tiles))
+	for _, bt := range tiles {
+		tile := &Tile{Tile: bt, ID: int(bt)*4 + used[bt]}
+		tile.RedDora = used[bt] == 0 && IsIn(red, bt)
+		used[bt]++
+		result = append(result, tile)
+	}
+	return result
+}
+
+func redCounts(actions []*ResponseAction) []int {
+	counts := make([]int, 0, len(actions))
+	for _, a := range actions {
+		counts = append(counts, CountRedDora(a.CorrespondTiles))
+	}
+	return counts
+}
+
+func TestCallVariantsWithRedFive(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.Hand = physicalTiles([]BaseTile{_5p, _5p, _5p, _4p, _6p, _6p, _1m, _2m, _3m, _9s, _9s, _9s, _1z}, _5p)
+	called := &Tile{Tile: _5p, ID: int(_5p)*4 + 3}
+
+	// 0p5p 与 5p5p 两种碰法，两张普通5p的组合只保留一个
+	pons := p.GetPon(called)
+	if counts := redCounts(pons); len(counts) != 2 || counts[0]+counts[1] != 1 {
+		t.Fatalf("expected pon with and without the red five, got %v", redCounts(pons))
+	}
+	kans := p.GetKan(called)
+	if len(kans) != 1 || CountRedDora(kans[0].CorrespondTiles) != 1 {
+		t.Fatalf("expected a single kan variant holding the red five")
+	}
+
+	// 吃7p：0p6p、5p6p 各一种，两张6p不产生重复
+	chis := p.GetChi(&Tile{Tile: _7p, ID: int(_7p) * 4})
+	variants := 0
+	for _, a := range chis {
+		if a.CorrespondTiles[0].Tile == _5p && a.CorrespondTiles[1].Tile == _6p {
+			variants++
+		}
+	}
+	if variants != 2 {
+		t.Fatalf("expected two 56p chi variants, got %d", variants)
+	}
+
+	// 选择保留赤宝牌的碰法后，副露记录赤宝牌
+	for _, a := range pons {
+		if CountRedDora(a.CorrespondTiles) == 1 {
+			p.ExecuteNakiWithTiles(a.CorrespondTiles, called, 1)
+		}
+	}
+	if len(p.CallGroups) != 1 || p.CallGroups[0].RedDora != 1 || CountRedDora(p.Hand) != 0 {
+		t.Fatalf("red five should move into the call group, got %+v", p.CallGroups)
+	}
+}
+
+func TestDeprecatedNakiWrappers(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.Hand = physicalTiles([]BaseTile{_3m, _4m, _7p, _7p, _7p, _9s, _9s, _1z, _2z, _3z, _4z, _5z, _6z})
+
+	p.ExecuteNaki(&Tile{Tile: _5m, ID: int(_5m)*4 + 1}, Chi)
+	p.ExecutePonSimple(&Tile{Tile: _9s, ID: int(_9s)*4 + 2})
+	p.ExecuteKanSimple(&Tile{Tile: _7p, ID: int(_7p)*4 + 3})
+
+	want := []CallGroup{
+		{Type: Shuntsu, Tiles: []BaseTile{_3m, _4m, _5m}, IsOpen: true, Take: 2},
+		{Type: Koutsu, Tiles: []BaseTile{_9s, _9s, _9s}, IsOpen: true},
+		{Type: Kantsu, Tiles: []BaseTile{_7p, _7p, _7p, _7p}, IsOpen: true},
+	}
+	if !slices.EqualFunc(p.CallGroups, want, func(a, b CallGroup) bool {
+		return a.Type == b.Type && slices.Equal(a.Tiles, b.Tiles) && a.Take == b.Take
+	}) || len(p.Hand) != 6 || p.IsMenzen() {
+		t.Fatalf("deprecated wrappers should make real calls, got %v with %d tiles left", p.CallGroups, len(p.Hand))
+	}
+}
+
+func TestKakanRequiresPonTile(t *testing.T) {
+	p := NewPlayer(East, true)
+	p.CallGroups = []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_2s, _2s, _2s}, IsOpen: true}}
+	// 1m 的 ID 为0，不能被当成 2s 加杠
+	p.Hand = physicalTiles([]BaseTile{_1m, _1m, _3p, _4p, _5p, _7z, _7z, _9m, _9m, _1z, _1z})
+	if got := p.GetKakan(); len(got) != 0 {
+		t.Fatalf("kakan without the pon tile: %v", got[0].CorrespondTiles[0])
+	}
+	p.Hand = append(p.Hand, &Tile{Tile: _2s, ID: int(_2s)*4 + 3})
+	got := p.GetKakan()
+	if len(got) != 1 || got[0].CorrespondTiles[0].Tile != _2s {
+		t.Fatalf("expected one kakan of 2s, got %d", len(got))
+	}
+}
+
+// respondUntil 在轮到 seat 响应之前其他玩家都选择 Pass
+func respondUntil(table *Table, seat int) {
+	for table.WhoMakeSelection() != seat {
+		table.MakeSelection(0)
+	}
+}
+
+func TestChankanRoundWindOnly(t *testing.T) {
+	// 南家碰场风东后听 5p 坎张，唯一的役是场风；东家碰 5p 后加杠被抢杠
+	hands := [NPlayers][]BaseTile{
+		{_1z, _5p, _5p, _2s, _4s, _7s, _2z, _3z, _4z, _6z, _7z, _1p, _9p},
+		{_1z, _1z, _2m, _3m, _4m, _6m, _7m, _8m, _9s, _9s, _4p, _9m, _8p},
+		{_5p, _1m, _5m, _1s, _3s, _5s, _8s, _2z, _3z, _4z, _6z, _7z, _3p},
+		{_1m, _5m, _9m, _1s, _3s, _6s, _8s, _2z, _3z, _4z, _6z, _7z, _7p},
+	}
+	table := newScenarioTable(t, hands, []BaseTile{_3m, _2p, _6p, _1p, _2p, _5p}, nil, 0)
+	p1 := table.Players[1]
+
+	selectSelf(t, table, Discard, _1z)
+	respondUntil(table, 1)
+	selectResponse(t, table, Pon)
+	passResponses(table)
+	selectSelf(t, table, Discard, _8p)
+	passResponses(table)
+
+	selectSelf(t, table, Discard, _5p)
+	respondUntil(table, 0)
+	selectResponse(t, table, Pon)
+	passResponses(table)
+	selectSelf(t, table, Discard, _3m)
+	passResponses(table)
+
+	selectSelf(t, table, Discard, _9m)
+	passResponses(table)
+	if !IsSameContainer(p1.AtariTiles, []BaseTile{_5p}) {
+		t.Fatalf("expected a 5p kanchan wait, got %v", p1.AtariTiles)
+	}
+	discardAndPass(t, table, _1p)
+	discardAndPass(t, table, _2p)
+
+	selectSelf(t, table, KaKan, _5p)
+	respondUntil(table, 1)
+	if !hasResponseAction(table, ChanKan) {
+		t.Fatalf("an open hand with a round wind pon should be offered chankan")
+	}
+	if len(p1.GetChankan(nil, table.SelectedTile)) != 0 {
+		t.Fatalf("without the table the round wind is unknown and there is no yaku")
+	}
+	selectResponse(t, table, ChanKan)
+	passResponses(table)
+
+	result := table.Result
+	if result == nil || result.Type != RonAgari || result.WinnerIdx != 1 || result.LoserIdx != 0 {
+		t.Fatalf("expected player 1 to win by chankan, got %v", result)
+	}
+	if !hasYaku(result.Yakus, Yakuhai) {
+		t.Fatalf("expected the round wind yakuhai, got %v", result.Yakus)
+	}
+}
+
+func TestNextTurnOnlySetsTurn(t *testing.T) {
+	table := NewTable()
+	table.NextTurn(2)
+	if table.Turn != 2 || table.Phase != PhaseUninitialized {
+		t.Fatalf("NextTurn should only set the turn, got turn %d phase %d", table.Turn, table.Phase)
+	}
+}
+
+// handIDs 返回玩家手牌的 ID
+func handIDs(p *Player) []int {
+	ids := make([]int, len(p.Hand))
+	for i, tile := range p.Hand {
+		ids[i] = tile.ID
+	}
+	return ids
+}
+
+func TestShuffledYamaReplaysFromLog(t *testing.T) {
+	table := NewTable()
+	table.GameInitWithConfig(GameConfig{HasSeed: true, Seed: 7})
+	// 洗的是牌山，Tiles 保持按 ID 排列，ImportYama 才能按 ID 取牌
+	for i, tile := range table.Tiles {
+		if tile.ID != i {
+			t.Fatalf("Tiles[%d] has ID %d after shuffling", i, tile.ID)
+		}
+	}
+	if len(table.YamaLog) != NTiles {
+		t.Fatalf("yama log should hold the whole wall, got %d tiles", len(table.YamaLog))
+	}
+	log := append([]int(nil), table.YamaLog...)
+
+	// 摸牌不再追加到牌山日志
+	for i := 0; i < 8 && !table.IsGameOver(); i++ {
+		table.MakeSelection(0)
+		passResponses(table)
+	}
+	if len(table.YamaLog) != NTiles {
+		t.Fatalf("drawing should not grow the yama log, got %d tiles", len(table.YamaLog))
+	}
+
+	replay := NewTable()
+	replay.GameInitWithConfig(GameConfig{YamaLog: log})
+	original := NewTable()
+	original.GameInitWithConfig(GameConfig{HasSeed: true, Seed: 7})
+	for i := 0; i < NPlayers; i++ {
+		if !slices.Equal(handIDs(replay.Players[i]), handIDs(original.Players[i])) {
+			t.Fatalf("player %d: replayed hand %v, expected %v", i, handIDs(replay.Players[i]), handIDs(original.Players[i]))
+		}
+	}
+}
+
+func TestDoraIndicatorsInDeadWall(t *testing.T) {
+	table := NewTable()
+	table.GameInitWithConfig(GameConfig{HasSeed: true, Seed: 11})
+	log := table.YamaLog
+	// 从牌山末尾摸牌，开头的14张为王牌：宝牌指示牌为 5、7、9、11、13，里宝牌为 4、6、8、10、12
+	for i := 0; i < 5; i++ {
+		if table.DoraIndicator[i].ID != log[5+2*i] || table.UraDoraIndicator[i].ID != log[4+2*i] {
+			t.Fatalf("indicator %d is not taken from the dead wall", i)
+		}
+	}
+	for i := 0; i < NPlayers; i++ {
+		for _, tile := range table.Players[i].Hand {
+			if tile == table.DoraIndicator[0] || tile == table.UraDoraIndicator[0] {
+				t.Fatalf("player %d was dealt a dora indicator", i)
+			}
+		}
+	}
+}
+
+func TestDrawTenhouStyleDeal(t *testing.T) {
+	table := NewTable()
+	table.InitTiles()
+	table.InitRedDora3()
+	table.InitYama()
+	table.Oya = 1
+	table.DrawTenhouStyle()
+
+	// 从牌山末尾起每人4张共3轮，再每人1张，从庄家开始
+	for i := 0; i < NPlayers; i++ {
+		seat := (table.Oya + i) % NPlayers
+		var want []int
+		for round := 0; round < 3; round++ {
+			top := NTiles - 1 - 16*round - 4*i
+			want = append(want, top, top-1, top-2, top-3)
+		}
+		want = append(want, NTiles-1-48-i)
+		if got := handIDs(table.Players[seat]); !slices.Equal(got, want) {
+			t.Fatalf("seat %d dealt %v, expected %v", seat, got, want)
+		}
+	}
+	if len(table.Yama) != NTiles-52 {
+		t.Fatalf("expected %d tiles left after the deal, got %d", NTiles-52, len(table.Yama))
+	}
+}
+
+func TestDrawRinshanStackOrder(t *testing.T) {
+	table := NewTable()
+	table.InitTiles()
+	table.InitYama()
+	table.InitDora()
+	// 岭上牌两张一叠，上面一张在 Yama[1]：剩余4张时摸 Yama[1]，剩余3张时摸 Yama[0]
+	first, second := table.Yama[1], table.Yama[0]
+	table.DrawRinshan(0)
+	if table.GetRemainKanTile() != 3 {
+		t.Fatalf("expected 3 rinshan tiles left, got %d", table.GetRemainKanTile())
+	}
+	table.DrawRinshan(0)
+	if hand := table.Players[0].Hand; len(hand) != 2 || hand[0] != first || hand[1] != second {
+		t.Fatalf("rinshan drawn in the wrong order: %v", handIDs(table.Players[0]))
+	}
+}
+
+func TestPhaseEngineTurnCycle(t *testing.T) {
+	table := NewTable()
+	table.GameInitWithConfig(GameConfig{HasSeed: true, Seed: 3})
+	if table.Phase != P1Action || table.WhoMakeSelection() != 0 || len(table.Players[0].Hand) != 14 {
+		t.Fatalf("dealer should act first with 14 tiles, phase %d", table.Phase)
+	}
+	if table.MakeSelection(-1) || table.MakeSelection(len(table.SelfActions)) || len(table.SelectionLog) != 0 {
+		t.Fatalf("out-of-range selections should be rejected without logging")
+	}
+
+	tile := table.Players[0].Hand[13].Tile
+	selectSelf(t, table, Discard, tile)
+	// 响应阶段按绝对座位依次进行，打牌者自己也只能 Pass
+	if table.Phase != P1Response || len(table.ResponseActions) != 1 || table.ResponseActions[0].GetAction() != Pass {
+		t.Fatalf("expected the discarder to pass first, phase %d actions %d", table.Phase, len(table.ResponseActions))
+	}
+	for seat := 0; seat < NPlayers; seat++ {
+		if table.WhoMakeSelection() != seat {
+			t.Fatalf("expected seat %d to respond, got %d", seat, table.WhoMakeSelection())
+		}
+		selectResponse(t, table, Pass)
+	}
+	if table.Phase != P2Action || table.Turn != 1 || len(table.Players[1].Hand) != 14 {
+		t.Fatalf("expected seat 1 to draw and act, phase %d turn %d", table.Phase, table.Turn)
+	}
+	if len(table.SelectionLog) != 1+NPlayers {
+		t.Fatalf("expected every selection to be logged, got %v", table.SelectionLog)
+	}
+}
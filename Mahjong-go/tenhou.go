@@ -33,14 +33,15 @@ func TenhouYamaFromSeed(mtseedB64 string) ([]int, error) {
 	}
 
 	// initialize MT with RTseed
-	initByArray(rtseed)
+	mt := newMT19937()
+	mt.initByArray(rtseed)
 
 	// prepare src and rnd arrays (mirroring C++ sizes)
 	rndDwords := (sha512.Size / 4) * 9 // 144
 	srcLen := rndDwords * 2            // 288
 	src := make([]uint32, srcLen)
 	for i := 0; i < srcLen; i++ {
-		src[i] = genrandInt32()
+		src[i] = mt.genrandInt32()
 	}
 
 	// compute rnd by hashing src in 9 blocks of 128 bytes
@@ -7,21 +7,28 @@ const (
 	mtM = 397
 )
 
-var (
+// mt19937 生成器状态，每次生成牌山各自持有一份，可以并发使用
+type mt19937 struct {
 	mt    [mtN]uint32
-	mtIdx = mtN + 1
-)
+	mtIdx int
+}
+
+func newMT19937() *mt19937 {
+	return &mt19937{mtIdx: mtN + 1}
+}
 
-func initGenrand(s uint32) {
+func (g *mt19937) initGenrand(s uint32) {
+	mt := &g.mt
 	mt[0] = s
 	for i := 1; i < mtN; i++ {
 		mt[i] = (1812433253*(mt[i-1]^(mt[i-1]>>30)) + uint32(i)) & 0xffffffff
 	}
-	mtIdx = mtN
+	g.mtIdx = mtN
 }
 
-func initByArray(initKey []uint32) {
-	initGenrand(19650218)
+func (g *mt19937) initByArray(initKey []uint32) {
+	mt := &g.mt
+	g.initGenrand(19650218)
 	i := 1
 	j := 0
 	k := mtN
@@ -53,12 +60,13 @@ func initByArray(initKey []uint32) {
 	mt[0] = 0x80000000
 }
 
-func genrandInt32() uint32 {
+func (g *mt19937) genrandInt32() uint32 {
+	mt := &g.mt
 	var mag01 = [2]uint32{0x0, 0x9908b0df}
-	if mtIdx >= mtN {
+	if g.mtIdx >= mtN {
 		var kk int
-		if mtIdx == mtN+1 {
-			initGenrand(5489)
+		if g.mtIdx == mtN+1 {
+			g.initGenrand(5489)
 		}
 		for kk = 0; kk < mtN-mtM; kk++ {
 			y := (mt[kk] & 0x80000000) | (mt[kk+1] & 0x7fffffff)
@@ -70,10 +78,10 @@ func genrandInt32() uint32 {
 		}
 		y := (mt[mtN-1] & 0x80000000) | (mt[0] & 0x7fffffff)
 		mt[mtN-1] = mt[mtM-1] ^ (y >> 1) ^ mag01[y&0x1]
-		mtIdx = 0
+		g.mtIdx = 0
 	}
-	y := mt[mtIdx]
-	mtIdx++
+	y := mt[g.mtIdx]
+	g.mtIdx++
 	y ^= (y >> 11)
 	y ^= (y << 7) & 0x9d2c5680
 	y ^= (y << 15) & 0xefc60000
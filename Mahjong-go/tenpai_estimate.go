@@ -0,0 +1,122 @@
+package mahjong
+
+// 对手听牌推测
+// 推测器只读取 PlayerView 中的公开信息，可以换成训练得到的模型
+
+// OpponentEstimate 对一家对手的推测
+type OpponentEstimate struct {
+	Tenpai float64             // 听牌的概率
+	Waits  [NBaseTiles]float64 // 听牌时各种牌为其荣和牌的可能性，和为1；没有可能的和牌时全为0
+}
+
+// TenpaiEstimator 根据视角推测一家对手的听牌概率与和牌
+type TenpaiEstimator interface {
+	EstimateOpponent(view *PlayerView, opponent int) OpponentEstimate
+}
+
+// TenpaiEstimatorFunc 将函数适配为 TenpaiEstimator
+type TenpaiEstimatorFunc func(view *PlayerView, opponent int) OpponentEstimate
+
+// EstimateOpponent 实现 TenpaiEstimator
+func (f TenpaiEstimatorFunc) EstimateOpponent(view *PlayerView, opponent int) OpponentEstimate {
+	return f(view, opponent)
+}
+
+// EstimateOpponents 推测视角玩家以外各家的听牌情况，自己的座位为空
+// estimator 为 nil 时使用 HeuristicTenpaiEstimator
+func EstimateOpponents(view *PlayerView, estimator TenpaiEstimator) [NPlayers]OpponentEstimate {
+	if estimator == nil {
+		estimator = HeuristicTenpaiEstimator{}
+	}
+	var result [NPlayers]OpponentEstimate
+	for opponent := range result {
+		if opponent != view.Seat {
+			result[opponent] = estimator.EstimateOpponent(view, opponent)
+		}
+	}
+	return result
+}
+
+// Risk 以各家的听牌概率加权 AnalyzeDanger 给出的危险度
+func (d *TileDanger) Risk(estimates *[NPlayers]OpponentEstimate) float64 {
+	risk := 0.0
+	for opponent, s := range d.Opponents {
+		risk += estimates[opponent].Tenpai * s.Danger
+	}
+	return risk
+}
+
+// HeuristicTenpaiEstimator 基于经验规则的推测
+// 听牌概率：立直为1，否则由巡目（河的张数）、副露数与最近的手切、摸切估计
+// 和牌：在非现物中按 AnalyzeDanger 的危险度分配，立直宣言牌的跨筋（相邻1、2的牌）加重
+type HeuristicTenpaiEstimator struct{}
+
+// EstimateOpponent 实现 TenpaiEstimator
+func (e HeuristicTenpaiEstimator) EstimateOpponent(view *PlayerView, opponent int) OpponentEstimate {
+	return OpponentEstimate{
+		Tenpai: e.tenpaiProbability(&view.Seats[opponent]),
+		Waits:  e.waits(view, opponent),
+	}
+}
+
+// callTenpai 按副露数的听牌概率，四副露必定听牌
+var callTenpai = [5]float64{0, 0.2, 0.45, 0.75, 1}
+
+func (HeuristicTenpaiEstimator) tenpaiProbability(seat *SeatView) float64 {
+	if seat.Riichi {
+		return 1
+	}
+	n := len(seat.River)
+	turn := min(max(float64(n-4)*0.05, 0), 0.6) // 门清第5巡起每巡约增加5%
+	p := 1 - (1-turn)*(1-callTenpai[min(len(seat.CallGroups), 4)])
+
+	// 中盘以后的动向：手切中张多为形变或听牌，连续摸切多为已听牌
+	if n >= 9 {
+		if last := seat.River[n-1]; last.FromHand && !IsYaochuhai(last.Tile.Tile) {
+			p += (1 - p) * 0.15
+		}
+		if !seat.River[n-1].FromHand && !seat.River[n-2].FromHand && !seat.River[n-3].FromHand {
+			p += (1 - p) * 0.1
+		}
+	}
+	return min(p, 1)
+}
+
+func (HeuristicTenpaiEstimator) waits(view *PlayerView, opponent int) [NBaseTiles]float64 {
+	visible := visibleCounts(view)
+	safe := newOpponentSafety(view, opponent)
+	riichiTile := -1
+	for _, r := range view.Seats[opponent].River {
+		if r.Riichi {
+			riichiTile = int(r.Tile.Tile)
+			break
+		}
+	}
+
+	var waits [NBaseTiles]float64
+	total := 0.0
+	for tile := BaseTile(0); tile < NBaseTiles; tile++ {
+		if visible[tile] >= 4 {
+			continue
+		}
+		info := safe.analyze(tile, &visible)
+		if info.Genbutsu {
+			continue
+		}
+		info.TempGenbutsu = false // 同巡振听只影响本巡，不影响听什么
+		w := info.danger(tile)
+		if riichiTile >= 0 && !IsTsuhai(tile) && int(tile)/9 == riichiTile/9 {
+			if d := int(tile) - riichiTile; d != 0 && d >= -2 && d <= 2 {
+				w *= 1.5
+			}
+		}
+		waits[tile] = w
+		total += w
+	}
+	if total > 0 {
+		for i := range waits {
+			waits[i] /= total
+		}
+	}
+	return waits
+}
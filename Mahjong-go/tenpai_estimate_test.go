@@ -0,0 +1,110 @@
+package mahjong
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHeuristicEstimateRiichi(t *testing.T) {
+	discards := []struct {
+		seat int
+		tile string
+	}{
+		{1, "1z"}, {2, "9p"}, {3, "2z"},
+		{1, "5m"}, {2, "3p"}, // 1家以5m立直，2家在立直后打了3p
+	}
+	table := dangerTable(t, "123p456s777z", discards, 3)
+	estimates := EstimateOpponents(NewPlayerView(table, 0), nil)
+
+	if estimates[0] != (OpponentEstimate{}) {
+		t.Fatalf("own seat should not be estimated: %+v", estimates[0])
+	}
+	riichi := estimates[1]
+	if riichi.Tenpai != 1 {
+		t.Fatalf("riichi player should be tenpai, got %v", riichi.Tenpai)
+	}
+	total := 0.0
+	for _, w := range riichi.Waits {
+		total += w
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("wait likelihoods should sum to 1, got %v", total)
+	}
+	for _, tile := range []BaseTile{_5m, _3p, _1z} {
+		if riichi.Waits[tile] != 0 {
+			t.Fatalf("genbutsu %s cannot be a wait: %v", BaseTileToString(tile), riichi.Waits[tile])
+		}
+	}
+	// 跨筋 4m 比 4p 危险，筋牌 2m 比 4p 安全，已见3张的 7z 最安全
+	if !(riichi.Waits[_4m] > riichi.Waits[_4p] && riichi.Waits[_4p] > riichi.Waits[_2m] && riichi.Waits[_2m] > riichi.Waits[_7z]) {
+		t.Fatalf("unexpected wait order 4m %v 4p %v 2m %v 7z %v",
+			riichi.Waits[_4m], riichi.Waits[_4p], riichi.Waits[_2m], riichi.Waits[_7z])
+	}
+	if estimates[2].Tenpai != 0 || estimates[3].Tenpai != 0 {
+		t.Fatalf("players with two discards should not be tenpai: %v %v", estimates[2].Tenpai, estimates[3].Tenpai)
+	}
+}
+
+func TestHeuristicTenpaiProbability(t *testing.T) {
+	seat := func(n, calls int, fromHand bool) *SeatView {
+		s := &SeatView{}
+		for i := 0; i < n; i++ {
+			s.River = append(s.River, RiverTileView{Tile: Tile{Tile: _5m}, Number: i, Remain: true, FromHand: fromHand || i < n-3})
+		}
+		for i := 0; i < calls; i++ {
+			s.CallGroups = append(s.CallGroups, CallGroup{Type: Koutsu, Tiles: []BaseTile{_1z, _1z, _1z}, IsOpen: true})
+		}
+		return s
+	}
+	var e HeuristicTenpaiEstimator
+	p := func(s *SeatView) float64 { return e.tenpaiProbability(s) }
+
+	if p(seat(3, 0, true)) != 0 {
+		t.Fatalf("an early closed hand should not be tenpai")
+	}
+	if !(p(seat(12, 0, true)) > p(seat(6, 0, true))) {
+		t.Fatalf("tenpai probability should grow with the turn")
+	}
+	if !(p(seat(6, 3, true)) > p(seat(6, 1, true)) && p(seat(6, 1, true)) > p(seat(6, 0, true))) {
+		t.Fatalf("tenpai probability should grow with calls")
+	}
+	if p(seat(6, 4, true)) != 1 {
+		t.Fatalf("four calls means tenpai")
+	}
+	// 第12巡：最后手切中张，或连续摸切，都比一直手切幺九更像听牌
+	tsumogiri := seat(12, 0, false)
+	yaochu := seat(12, 0, true)
+	yaochu.River[11].Tile.Tile = _1z
+	if !(p(tsumogiri) > p(yaochu) && p(seat(12, 0, true)) > p(yaochu)) {
+		t.Fatalf("late discards should raise tenpai probability: %v %v %v",
+			p(tsumogiri), p(seat(12, 0, true)), p(yaochu))
+	}
+}
+
+func TestCustomTenpaiEstimator(t *testing.T) {
+	discards := []struct {
+		seat int
+		tile string
+	}{
+		{1, "1z"}, {2, "9p"}, {3, "5s"},
+	}
+	table := dangerTable(t, "1m5p7z", discards, -1)
+	var called []int
+	estimator := TenpaiEstimatorFunc(func(view *PlayerView, opponent int) OpponentEstimate {
+		called = append(called, opponent)
+		return OpponentEstimate{Tenpai: 0.5}
+	})
+	estimates := EstimateOpponents(NewPlayerView(table, 0), estimator)
+	if len(called) != 3 || called[0] != 1 || called[2] != 3 {
+		t.Fatalf("estimator should be called for each opponent, got %v", called)
+	}
+	for _, d := range AnalyzeDanger(NewPlayerView(table, 0)) {
+		sum := 0.0
+		for _, s := range d.Opponents {
+			sum += s.Danger
+		}
+		if math.Abs(d.Risk(&estimates)-sum/2) > 1e-9 {
+			t.Fatalf("%s: risk %v, expected %v", BaseTileToString(d.Tile), d.Risk(&estimates), sum/2)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// 生成 encoding_v2_*.txt：用 C++ TrainingDataEncodingV2::TableEncoder 编码几局固定的对局
+//
+// 在仓库根目录编译运行（不需要 cmake）：
+//   g++ -std=c++17 -O1 -DFMT_HEADER_ONLY -IMahjong -IThirdParty/fmt/include \
+//     Mahjong-go/testdata/gen_encoding_v2.cpp Mahjong/*.cpp Mahjong/Encoding/TrainingDataEncodingV2.cpp -o gen_encoding_v2
+//   ./gen_encoding_v2 Mahjong-go/testdata
+//
+// 每个文件的格式：
+//   config <oya> <game_wind> <honba> <kyoutaku> <4个开局点数>
+//   yama <136张牌的编号>
+//   step <编码状态的哈希> <动作> <对应牌的编号...>   每次选择之前的状态与所做的选择
+//   final <编码状态的哈希>
+//   self|global <玩家> <下标>=<值>...                 结束时非零的项
+//   record <玩家> <行> <下标>=<值>...
+// 哈希为 FNV-1a 64，依次对每个玩家的 self info、global info、记录行数与各行按 int16 小端序计算
+
+#include <algorithm>
+#include <cstdio>
+#include <random>
+#include <stdexcept>
+#include <string>
+#include "Table.h"
+#include "Encoding/TrainingDataEncodingV2.h"
+
+using namespace mahjong;
+using namespace mahjong::TrainingDataEncoding::v2;
+
+struct fnv64 {
+	uint64_t h = 14695981039346656037ull;
+	void add(dtype v) {
+		for (int i = 0; i < 2; ++i) {
+			h ^= (uint8_t)((uint16_t)v >> (8 * i));
+			h *= 1099511628211ull;
+		}
+	}
+	void add_len(size_t n) {
+		for (int i = 0; i < 4; ++i) {
+			h ^= (uint8_t)(n >> (8 * i));
+			h *= 1099511628211ull;
+		}
+	}
+};
+
+static uint64_t encoder_hash(const TableEncoder& e) {
+	fnv64 f;
+	for (int p = 0; p < 4; ++p) {
+		for (auto v : e.self_infos[p]) f.add(v);
+		for (auto v : e.global_infos[p]) f.add(v);
+		f.add_len(e.records[p].size());
+		for (auto& r : e.records[p])
+			for (auto v : r) f.add(v);
+	}
+	return f.h;
+}
+
+template <typename T>
+static void print_sparse(FILE* out, const T& values) {
+	for (size_t i = 0; i < values.size(); ++i)
+		if (values[i] != 0) fprintf(out, " %zu=%d", i, values[i]);
+	fprintf(out, "\n");
+}
+
+// 动作写成名字，两边的枚举值不同
+static const char* action_name(BaseAction action) {
+	switch (action) {
+	case BaseAction::Pass: return "pass";
+	case BaseAction::Chi: return "chi";
+	case BaseAction::Pon: return "pon";
+	case BaseAction::Kan: return "kan";
+	case BaseAction::Ron: return "ron";
+	case BaseAction::ChanAnKan: return "chanankan";
+	case BaseAction::ChanKan: return "chankan";
+	case BaseAction::AnKan: return "ankan";
+	case BaseAction::KaKan: return "kakan";
+	case BaseAction::Discard: return "discard";
+	case BaseAction::Riichi: return "riichi";
+	case BaseAction::Tsumo: return "tsumo";
+	case BaseAction::Kyushukyuhai: return "kyushukyuhai";
+	}
+	throw std::runtime_error("bad action");
+}
+
+template <typename A>
+static void print_action(FILE* out, const A& a) {
+	fprintf(out, " %s", action_name(a.action));
+	for (auto t : a.correspond_tiles) fprintf(out, " %d", t->id);
+	fprintf(out, "\n");
+}
+
+// shanten 一般形向听数的简单搜索，只用于挑选弃牌
+static int shanten_search(int* c, int i, int need, int mentsu, int taatsu, bool head) {
+	while (i < 34 && c[i] == 0) ++i;
+	if (i == 34)
+		return 2 * need - 2 * mentsu - std::min(taatsu, need - mentsu) - (head ? 1 : 0);
+	int best = shanten_search(c, i + 1, need, mentsu, taatsu, head);
+	bool seq = i < 27 && i % 9 < 7;
+	if (c[i] >= 3) { c[i] -= 3; best = std::min(best, shanten_search(c, i, need, mentsu + 1, taatsu, head)); c[i] += 3; }
+	if (seq && c[i + 1] && c[i + 2]) { c[i]--; c[i + 1]--; c[i + 2]--; best = std::min(best, shanten_search(c, i, need, mentsu + 1, taatsu, head)); c[i]++; c[i + 1]++; c[i + 2]++; }
+	if (c[i] >= 2) {
+		c[i] -= 2;
+		if (!head) best = std::min(best, shanten_search(c, i, need, mentsu, taatsu, true));
+		best = std::min(best, shanten_search(c, i, need, mentsu, taatsu + 1, head));
+		c[i] += 2;
+	}
+	if (i < 27 && i % 9 < 8 && c[i + 1]) { c[i]--; c[i + 1]--; best = std::min(best, shanten_search(c, i, need, mentsu, taatsu + 1, head)); c[i]++; c[i + 1]++; }
+	if (seq && c[i + 2]) { c[i]--; c[i + 2]--; best = std::min(best, shanten_search(c, i, need, mentsu, taatsu + 1, head)); c[i]++; c[i + 2]++; }
+	return best;
+}
+
+static int shanten_after_discard(const Player& player, const Tile* discard) {
+	int c[34] = { 0 };
+	for (auto t : player.hand)
+		if (t != discard) c[t->tile]++;
+	return shanten_search(c, 0, 4 - (int)player.call_groups.size(), 0, 0, false);
+}
+
+// all_copies 手中同种同赤的牌都在 tiles 中
+// Go 版对同种的牌只给出一个选项，只选这样的牌，保证两边给出的是同一张
+static bool all_copies(const Player& player, const std::vector<Tile*>& tiles) {
+	for (auto t : tiles) {
+		int n = 0, m = 0;
+		for (auto h : player.hand)
+			if (h->tile == t->tile && h->red_dora == t->red_dora) ++n;
+		for (auto u : tiles)
+			if (u->tile == t->tile && u->red_dora == t->red_dora) ++m;
+		if (n != m) return false;
+	}
+	return true;
+}
+
+// choose 带偏向的随机策略：和牌、立直、杠优先，偶尔鸣牌，弃牌取向听数最小者，尽量覆盖编码的各个分支
+static int choose_self(const Table& table, const std::vector<SelfAction>& actions, std::mt19937& rng) {
+	const Player& player = table.players[table.who_make_selection()];
+	std::vector<int> best, any;
+	int best_shanten = 100;
+	for (int i = 0; i < (int)actions.size(); ++i) {
+		switch (actions[i].action) {
+		case BaseAction::Tsumo:
+		case BaseAction::AnKan:
+		case BaseAction::KaKan:
+			return i;
+		case BaseAction::Riichi:
+			if (all_copies(player, actions[i].correspond_tiles)) return i;
+			break;
+		case BaseAction::Discard: {
+			any.push_back(i);
+			if (!all_copies(player, actions[i].correspond_tiles)) break;
+			int s = shanten_after_discard(player, actions[i].correspond_tiles[0]);
+			if (s < best_shanten) best.clear(), best_shanten = s;
+			if (s == best_shanten) best.push_back(i);
+			break;
+		}
+		default:
+			break;
+		}
+	}
+	if (best.empty())
+		return any[rng() % any.size()];
+	return best[rng() % best.size()];
+}
+
+static int choose_response(const Table& table, const std::vector<ResponseAction>& actions, std::mt19937& rng) {
+	const Player& player = table.players[table.who_make_selection()];
+	for (int i = 0; i < (int)actions.size(); ++i) {
+		auto a = actions[i].action;
+		if (a == BaseAction::Ron || a == BaseAction::ChanKan || a == BaseAction::ChanAnKan || a == BaseAction::Kan)
+			return i;
+		if ((a == BaseAction::Chi || a == BaseAction::Pon) && all_copies(player, actions[i].correspond_tiles) && rng() % 6 == 0)
+			return i;
+	}
+	for (int i = 0; i < (int)actions.size(); ++i)
+		if (actions[i].action == BaseAction::Pass) return i;
+	return 0;
+}
+
+static void play(const char* path, unsigned seed, int oya, int game_wind, int honba, int kyoutaku, std::vector<int> scores) {
+	std::mt19937 rng(seed);
+	std::vector<int> yama(N_TILES);
+	for (int i = 0; i < N_TILES; ++i) yama[i] = i;
+	std::shuffle(yama.begin(), yama.end(), rng);
+
+	FILE* out = fopen(path, "w");
+	fprintf(out, "config %d %d %d %d %d %d %d %d\n", oya, game_wind, honba, kyoutaku, scores[0], scores[1], scores[2], scores[3]);
+	fprintf(out, "yama");
+	for (int id : yama) fprintf(out, " %d", id);
+	fprintf(out, "\n");
+
+	Table table;
+	table.game_init_with_config(yama, scores, kyoutaku, honba, game_wind, oya);
+	TableEncoder e(&table);
+	e.init();
+	while (!table.is_over()) {
+		e.update();
+		fprintf(out, "step %016llx", (unsigned long long)encoder_hash(e));
+		if (table.is_self_acting()) {
+			auto actions = table.get_self_actions();
+			int i = choose_self(table, actions, rng);
+			print_action(out, actions[i]);
+			table.make_selection(i);
+		} else {
+			auto actions = table.get_response_actions();
+			int i = choose_response(table, actions, rng);
+			print_action(out, actions[i]);
+			table.make_selection(i);
+		}
+	}
+	e.update();
+	fprintf(out, "final %016llx\n", (unsigned long long)encoder_hash(e));
+	for (int p = 0; p < 4; ++p) {
+		fprintf(out, "self %d", p);
+		print_sparse(out, e.self_infos[p]);
+		fprintf(out, "global %d", p);
+		print_sparse(out, e.global_infos[p]);
+		for (size_t r = 0; r < e.records[p].size(); ++r) {
+			fprintf(out, "record %d %zu", p, r);
+			print_sparse(out, e.records[p][r]);
+		}
+	}
+	fclose(out);
+}
+
+// 种子选为 Go 版能重放、合起来覆盖吃碰杠、立直、荣和、自摸、岭上开花与流局的对局，开局点数各不相同用于检查点数的顺序
+int main(int argc, char** argv) {
+	std::string dir = argc > 1 ? argv[1] : ".";
+	play((dir + "/encoding_v2_1.txt").c_str(), 11, 3, 0, 2, 1, { 32000, 18000, 27000, 23000 });
+	play((dir + "/encoding_v2_2.txt").c_str(), 92, 0, 1, 2, 0, { 41000, 9000, 30000, 20000 });
+	play((dir + "/encoding_v2_3.txt").c_str(), 212, 0, 1, 2, 0, { 21000, 36000, 15000, 28000 });
+	play((dir + "/encoding_v2_4.txt").c_str(), 1321, 1, 0, 1, 1, { 25000, 30000, 20000, 24000 });
+	play((dir + "/encoding_v2_5.txt").c_str(), 1545, 1, 0, 0, 1, { 12000, 26000, 35000, 27000 });
+	return 0;
+}
@@ -67,6 +67,25 @@ type Tile struct {
 	ID      int      // 牌的唯一ID
 }
 
+// physicalKey 区分牌种与是否为赤宝牌，同种的非赤牌视为相同
+func (t *Tile) physicalKey() int {
+	if t.RedDora {
+		return int(t.Tile)*2 + 1
+	}
+	return int(t.Tile) * 2
+}
+
+// CountRedDora 统计牌中赤宝牌的张数
+func CountRedDora(tiles []*Tile) int {
+	n := 0
+	for _, t := range tiles {
+		if t.RedDora {
+			n++
+		}
+	}
+	return n
+}
+
 // String 返回牌的字符串表示
 func (t *Tile) String() string {
 	number := t.Tile%9 + 1
@@ -185,9 +204,9 @@ func IsShuntsu(tiles []BaseTile) bool {
 		return false
 	}
 
-	// 检查顺子的最后一张不能是8m,9m,8p,9p,8s,9s,任何z
-	if sorted[2] >= _1z || sorted[2] == _8m || sorted[2] == _9m ||
-		sorted[2] == _8p || sorted[2] == _9p || sorted[2] == _8s || sorted[2] == _9s {
+	// 字牌不能组成顺子，首张为8或9时会跨越花色（如 8m9m1p）
+	if sorted[2] >= _1z || sorted[0] == _8m || sorted[0] == _9m ||
+		sorted[0] == _8p || sorted[0] == _9p || sorted[0] == _8s || sorted[0] == _9s {
 		return false
 	}
 
@@ -0,0 +1,165 @@
+package mahjong
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// VecObsSize 向量化环境中每个观测的长度：当前玩家的 SelfInfo 后接 GlobalInfo
+const VecObsSize = NSelfInfoRows*NBaseTiles + NGlobalInfo
+
+// VecEnv 同时运行 N 局互相独立的对局
+//
+// 每次 Step 为每一局的当前玩家执行一个动作，结果写入预先分配的扁平缓冲区：
+// 第 i 局的观测为 Obs[i*VecObsSize:(i+1)*VecObsSize]，掩码与收益同理。
+// 结束的对局会记录收益与 EpisodeInfo 后自动开始新的一局，此时观测已经是新一局的。
+type VecEnv struct {
+	Envs []*MahjongEnv
+
+	// Workers 并行处理的 goroutine 数，不大于1时在调用者的 goroutine 中依次处理
+	Workers int
+
+	Obs     []int16       // 各局当前玩家的观测，长度 N*VecObsSize
+	Masks   []bool        // 各局当前玩家的合法动作，长度 N*NActionSpace
+	Players []int         // 各局当前需要行动的玩家
+	Rewards []float64     // 上一步结束的对局中四家的收益，长度 N*NPlayers，未结束时为0
+	Dones   []bool        // 上一步各局是否结束
+	Infos   []EpisodeInfo // 上一步结束的对局的信息
+
+	errs []error
+}
+
+// NewVecEnv 创建 n 局的向量化环境，每局的随机数种子由 seed 派生
+func NewVecEnv(n int, seed int64) *VecEnv {
+	rng := rand.New(rand.NewSource(seed))
+	v := &VecEnv{
+		Envs:    make([]*MahjongEnv, n),
+		Obs:     make([]int16, n*VecObsSize),
+		Masks:   make([]bool, n*NActionSpace),
+		Players: make([]int, n),
+		Rewards: make([]float64, n*NPlayers),
+		Dones:   make([]bool, n),
+		Infos:   make([]EpisodeInfo, n),
+		errs:    make([]error, n),
+	}
+	for i := range v.Envs {
+		v.Envs[i] = NewMahjongEnv(rng.Int63())
+		v.Envs[i].Encoding = true
+	}
+	return v
+}
+
+// Len 返回对局数
+func (v *VecEnv) Len() int {
+	return len(v.Envs)
+}
+
+// ObsAt 返回第 i 局的观测
+func (v *VecEnv) ObsAt(i int) []int16 {
+	return v.Obs[i*VecObsSize : (i+1)*VecObsSize]
+}
+
+// MaskAt 返回第 i 局的合法动作掩码
+func (v *VecEnv) MaskAt(i int) []bool {
+	return v.Masks[i*NActionSpace : (i+1)*NActionSpace]
+}
+
+// RewardsAt 返回第 i 局上一步的四家收益
+func (v *VecEnv) RewardsAt(i int) []float64 {
+	return v.Rewards[i*NPlayers : (i+1)*NPlayers]
+}
+
+// Reset 重新开始所有对局
+func (v *VecEnv) Reset() {
+	v.run(func(i int) error {
+		v.resetEnv(i)
+		v.Dones[i] = false
+		clear(v.RewardsAt(i))
+		v.Infos[i] = EpisodeInfo{}
+		v.write(i)
+		return nil
+	})
+}
+
+// Step 为每一局的当前玩家执行 actions[i]
+// 某一局的动作不合法时该局保持不变，返回遇到的第一个错误
+func (v *VecEnv) Step(actions []int) error {
+	if len(actions) != len(v.Envs) {
+		return fmt.Errorf("expected %d actions, got %d", len(v.Envs), len(actions))
+	}
+	return v.run(func(i int) error {
+		env := v.Envs[i]
+		v.Dones[i] = false
+		clear(v.RewardsAt(i))
+		if err := env.Step(v.Players[i], actions[i]); err != nil {
+			return fmt.Errorf("env %d: %w", i, err)
+		}
+		if env.IsOver() {
+			v.Dones[i] = true
+			payoffs := env.GetPayoffs()
+			copy(v.RewardsAt(i), payoffs[:])
+			v.Infos[i] = env.Info()
+			v.resetEnv(i)
+		}
+		v.write(i)
+		return nil
+	})
+}
+
+// resetEnv 开始新的一局，跳过开局即结束的对局
+func (v *VecEnv) resetEnv(i int) {
+	env := v.Envs[i]
+	env.Reset()
+	for env.IsOver() {
+		env.Reset()
+	}
+}
+
+// write 将第 i 局当前玩家的观测与掩码写入缓冲区
+func (v *VecEnv) write(i int) {
+	env := v.Envs[i]
+	player := env.CurrentPlayer()
+	v.Players[i] = player
+
+	obs := v.ObsAt(i)
+	n := copy(obs, env.Encoder.GetSelfInfo(player)[:])
+	copy(obs[n:], env.Encoder.GetGlobalInfo(player)[:])
+
+	mask := env.GetValidActionMask()
+	copy(v.MaskAt(i), mask[:])
+}
+
+// run 对每一局执行 f，按 Workers 决定是否并行
+func (v *VecEnv) run(f func(i int) error) error {
+	n := len(v.Envs)
+	workers := v.Workers
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			v.errs[i] = f(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		chunk := (n + workers - 1) / workers
+		for start := 0; start < n; start += chunk {
+			end := min(start+chunk, n)
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				for i := start; i < end; i++ {
+					v.errs[i] = f(i)
+				}
+			}(start, end)
+		}
+		wg.Wait()
+	}
+	for _, err := range v.errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
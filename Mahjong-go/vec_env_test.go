@@ -0,0 +1,114 @@
+package mahjong
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// randomVecActions 为每一局在掩码中随机选择动作
+func randomVecActions(v *VecEnv, rng *rand.Rand, actions []int) {
+	for i := range actions {
+		var legal []int
+		for index, ok := range v.MaskAt(i) {
+			if ok {
+				legal = append(legal, index)
+			}
+		}
+		actions[i] = legal[rng.Intn(len(legal))]
+	}
+}
+
+func TestVecEnvAutoReset(t *testing.T) {
+	v := NewVecEnv(4, 1)
+	v.Reset()
+	rng := rand.New(rand.NewSource(1))
+	actions := make([]int, v.Len())
+	finished := 0
+	for step := 0; step < 600; step++ {
+		randomVecActions(v, rng, actions)
+		if err := v.Step(actions); err != nil {
+			t.Fatal(err)
+		}
+		for i, env := range v.Envs {
+			if env.IsOver() {
+				t.Fatalf("env %d should have been reset", i)
+			}
+			player := env.CurrentPlayer()
+			if v.Players[i] != player {
+				t.Fatalf("env %d: player %d, expected %d", i, v.Players[i], player)
+			}
+			if !slices.Equal(v.ObsAt(i)[:NSelfInfoRows*NBaseTiles], env.Encoder.GetSelfInfo(player)[:]) {
+				t.Fatalf("env %d: observation does not match the encoder", i)
+			}
+			mask := env.GetValidActionMask()
+			if !slices.Equal(v.MaskAt(i), mask[:]) {
+				t.Fatalf("env %d: mask does not match the env", i)
+			}
+			if v.Dones[i] {
+				finished++
+				if v.Infos[i].Result == nil || v.Infos[i].Episode+1 != env.Info().Episode {
+					t.Fatalf("env %d: finished episode info missing", i)
+				}
+			} else if slices.ContainsFunc(v.RewardsAt(i), func(r float64) bool { return r != 0 }) {
+				t.Fatalf("env %d: rewards should be zero before the game ends", i)
+			}
+		}
+	}
+	if finished == 0 {
+		t.Fatalf("expected some games to finish")
+	}
+}
+
+func TestVecEnvDeterministic(t *testing.T) {
+	run := func() []int16 {
+		v := NewVecEnv(3, 7)
+		v.Reset()
+		rng := rand.New(rand.NewSource(7))
+		actions := make([]int, v.Len())
+		for step := 0; step < 300; step++ {
+			randomVecActions(v, rng, actions)
+			if err := v.Step(actions); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return slices.Clone(v.Obs)
+	}
+	if !slices.Equal(run(), run()) {
+		t.Fatalf("the same seed and actions should give the same observations")
+	}
+}
+
+func TestVecEnvStepErrors(t *testing.T) {
+	v := NewVecEnv(2, 1)
+	v.Reset()
+	if err := v.Step([]int{0}); err == nil {
+		t.Fatalf("wrong number of actions should be rejected")
+	}
+	if err := v.Step([]int{ActionRon, ActionRon}); err == nil {
+		t.Fatalf("invalid actions should be rejected")
+	}
+}
+
+// BenchmarkVecEnvStep 64局随机对局，报告每秒的决定数（每一局每步为一个决定，含编码与自动重置）
+func BenchmarkVecEnvStep(b *testing.B) {
+	for _, workers := range slices.Compact([]int{1, runtime.GOMAXPROCS(0)}) {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			v := NewVecEnv(64, 1)
+			v.Workers = workers
+			v.Reset()
+			rng := rand.New(rand.NewSource(1))
+			actions := make([]int, v.Len())
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				randomVecActions(v, rng, actions)
+				if err := v.Step(actions); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*v.Len())/b.Elapsed().Seconds(), "decisions/s")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package mahjong
+
+import "sort"
+
+// WaitValue 一张听牌的和牌价值
+type WaitValue struct {
+	Tile     BaseTile            // 和牌
+	Ron      *ScoreCounterResult // 荣和的结果（役、番、符、点数），无役时为 nil
+	Tsumo    *ScoreCounterResult // 自摸的结果，无役时为 nil
+	Furiten  bool                // 振听，不能荣和
+	CanRon   bool                // 能否荣和（有役且不振听）
+	CanTsumo bool                // 能否自摸（有役）
+}
+
+// PreviewWaits 计算听牌玩家每张听牌的荣和与自摸价值，按和牌从小到大排列
+// 手牌须为打牌后的 3n+1 张，未听牌时返回 nil
+// 宝牌、立直、一发、自风与场风按当前状态计算；里宝牌未知，不计入；和牌按非赤牌计算
+// assumeRiichi 为 true 时，门清未立直的玩家按立直后计算，用于比较立直与默听
+func (p *Player) PreviewWaits(table *Table, assumeRiichi bool) []WaitValue {
+	if len(p.Hand)%3 != 1 || len(p.AtariTiles) == 0 {
+		return nil
+	}
+
+	// 在副本上计算，不改变牌桌与玩家的状态
+	var preview *Table
+	if table != nil {
+		copied := *table
+		copied.UraDoraIndicator = nil
+		copied.LastAction = Discard // 不计岭上开花、抢杠
+		copied.KanDiscard = false
+		preview = &copied
+	}
+	player := *p
+	player.Hand = append([]*Tile(nil), p.Hand...)
+	if assumeRiichi && !p.IsRiichi() && p.IsMenzen() {
+		player.Riichi = true
+	}
+
+	atari := append([]BaseTile(nil), p.AtariTiles...)
+	sort.Slice(atari, func(i, j int) bool { return atari[i] < atari[j] })
+	values := make([]WaitValue, 0, len(atari))
+	for _, tile := range atari {
+		tiles := append(ConvertTilesToBaseTiles(player.Hand), tile)
+		sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+		sevenPairs := IsSevenPairPattern(tiles)
+		value := WaitValue{Tile: tile, Furiten: p.IsFuriten()}
+
+		// 荣和：手牌为 3n+1 张
+		value.Ron = (&ScoreCounter{}).CalculateScore(preview, &player, tiles, player.CallGroups, tile, sevenPairs)
+
+		// 自摸：和牌加入手牌
+		hand := player.Hand
+		player.Hand = append(hand, &Tile{Tile: tile, ID: -1})
+		value.Tsumo = (&ScoreCounter{}).CalculateScore(preview, &player, tiles, player.CallGroups, tile, sevenPairs)
+		player.Hand = hand
+
+		value.CanRon = value.Ron != nil && !value.Furiten
+		value.CanTsumo = value.Tsumo != nil
+		values = append(values, value)
+	}
+	return values
+}
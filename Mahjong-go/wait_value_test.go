@@ -0,0 +1,93 @@
+package mahjong
+
+import "testing"
+
+// newPreviewPlayer 创建一个已过第一巡的南家玩家，宝牌指示牌为 dora
+func newPreviewPlayer(t *testing.T, hand string, dora string) (*Table, *Player) {
+	t.Helper()
+	table := NewTable()
+	table.GameWind = East
+	indicators, err := ParseTiles(dora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.DoraIndicator = indicators
+	table.NActiveDora = len(indicators)
+	tiles, err := ParseTiles(hand)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPlayer(South, false)
+	p.Hand = tiles
+	p.FirstRound = false
+	p.UpdateAtariTiles()
+	return table, p
+}
+
+func TestPreviewWaitsDamaAndRiichi(t *testing.T) {
+	table, p := newPreviewPlayer(t, "234m567m345p67s55p", "4m")
+	dama := p.PreviewWaits(table, false)
+	if len(dama) != 2 || dama[0].Tile != _5s || dama[1].Tile != _8s {
+		t.Fatalf("expected waits on 5s and 8s, got %+v", dama)
+	}
+	for _, v := range dama {
+		if !v.CanRon || !v.CanTsumo || v.Furiten {
+			t.Fatalf("%s should be winnable: %+v", BaseTileToString(v.Tile), v)
+		}
+		// 平和、断幺、宝牌1
+		if v.Ron.Fan != 3 || v.Ron.Fu != 30 || v.Ron.RonScore != 3900 || hasYaku(v.Ron.Yakus, RiichiYaku) {
+			t.Fatalf("unexpected damaten ron on %s: %+v", BaseTileToString(v.Tile), v.Ron)
+		}
+		// 加上门清自摸，平和自摸20符
+		if v.Tsumo.Fan != 4 || v.Tsumo.Fu != 20 || !hasYaku(v.Tsumo.Yakus, Menzentsumo) {
+			t.Fatalf("unexpected damaten tsumo on %s: %+v", BaseTileToString(v.Tile), v.Tsumo)
+		}
+	}
+
+	riichi := p.PreviewWaits(table, true)
+	if riichi[1].Ron.Fan != 4 || riichi[1].Ron.RonScore != 7700 || !hasYaku(riichi[1].Ron.Yakus, RiichiYaku) {
+		t.Fatalf("unexpected riichi ron %+v", riichi[1].Ron)
+	}
+	if p.IsRiichi() {
+		t.Fatalf("previewing riichi should not change the player")
+	}
+}
+
+func TestPreviewWaitsFuritenNoYakuAndUra(t *testing.T) {
+	table, p := newPreviewPlayer(t, "234m567m345p67s55p", "1z")
+	p.FuritenRiver = true
+	for _, v := range p.PreviewWaits(table, false) {
+		if v.CanRon || !v.Furiten || v.Ron == nil || !v.CanTsumo {
+			t.Fatalf("furiten wait should be valued but not ronnable: %+v", v)
+		}
+	}
+
+	// 副露后无役
+	table, p = newPreviewPlayer(t, "234m567m67s55p", "1z")
+	p.Menzen = false
+	p.CallGroups = []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_1p, _1p, _1p}, IsOpen: true}}
+	for _, v := range p.PreviewWaits(table, true) {
+		if v.CanRon || v.CanTsumo || v.Ron != nil || v.Tsumo != nil {
+			t.Fatalf("open hand without yaku should not win: %+v", v)
+		}
+	}
+
+	// 立直后里宝牌未知，不计入
+	table, p = newPreviewPlayer(t, "234m567m345p67s55p", "1z")
+	p.Riichi = true
+	ura, _ := ParseTiles("4p")
+	table.UraDoraIndicator = ura
+	for _, v := range p.PreviewWaits(table, false) {
+		if hasYaku(v.Ron.Yakus, UradoraYaku) || hasYaku(v.Tsumo.Yakus, UradoraYaku) {
+			t.Fatalf("ura dora should not be previewed: %+v", v.Ron)
+		}
+	}
+	if len(table.UraDoraIndicator) != 1 {
+		t.Fatalf("previewing should not change the table")
+	}
+
+	_, p = newPreviewPlayer(t, "147m258p369s1234z", "1z")
+	if p.PreviewWaits(table, false) != nil {
+		t.Fatalf("a hand that is not tenpai has no wait values")
+	}
+}
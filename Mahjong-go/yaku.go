@@ -24,8 +24,8 @@ const (
 	Sanputsu                     // 三副露
 
 	// 役牌（1番）
-	Yakuhai           // 役牌（通用）
-	YakuhaiWind       // 役牌（风）
+	Yakuhai           // 役牌（场风）
+	YakuhaiWind       // 役牌（自风）
 	YakuhaiWhiteBoard // 役牌（白板）
 	YakuhaiGreenBoard // 役牌（绿板）
 	YakuhaiRedBoard   // 役牌（红板）
@@ -55,57 +55,109 @@ const (
 	Menzentsumo  // 门清自摸
 	Dabururiichi // 双立直
 
+	// 双倍役满（规则承认时计为2倍役满，否则按1倍计）
+	SuankouTanki // 四暗刻单骑
+	ChurenJunsei // 纯正九莲宝灯
+	Kokushi13    // 国士无双十三面
+
+	// 地方役（需在规则中启用，番数可配置）
+	Renhou        // 人和
+	Sanrenkou     // 三连刻
+	IsshokuSanjun // 一色三顺
+	Daisharin     // 大车轮
+	Shiisanpuutaa // 十三不塔
+	OpenRiichi    // 开立直
+	Tsubamegaeshi // 燕返
+	Kanburi       // 杠振
+
 	// 最大值
 	MaxYaku
 )
 
 // YakuInfo 存储役的信息
+// 副露后减一番（食い下がり）的役分别记录门清与副露时的番数，
+// 门清限定役的 FanOpen 为 0，表示副露时不成立
 type YakuInfo struct {
 	Name      string // 役名
-	Fan       int    // 番数
-	IsOpen    bool   // 是否可以鸣牌
+	FanClosed int    // 门清时的番数
+	FanOpen   int    // 副露时的番数（0表示副露时不成立）
 	IsYakuman bool   // 是否为役满
+	IsLocal   bool   // 是否为地方役（默认不启用）
 }
 
 // yakuInfoTable 役信息表
 var yakuInfoTable = map[Yaku]YakuInfo{
-	Tanyao:            {Name: "断幺", Fan: 1, IsOpen: true, IsYakuman: false},
-	Pinfu:             {Name: "平和", Fan: 1, IsOpen: false, IsYakuman: false},
-	Iipeikou:          {Name: "一对对", Fan: 1, IsOpen: false, IsYakuman: false},
-	Ryanpeikou:        {Name: "二对对", Fan: 3, IsOpen: false, IsYakuman: false},
-	Sanshokusequence:  {Name: "三色同顺", Fan: 2, IsOpen: true, IsYakuman: false},
-	Ittsu:             {Name: "一通贯", Fan: 2, IsOpen: true, IsYakuman: false},
-	Chanta:            {Name: "全带幺", Fan: 2, IsOpen: true, IsYakuman: false},
-	Honchanta:         {Name: "混全带幺", Fan: 2, IsOpen: true, IsYakuman: false},
-	Sanshokudoukou:    {Name: "三色同刻", Fan: 2, IsOpen: true, IsYakuman: false},
-	Toitoi:            {Name: "对对和", Fan: 2, IsOpen: true, IsYakuman: false},
-	Sanankou:          {Name: "三暗刻", Fan: 2, IsOpen: false, IsYakuman: false},
-	Sangantu:          {Name: "三杆子", Fan: 2, IsOpen: true, IsYakuman: false},
-	Sanputsu:          {Name: "三副露", Fan: 2, IsOpen: true, IsYakuman: false},
-	Yakuhai:           {Name: "役牌", Fan: 1, IsOpen: true, IsYakuman: false},
-	YakuhaiWind:       {Name: "役牌（风）", Fan: 1, IsOpen: true, IsYakuman: false},
-	YakuhaiWhiteBoard: {Name: "役牌（白板）", Fan: 1, IsOpen: true, IsYakuman: false},
-	YakuhaiGreenBoard: {Name: "役牌（绿板）", Fan: 1, IsOpen: true, IsYakuman: false},
-	YakuhaiRedBoard:   {Name: "役牌（红板）", Fan: 1, IsOpen: true, IsYakuman: false},
-	Honitsu:           {Name: "混一色", Fan: 3, IsOpen: true, IsYakuman: false},
-	Junchan:           {Name: "纯全带幺", Fan: 3, IsOpen: true, IsYakuman: false},
-	Chinitsu:          {Name: "清一色", Fan: 6, IsOpen: true, IsYakuman: false},
-	Kokushi:           {Name: "国士无双", Fan: 13, IsOpen: false, IsYakuman: true},
-	Suankou:           {Name: "四暗刻", Fan: 13, IsOpen: false, IsYakuman: true},
-	Daisangen:         {Name: "大三元", Fan: 13, IsOpen: true, IsYakuman: true},
-	Shosuushi:         {Name: "小四喜", Fan: 13, IsOpen: true, IsYakuman: true},
-	Daisuushi:         {Name: "大四喜", Fan: 13, IsOpen: true, IsYakuman: true},
-	Tsuisou:           {Name: "字一色", Fan: 13, IsOpen: true, IsYakuman: true},
-	Ryuuisou:          {Name: "绿一色", Fan: 13, IsOpen: true, IsYakuman: true},
-	Chinroutou:        {Name: "清老头", Fan: 13, IsOpen: true, IsYakuman: true},
-	Honroutou:         {Name: "混老头", Fan: 13, IsOpen: true, IsYakuman: true},
-	Chiitoitsu:        {Name: "七对子", Fan: 25, IsOpen: false, IsYakuman: true},
-	Kazoe:             {Name: "数役满", Fan: 13, IsOpen: true, IsYakuman: false},
-	Tenhou:            {Name: "天胡", Fan: 13, IsOpen: false, IsYakuman: true},
-	Chihou:            {Name: "地胡", Fan: 13, IsOpen: false, IsYakuman: true},
-	Churen:            {Name: "九莲宝灯", Fan: 13, IsOpen: false, IsYakuman: true},
-	Menzentsumo:       {Name: "门清自摸", Fan: 1, IsOpen: false, IsYakuman: false},
-	Dabururiichi:      {Name: "双立直", Fan: 2, IsOpen: false, IsYakuman: false},
+	Tanyao:            {Name: "断幺", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	Pinfu:             {Name: "平和", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	Iipeikou:          {Name: "一对对", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	Ryanpeikou:        {Name: "二对对", FanClosed: 3, FanOpen: 0, IsYakuman: false},
+	Sanshokusequence:  {Name: "三色同顺", FanClosed: 2, FanOpen: 1, IsYakuman: false},
+	Ittsu:             {Name: "一通贯", FanClosed: 2, FanOpen: 1, IsYakuman: false},
+	Chanta:            {Name: "全带幺", FanClosed: 2, FanOpen: 1, IsYakuman: false},
+	Honchanta:         {Name: "混全带幺", FanClosed: 2, FanOpen: 1, IsYakuman: false},
+	Sanshokudoukou:    {Name: "三色同刻", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Toitoi:            {Name: "对对和", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Sanankou:          {Name: "三暗刻", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Sangantu:          {Name: "三杆子", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Sanputsu:          {Name: "三副露", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Yakuhai:           {Name: "役牌（场风）", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	YakuhaiWind:       {Name: "役牌（自风）", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	YakuhaiWhiteBoard: {Name: "役牌（白板）", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	YakuhaiGreenBoard: {Name: "役牌（绿板）", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	YakuhaiRedBoard:   {Name: "役牌（红板）", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	Honitsu:           {Name: "混一色", FanClosed: 3, FanOpen: 2, IsYakuman: false},
+	Junchan:           {Name: "纯全带幺", FanClosed: 3, FanOpen: 2, IsYakuman: false},
+	Chinitsu:          {Name: "清一色", FanClosed: 6, FanOpen: 5, IsYakuman: false},
+	Kokushi:           {Name: "国士无双", FanClosed: 13, FanOpen: 0, IsYakuman: true},
+	Suankou:           {Name: "四暗刻", FanClosed: 13, FanOpen: 0, IsYakuman: true},
+	Daisangen:         {Name: "大三元", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Shosuushi:         {Name: "小四喜", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Daisuushi:         {Name: "大四喜", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Tsuisou:           {Name: "字一色", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Ryuuisou:          {Name: "绿一色", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Chinroutou:        {Name: "清老头", FanClosed: 13, FanOpen: 13, IsYakuman: true},
+	Honroutou:         {Name: "混老头", FanClosed: 2, FanOpen: 2, IsYakuman: false},
+	Chiitoitsu:        {Name: "七对子", FanClosed: 2, FanOpen: 0, IsYakuman: false},
+	Kazoe:             {Name: "数役满", FanClosed: 13, FanOpen: 13, IsYakuman: false},
+	Tenhou:            {Name: "天胡", FanClosed: 13, FanOpen: 0, IsYakuman: true},
+	Chihou:            {Name: "地胡", FanClosed: 13, FanOpen: 0, IsYakuman: true},
+	Churen:            {Name: "九莲宝灯", FanClosed: 13, FanOpen: 0, IsYakuman: true},
+	Menzentsumo:       {Name: "门清自摸", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	Dabururiichi:      {Name: "双立直", FanClosed: 2, FanOpen: 0, IsYakuman: false},
+	SuankouTanki:      {Name: "四暗刻单骑", FanClosed: 26, FanOpen: 0, IsYakuman: true},
+	ChurenJunsei:      {Name: "纯正九莲宝灯", FanClosed: 26, FanOpen: 0, IsYakuman: true},
+	Kokushi13:         {Name: "国士无双十三面", FanClosed: 26, FanOpen: 0, IsYakuman: true},
+	Renhou:            {Name: "人和", FanClosed: 5, FanOpen: 0, IsLocal: true},
+	Sanrenkou:         {Name: "三连刻", FanClosed: 2, FanOpen: 2, IsLocal: true},
+	IsshokuSanjun:     {Name: "一色三顺", FanClosed: 3, FanOpen: 2, IsLocal: true},
+	Daisharin:         {Name: "大车轮", FanClosed: 13, FanOpen: 0, IsYakuman: true, IsLocal: true},
+	Shiisanpuutaa:     {Name: "十三不塔", FanClosed: 13, FanOpen: 0, IsYakuman: true, IsLocal: true},
+	OpenRiichi:        {Name: "开立直", FanClosed: 1, FanOpen: 0, IsLocal: true},
+	Tsubamegaeshi:     {Name: "燕返", FanClosed: 1, FanOpen: 1, IsLocal: true},
+	Kanburi:           {Name: "杠振", FanClosed: 1, FanOpen: 1, IsLocal: true},
+	RiichiYaku:        {Name: "立直", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	IppatsuYaku:       {Name: "一发", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	DoraYaku:          {Name: "宝牌", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+	UradoraYaku:       {Name: "里宝牌", FanClosed: 1, FanOpen: 0, IsYakuman: false},
+	AkadoraYaku:       {Name: "赤宝牌", FanClosed: 1, FanOpen: 1, IsYakuman: false},
+}
+
+// statusYakus 由玩家状态决定的役（编号不在 0..MaxYaku 内，单独判定）
+var statusYakus = []Yaku{RiichiYaku, IppatsuYaku}
+
+// supersededYakus 记录上位役与被其取代的下位役，二者同时成立时只计上位役
+var supersededYakus = map[Yaku][]Yaku{
+	Junchan:    {Chanta, Honchanta},
+	Honchanta:  {Chanta},
+	Honroutou:  {Chanta, Honchanta},
+	Ryanpeikou: {Iipeikou},
+	Chinitsu:   {Honitsu},
+
+	SuankouTanki: {Suankou},
+	ChurenJunsei: {Churen},
+	Kokushi13:    {Kokushi},
+
+	IsshokuSanjun: {Iipeikou},
 }
 
 // YakuToString 将Yaku转换为字符串
@@ -113,21 +165,40 @@ func YakuToString(yaku Yaku) string {
 	if info, ok := yakuInfoTable[yaku]; ok {
 		return info.Name
 	}
+	if name, ok := customYakuName(yaku); ok {
+		return name
+	}
 	return fmt.Sprintf("未知役%d", yaku)
 }
 
-// GetFanCount 获取役的番数
+// GetFanCount 获取役在门清时的番数
 func GetFanCount(yaku Yaku) int {
 	if info, ok := yakuInfoTable[yaku]; ok {
-		return info.Fan
+		return info.FanClosed
 	}
 	return 0
 }
 
+// GetFanCountOpen 获取役在副露时的番数，门清限定役返回0
+func GetFanCountOpen(yaku Yaku) int {
+	if info, ok := yakuInfoTable[yaku]; ok {
+		return info.FanOpen
+	}
+	return 0
+}
+
+// YakuFan 根据门清状态获取役的番数
+func YakuFan(yaku Yaku, menzen bool) int {
+	if menzen {
+		return GetFanCount(yaku)
+	}
+	return GetFanCountOpen(yaku)
+}
+
 // CanAgari 判断是否有役可以胡牌
-func CanAgari(yakus []Yaku) bool {
+func CanAgari(yakus []Yaku, menzen bool) bool {
 	for _, yaku := range yakus {
-		if GetFanCount(yaku) > 0 {
+		if YakuFan(yaku, menzen) > 0 {
 			return true
 		}
 	}
@@ -142,26 +213,100 @@ func IsYakuman(yaku Yaku) bool {
 	return false
 }
 
-// CanOpenYaku 判断役是否可以鸣牌成立
-func CanOpenYaku(yaku Yaku) bool {
+// IsLocalYaku 判断是否为地方役
+func IsLocalYaku(yaku Yaku) bool {
 	if info, ok := yakuInfoTable[yaku]; ok {
-		return info.IsOpen
+		return info.IsLocal
 	}
 	return false
 }
 
+// IsDoubleYakuman 判断役是否可按双倍役满计算（大四喜与各双倍役满型）
+func IsDoubleYakuman(yaku Yaku) bool {
+	return yaku == Daisuushi || (IsYakuman(yaku) && GetFanCount(yaku) >= 26)
+}
+
+// YakumanMultiple 获取役满的倍数，非役满返回0
+// 双倍役满仅在规则承认时计为2倍
+func YakumanMultiple(yaku Yaku, rule *GameRule) int {
+	if rule == nil {
+		rule = DefaultGameRule()
+	}
+	if IsLocalYaku(yaku) {
+		// 地方役按配置的番数决定是否为役满
+		return rule.YakuFan(yaku, true) / 13
+	}
+	if !IsYakuman(yaku) {
+		return 0
+	}
+	if rule.DoubleYakuman && IsDoubleYakuman(yaku) {
+		return 2
+	}
+	return 1
+}
+
+// CountYakuman 计算一组役的役满倍数
+// 规则不允许复合役满时只取其中最大的一个
+func CountYakuman(yakus []Yaku, rule *GameRule) int {
+	if rule == nil {
+		rule = DefaultGameRule()
+	}
+	total := 0
+	maxSingle := 0
+	for _, yaku := range yakus {
+		m := YakumanMultiple(yaku, rule)
+		total += m
+		if m > maxSingle {
+			maxSingle = m
+		}
+	}
+	if !rule.MultipleYakuman {
+		return maxSingle
+	}
+	return total
+}
+
+// CanOpenYaku 判断役是否可以鸣牌成立
+func CanOpenYaku(yaku Yaku) bool {
+	return GetFanCountOpen(yaku) > 0
+}
+
+// RemoveSupersededYakus 移除被上位役取代的役（如纯全带幺成立时不再计全带幺）
+func RemoveSupersededYakus(yakus []Yaku) []Yaku {
+	removed := make(map[Yaku]bool)
+	for _, yaku := range yakus {
+		for _, lower := range supersededYakus[yaku] {
+			removed[lower] = true
+		}
+	}
+	result := make([]Yaku, 0, len(yakus))
+	for _, yaku := range yakus {
+		if !removed[yaku] {
+			result = append(result, yaku)
+		}
+	}
+	return result
+}
+
 // RiichiYaku 是立直状态标记
 const RiichiYaku Yaku = 0xFF
 
 // IppatsuYaku 是一发状态标记
 const IppatsuYaku Yaku = 0xFE
 
+// DoraYaku、UradoraYaku 与 AkadoraYaku 记录宝牌、里宝牌、赤宝牌的番数，不算作役
+const (
+	DoraYaku    Yaku = 0xFD
+	UradoraYaku Yaku = 0xFC
+	AkadoraYaku Yaku = 0xFB
+)
+
 // GetRiichiInfo 获取立直的役信息
 func GetRiichiInfo() YakuInfo {
 	return YakuInfo{
 		Name:      "立直",
-		Fan:       1,
-		IsOpen:    false,
+		FanClosed: 1,
+		FanOpen:   0,
 		IsYakuman: false,
 	}
 }
@@ -170,8 +315,8 @@ func GetRiichiInfo() YakuInfo {
 func GetDoraInfo() YakuInfo {
 	return YakuInfo{
 		Name:      "宝牌",
-		Fan:       1,
-		IsOpen:    true,
+		FanClosed: 1,
+		FanOpen:   1,
 		IsYakuman: false,
 	}
 }
@@ -180,17 +325,35 @@ func GetDoraInfo() YakuInfo {
 func GetUradoraInfo() YakuInfo {
 	return YakuInfo{
 		Name:      "里宝牌",
-		Fan:       1,
-		IsOpen:    false,
+		FanClosed: 1,
+		FanOpen:   0,
 		IsYakuman: false,
 	}
 }
 
-// TotalFan 计算总番数
-func TotalFan(yakus []Yaku) int {
-	total := 0
+// TotalFan 根据门清状态计算总番数（默认规则）
+func TotalFan(yakus []Yaku, menzen bool) int {
+	return TotalFanWithRule(yakus, menzen, nil)
+}
+
+// TotalFanWithRule 根据门清状态与规则计算总番数
+// 役满成立时只计役满，每倍役满记为13番
+func TotalFanWithRule(yakus []Yaku, menzen bool, rule *GameRule) int {
+	if rule == nil {
+		rule = DefaultGameRule()
+	}
+	available := make([]Yaku, 0, len(yakus))
 	for _, yaku := range yakus {
-		total += GetFanCount(yaku)
+		if rule.YakuFan(yaku, menzen) > 0 {
+			available = append(available, yaku)
+		}
+	}
+	if n := CountYakuman(available, rule); n > 0 {
+		return 13 * n
+	}
+	total := 0
+	for _, yaku := range available {
+		total += rule.YakuFan(yaku, menzen)
 	}
 	return total
 }
@@ -0,0 +1,183 @@
+package mahjong
+
+import (
+	"sort"
+	"sync"
+)
+
+// customYakuBase 自定义役的起始编号，避开内置役与立直/一发等状态标记
+const customYakuBase Yaku = 0x200
+
+// YakuContext 是自定义役判定时看到的只读局面
+// 所有切片均为副本，判定函数修改它们不会影响计分
+type YakuContext struct {
+	Completed  CompletedTiles // 当前拆分（七对子时 Body 为7个对子，国士无双时为空）
+	CallGroups []CallGroup    // 副露
+	Tiles      []BaseTile     // 手中的牌（含和牌）
+	WinTile    BaseTile       // 和牌
+	WinOnHead  bool           // 和牌是否在雀头（单骑）
+	Tsumo      bool           // 是否为自摸
+	SevenPairs bool           // 是否为七对子形
+
+	// 场况
+	Menzen      bool       // 是否门清
+	Riichi      bool       // 是否立直
+	Ippatsu     bool       // 是否有一发
+	FirstRound  bool       // 是否为第一巡
+	Oya         bool       // 是否为庄家
+	SeatWind    Wind       // 自风
+	GameWind    Wind       // 场风
+	Dora        []BaseTile // 宝牌
+	RemainTiles int        // 牌山剩余张数（无牌桌时为-1）
+	LastAction  BaseAction // 牌桌上一个动作
+}
+
+// YakuChecker 自定义役的判定函数
+// 返回番数（<=0 表示不成立）与是否为役满；役满时番数按每13番一倍计，不足13番按一倍计
+type YakuChecker func(ctx *YakuContext) (fan int, yakuman bool)
+
+type customYaku struct {
+	name    string
+	checker YakuChecker
+}
+
+// yakuRegistry 自定义役注册表
+var yakuRegistry = struct {
+	sync.RWMutex
+	next  Yaku
+	yakus map[Yaku]customYaku
+}{next: customYakuBase, yakus: make(map[Yaku]customYaku)}
+
+// RegisterYaku 注册一个自定义役，返回分配的役编号
+// 注册后所有 ScoreCounter 都会在内置役之外判定该役
+func RegisterYaku(name string, checker YakuChecker) Yaku {
+	yakuRegistry.Lock()
+	defer yakuRegistry.Unlock()
+	id := yakuRegistry.next
+	yakuRegistry.next++
+	yakuRegistry.yakus[id] = customYaku{name: name, checker: checker}
+	return id
+}
+
+// UnregisterYaku 注销自定义役
+func UnregisterYaku(yaku Yaku) {
+	yakuRegistry.Lock()
+	defer yakuRegistry.Unlock()
+	delete(yakuRegistry.yakus, yaku)
+}
+
+// IsCustomYaku 判断是否为已注册的自定义役
+func IsCustomYaku(yaku Yaku) bool {
+	yakuRegistry.RLock()
+	defer yakuRegistry.RUnlock()
+	_, ok := yakuRegistry.yakus[yaku]
+	return ok
+}
+
+// CustomYakus 返回所有已注册的自定义役（按编号排序）
+func CustomYakus() []Yaku {
+	yakuRegistry.RLock()
+	defer yakuRegistry.RUnlock()
+	result := make([]Yaku, 0, len(yakuRegistry.yakus))
+	for id := range yakuRegistry.yakus {
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// customYakuName 获取自定义役的名称
+func customYakuName(yaku Yaku) (string, bool) {
+	yakuRegistry.RLock()
+	defer yakuRegistry.RUnlock()
+	cy, ok := yakuRegistry.yakus[yaku]
+	return cy.name, ok
+}
+
+// customYakuResult 自定义役的判定结果
+type customYakuResult struct {
+	yaku    Yaku
+	fan     int
+	yakuman int // 役满倍数，非役满为0
+}
+
+// checkCustomYakus 对当前变体判定所有自定义役
+func (s *ScoreCounter) checkCustomYakus(ct *CompletedTiles) []customYakuResult {
+	yakuRegistry.RLock()
+	if len(yakuRegistry.yakus) == 0 {
+		yakuRegistry.RUnlock()
+		return nil
+	}
+	ids := make([]Yaku, 0, len(yakuRegistry.yakus))
+	checkers := make(map[Yaku]YakuChecker, len(yakuRegistry.yakus))
+	for id, cy := range yakuRegistry.yakus {
+		ids = append(ids, id)
+		checkers[id] = cy.checker
+	}
+	yakuRegistry.RUnlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var results []customYakuResult
+	for _, id := range ids {
+		// 每个判定函数拿到独立的副本
+		fan, yakuman := checkers[id](s.yakuContext(ct))
+		if fan <= 0 {
+			continue
+		}
+		r := customYakuResult{yaku: id, fan: fan}
+		if yakuman {
+			r.yakuman = fan / 13
+			if r.yakuman == 0 {
+				r.yakuman = 1
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// yakuContext 构造当前变体的只读局面
+func (s *ScoreCounter) yakuContext(ct *CompletedTiles) *YakuContext {
+	ctx := &YakuContext{
+		Completed:   cloneCompletedTiles(ct),
+		CallGroups:  make([]CallGroup, len(s.CallGroups)),
+		Tiles:       append([]BaseTile(nil), s.Tiles...),
+		WinTile:     s.WinTile,
+		WinOnHead:   s.winOnHead,
+		Tsumo:       s.Tsumo,
+		SevenPairs:  s.IsSevenPair,
+		Menzen:      s.Player.IsMenzen(),
+		Riichi:      s.Player.IsRiichi(),
+		Ippatsu:     s.Player.Ippatsu,
+		FirstRound:  s.Player.FirstRound,
+		Oya:         s.Player.Oya,
+		SeatWind:    s.Player.Wind,
+		RemainTiles: -1,
+	}
+	for i, cg := range s.CallGroups {
+		cg.Tiles = append([]BaseTile(nil), cg.Tiles...)
+		ctx.CallGroups[i] = cg
+	}
+	if s.Table != nil {
+		ctx.GameWind = s.Table.GameWind
+		ctx.Dora = s.Table.GetDora()
+		ctx.RemainTiles = s.Table.GetRemainTile()
+		ctx.LastAction = s.Table.LastAction
+	}
+	return ctx
+}
+
+// cloneCompletedTiles 深拷贝拆分结果
+func cloneCompletedTiles(ct *CompletedTiles) CompletedTiles {
+	if ct == nil {
+		return CompletedTiles{}
+	}
+	clone := CompletedTiles{
+		Head: TileGroup{Type: ct.Head.Type, Tiles: append([]BaseTile(nil), ct.Head.Tiles...)},
+		Body: make([]TileGroup, len(ct.Body)),
+	}
+	for i, g := range ct.Body {
+		clone.Body[i] = TileGroup{Type: g.Type, Tiles: append([]BaseTile(nil), g.Tiles...)}
+	}
+	return clone
+}
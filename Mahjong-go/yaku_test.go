@@ -0,0 +1,472 @@
+package mahjong
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestYakuFan_Kuisagari(t *testing.T) {
+	cases := []struct {
+		yaku   Yaku
+		closed int
+		open   int
+	}{
+		{Tanyao, 1, 1},
+		{Pinfu, 1, 0},
+		{Iipeikou, 1, 0},
+		{Ittsu, 2, 1},
+		{Sanshokusequence, 2, 1},
+		{Chanta, 2, 1},
+		{Junchan, 3, 2},
+		{Honitsu, 3, 2},
+		{Chinitsu, 6, 5},
+		{Toitoi, 2, 2},
+		{Honroutou, 2, 2},
+		{Chiitoitsu, 2, 0},
+	}
+	for _, c := range cases {
+		if got := YakuFan(c.yaku, true); got != c.closed {
+			t.Errorf("%s closed: expected %d, got %d", YakuToString(c.yaku), c.closed, got)
+		}
+		if got := YakuFan(c.yaku, false); got != c.open {
+			t.Errorf("%s open: expected %d, got %d", YakuToString(c.yaku), c.open, got)
+		}
+	}
+}
+
+func TestRemoveSupersededYakus(t *testing.T) {
+	yakus := RemoveSupersededYakus([]Yaku{Chanta, Honchanta, Junchan, Honitsu, Chinitsu})
+	if len(yakus) != 2 || yakus[0] != Junchan || yakus[1] != Chinitsu {
+		t.Fatalf("unexpected yakus after supersede: %v", yakus)
+	}
+}
+
+func TestCalculateScore_OpenHonitsuIttsu(t *testing.T) {
+	// 副露 555z 后和 123m456m789m11m：混一色(副露2番) + 一通(副露1番)
+	p := NewPlayer(South, false)
+	p.Menzen = false
+	p.FirstRound = false
+	callGroups := []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_5z, _5z, _5z}, IsOpen: true}}
+	tiles := []BaseTile{_1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _1m, _1m}
+	s := &ScoreCounter{}
+	res := s.CalculateScore(nil, p, tiles, callGroups, _9m, false)
+	if res == nil {
+		t.Fatalf("expected a scoring result")
+	}
+	if !hasYaku(res.Yakus, Honitsu) || !hasYaku(res.Yakus, Ittsu) {
+		t.Fatalf("expected honitsu and ittsu, got %v", res.Yakus)
+	}
+	if hasYaku(res.Yakus, Pinfu) || hasYaku(res.Yakus, Iipeikou) {
+		t.Fatalf("closed-only yaku counted on an open hand: %v", res.Yakus)
+	}
+	// 混一色(2) + 一通(1) + 役牌白(1)
+	if yakuFan(res, Honitsu) != 2 || yakuFan(res, Ittsu) != 1 || res.Fan != 4 {
+		t.Fatalf("expected open honitsu 2 + ittsu 1 + haku 1 = 4 han, got %v %v (%d han)", res.Yakus, res.Fans, res.Fan)
+	}
+}
+
+func hasYaku(yakus []Yaku, y Yaku) bool {
+	for _, got := range yakus {
+		if got == y {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCalculateScore_MultipleYakuman(t *testing.T) {
+	// 副露 111z 后和 555z666z777z22z：大三元 + 字一色 = 两倍役满
+	p := NewPlayer(South, false)
+	p.Menzen = false
+	p.FirstRound = false
+	callGroups := []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_1z, _1z, _1z}, IsOpen: true}}
+	tiles := []BaseTile{_5z, _5z, _5z, _6z, _6z, _6z, _7z, _7z, _7z, _2z, _2z}
+
+	table := NewTable()
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, callGroups, _2z, false)
+	if res == nil || res.Yakuman != 2 {
+		t.Fatalf("expected double yakuman, got %+v", res)
+	}
+	if !hasYaku(res.Yakus, Daisangen) || !hasYaku(res.Yakus, Tsuisou) {
+		t.Fatalf("expected daisangen and tsuisou, got %v", res.Yakus)
+	}
+	if res.RonScore != 64000 {
+		t.Fatalf("expected ron score 64000, got %d", res.RonScore)
+	}
+
+	table.Rule.MultipleYakuman = false
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, callGroups, _2z, false)
+	if res == nil || res.Yakuman != 1 || res.RonScore != 32000 {
+		t.Fatalf("expected single yakuman when stacking is disabled, got %+v", res)
+	}
+}
+
+func TestCalculateScore_Kokushi13(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	tiles := []BaseTile{_1m, _9m, _1p, _9p, _1s, _9s, _1z, _2z, _3z, _4z, _5z, _6z, _7z, _1m}
+
+	table := NewTable()
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _1m, false)
+	if res == nil || !hasYaku(res.Yakus, Kokushi13) || res.Yakuman != 1 {
+		t.Fatalf("expected single-counted kokushi 13-sided wait, got %+v", res)
+	}
+
+	table.Rule.DoubleYakuman = true
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _1m, false)
+	if res == nil || res.Yakuman != 2 {
+		t.Fatalf("expected double yakuman for kokushi 13-sided wait, got %+v", res)
+	}
+
+	// 单面听的国士无双只计一倍
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _9m, false)
+	if res == nil || !hasYaku(res.Yakus, Kokushi) || res.Yakuman != 1 {
+		t.Fatalf("expected plain kokushi, got %+v", res)
+	}
+}
+
+func TestCalculateScore_SuankouRonOnShanpon(t *testing.T) {
+	tiles := []BaseTile{_1m, _1m, _1m, _2p, _2p, _2p, _3s, _3s, _3s, _4s, _4s, _4s, _5z, _5z}
+
+	// 荣和双碰：和牌完成的刻子为明刻，只成立三暗刻
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	res := (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _4s, false)
+	if res == nil || res.IsYakuman() || !hasYaku(res.Yakus, Sanankou) {
+		t.Fatalf("expected sanankou on shanpon ron, got %+v", res)
+	}
+
+	// 自摸则成立四暗刻
+	for i, bt := range tiles {
+		p.Hand = append(p.Hand, makeTile(bt, i))
+	}
+	res = (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _4s, false)
+	if res == nil || !hasYaku(res.Yakus, Suankou) || res.Yakuman != 1 {
+		t.Fatalf("expected suankou on tsumo, got %+v", res)
+	}
+
+	// 单骑荣和为四暗刻单骑
+	p.Hand = p.Hand[:13]
+	res = (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _5z, false)
+	if res == nil || !hasYaku(res.Yakus, SuankouTanki) {
+		t.Fatalf("expected suankou tanki, got %+v", res)
+	}
+}
+
+func TestCalculateScore_YakuFromOneDecomposition(t *testing.T) {
+	// 111222333m 可拆为三个刻子或三个 123m：对对和+三暗刻 与 纯全带+一杯口 各4番，不能混在一起计算
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	tiles := mustBaseTiles(t, "111222333m999p11s")
+	res := (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _3m, false)
+	if res == nil || res.Fan != 4 {
+		t.Fatalf("expected 4 han from a single decomposition, got %+v", res)
+	}
+	toitoi := hasYaku(res.Yakus, Toitoi) && hasYaku(res.Yakus, Sanankou)
+	junchan := hasYaku(res.Yakus, Junchan) && hasYaku(res.Yakus, Iipeikou)
+	if toitoi == junchan || len(res.Yakus) != 2 {
+		t.Fatalf("yaku mixed across decompositions: %v", res.Yakus)
+	}
+}
+
+func TestCalculateScore_ChiitoitsuHonroutou(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+
+	// 七对子为2番25符，不是役满，也不同时算对对和
+	res := (&ScoreCounter{}).CalculateScore(nil, p, mustBaseTiles(t, "1122m3344p5566s77z"), nil, _7z, true)
+	if res == nil || res.Fan != 2 || res.Fu != 25 || res.IsYakuman() || res.RonScore != 1600 {
+		t.Fatalf("expected 2 han 25 fu chiitoitsu, got %+v", res)
+	}
+	if len(res.Yakus) != 1 || res.Yakus[0] != Chiitoitsu {
+		t.Fatalf("expected only chiitoitsu, got %v", res.Yakus)
+	}
+
+	// 混老头为2番
+	res = (&ScoreCounter{}).CalculateScore(nil, p, mustBaseTiles(t, "1199m1199p11s1122z"), nil, _2z, true)
+	if res == nil || res.Fan != 4 || res.IsYakuman() || !hasYaku(res.Yakus, Honroutou) || hasYaku(res.Yakus, Toitoi) {
+		t.Fatalf("expected chiitoitsu + honroutou for 4 han, got %+v", res)
+	}
+}
+
+func TestCalculateBaseScore_Rule(t *testing.T) {
+	table := NewTable()
+	s := &ScoreCounter{Player: NewPlayer(South, false), Table: table}
+	if got := s.CalculateBaseScore(1, 30); got != 240 {
+		t.Fatalf("expected 1 han 30 fu base 240, got %d", got)
+	}
+	if got := s.CalculateRonScore(240); got != 1000 {
+		t.Fatalf("expected ron 1000, got %d", got)
+	}
+	if got := s.CalculateBaseScore(13, 30); got != 8000 {
+		t.Fatalf("expected kazoe yakuman base 8000, got %d", got)
+	}
+	table.Rule.KazoeYakuman = false
+	if got := s.CalculateBaseScore(13, 30); got != 6000 {
+		t.Fatalf("expected kazoe capped at sanbaiman, got %d", got)
+	}
+}
+
+func TestLocalYaku_Daisharin(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	tiles := []BaseTile{_2p, _2p, _3p, _3p, _4p, _4p, _5p, _5p, _6p, _6p, _7p, _7p, _8p, _8p}
+
+	table := NewTable()
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _8p, false)
+	if res == nil || res.IsYakuman() || hasYaku(res.Yakus, Daisharin) {
+		t.Fatalf("daisharin must be disabled by default, got %+v", res)
+	}
+
+	table.Rule.EnableLocalYaku(Daisharin, 0)
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _8p, false)
+	if res == nil || res.Yakuman != 1 || !hasYaku(res.Yakus, Daisharin) {
+		t.Fatalf("expected daisharin yakuman, got %+v", res)
+	}
+}
+
+func TestLocalYaku_ConfigurableFan(t *testing.T) {
+	// 123m123m123m456p99s：一色三顺取代一对对
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	tiles := []BaseTile{_1m, _2m, _3m, _1m, _2m, _3m, _1m, _2m, _3m, _4p, _5p, _6p, _9s, _9s}
+
+	table := NewTable()
+	table.Rule.EnableLocalYaku(IsshokuSanjun, 4)
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _6p, false)
+	if res == nil || !hasYaku(res.Yakus, IsshokuSanjun) || hasYaku(res.Yakus, Iipeikou) {
+		t.Fatalf("expected isshoku sanjun without iipeikou, got %+v", res)
+	}
+	if table.Rule.YakuFan(IsshokuSanjun, true) != 4 || table.Rule.YakuFan(IsshokuSanjun, false) != 3 {
+		t.Fatalf("unexpected configured fan for isshoku sanjun")
+	}
+
+	// 人和配置为役满
+	table.Rule.EnableLocalYaku(Renhou, 13)
+	p.FirstRound = true
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _6p, false)
+	if res == nil || !hasYaku(res.Yakus, Renhou) || res.Yakuman != 1 {
+		t.Fatalf("expected renhou yakuman, got %+v", res)
+	}
+}
+
+func TestLocalYaku_SanrenkouOpen(t *testing.T) {
+	p := NewPlayer(South, false)
+	p.Menzen = false
+	p.FirstRound = false
+	callGroups := []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_2m, _2m, _2m}, IsOpen: true}}
+	tiles := []BaseTile{_3m, _3m, _3m, _4m, _4m, _4m, _5p, _6p, _7p, _9s, _9s}
+
+	table := NewTable()
+	table.Rule.EnableLocalYaku(Sanrenkou, 0)
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, callGroups, _9s, false)
+	if res == nil || !hasYaku(res.Yakus, Sanrenkou) {
+		t.Fatalf("expected sanrenkou on open hand, got %+v", res)
+	}
+}
+
+func TestIsShiisanpuutaaPattern(t *testing.T) {
+	ok := []BaseTile{_1m, _4m, _7m, _1p, _4p, _7p, _1s, _4s, _1z, _2z, _3z, _5z, _7z, _7z}
+	if !IsShiisanpuutaaPattern(ok) {
+		t.Fatalf("expected shiisanpuutaa pattern")
+	}
+	bad := []BaseTile{_1m, _3m, _7m, _1p, _4p, _7p, _1s, _4s, _1z, _2z, _3z, _5z, _7z, _7z}
+	if IsShiisanpuutaaPattern(bad) {
+		t.Fatalf("kanchan shape must not be shiisanpuutaa")
+	}
+}
+
+func TestRegisterYaku_Custom(t *testing.T) {
+	// 五门齐：万筒索风三元各至少一张
+	uumensai := RegisterYaku("五门齐", func(ctx *YakuContext) (int, bool) {
+		var hasSuit [3]bool
+		hasWind, hasDragon := false, false
+		tiles := append([]BaseTile(nil), ctx.Tiles...)
+		for _, cg := range ctx.CallGroups {
+			tiles = append(tiles, cg.Tiles...)
+		}
+		for _, tile := range tiles {
+			switch {
+			case tile >= _1z && tile <= _4z:
+				hasWind = true
+			case tile >= _5z:
+				hasDragon = true
+			default:
+				hasSuit[int(tile)/9] = true
+			}
+		}
+		// 修改副本不应影响计分
+		ctx.Tiles[0] = _9s
+		if hasSuit[0] && hasSuit[1] && hasSuit[2] && hasWind && hasDragon {
+			return 2, false
+		}
+		return 0, false
+	})
+	defer UnregisterYaku(uumensai)
+
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	tiles := []BaseTile{_1m, _2m, _3m, _4p, _5p, _6p, _7s, _8s, _9s, _1z, _1z, _1z, _5z, _5z}
+	res := (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _3m, false)
+	if res == nil || !hasYaku(res.Yakus, uumensai) {
+		t.Fatalf("expected custom yaku, got %+v", res)
+	}
+	if YakuToString(uumensai) != "五门齐" || !IsCustomYaku(uumensai) {
+		t.Fatalf("custom yaku not registered by name")
+	}
+	for i, y := range res.Yakus {
+		if y == uumensai && res.Fans[i] != 2 {
+			t.Fatalf("expected 2 han for custom yaku, got %d", res.Fans[i])
+		}
+	}
+	if tiles[0] != _1m {
+		t.Fatalf("checker must not modify the caller's tiles")
+	}
+
+	// 自定义役满与内置役的役满规则一致
+	custom := RegisterYaku("测试役满", func(ctx *YakuContext) (int, bool) {
+		return 13, ctx.WinTile == _3m
+	})
+	defer UnregisterYaku(custom)
+	res = (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _3m, false)
+	if res == nil || res.Yakuman != 1 || hasYaku(res.Yakus, uumensai) {
+		t.Fatalf("expected custom yakuman to replace regular yaku, got %+v", res)
+	}
+
+	UnregisterYaku(custom)
+	UnregisterYaku(uumensai)
+	res = (&ScoreCounter{}).CalculateScore(nil, p, tiles, nil, _3m, false)
+	if res != nil && hasYaku(res.Yakus, uumensai) {
+		t.Fatalf("unregistered yaku must not be evaluated")
+	}
+}
+
+func TestCalculateScore_DoraAndUradora(t *testing.T) {
+	// 234m567m345p678s55p 荣和8s，宝牌5m，里宝牌1p与5p（杠里）
+	tiles := []BaseTile{_2m, _3m, _4m, _5m, _6m, _7m, _3p, _4p, _5p, _6s, _7s, _5p, _5p, _8s}
+	table := NewTable()
+	table.DoraIndicator = []*Tile{makeTile(_4m, 0), makeTile(_1z, 1)}
+	table.UraDoraIndicator = []*Tile{makeTile(_9p, 0), makeTile(_4p, 1)}
+	table.NActiveDora = 2
+
+	p := NewPlayer(South, false)
+	p.FirstRound = false
+	res := (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _8s, false)
+	if fan := yakuFan(res, DoraYaku); fan != 1 {
+		t.Fatalf("expected 1 dora, got %d (%+v)", fan, res)
+	}
+	if hasYaku(res.Yakus, UradoraYaku) {
+		t.Fatalf("ura dora must only count for riichi, got %v", res.Yakus)
+	}
+
+	p.Riichi = true
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _8s, false)
+	if fan := yakuFan(res, UradoraYaku); fan != 3 {
+		t.Fatalf("expected 3 ura dora from the kan ura indicator, got %d (%+v)", fan, res)
+	}
+
+	table.Rule.KanUraDora = false
+	res = (&ScoreCounter{}).CalculateScore(table, p, tiles, nil, _8s, false)
+	if hasYaku(res.Yakus, UradoraYaku) {
+		t.Fatalf("kan ura dora should be ignored when disabled, got %v", res.Yakus)
+	}
+
+	// 宝牌不是役
+	p.Riichi = false
+	table.DoraIndicator = []*Tile{makeTile(_8s, 0)}
+	table.NActiveDora = 1
+	noYaku := []BaseTile{_1m, _2m, _3m, _5m, _6m, _7m, _3p, _4p, _5p, _7s, _8s, _9s, _9p, _9p}
+	if res := (&ScoreCounter{}).CalculateScore(table, p, noYaku, nil, _8s, false); res != nil {
+		t.Fatalf("dora alone must not allow a win, got %+v", res)
+	}
+}
+
+// yakuFan 返回结果中某个役的番数，不存在时返回0
+func yakuFan(res *ScoreCounterResult, y Yaku) int {
+	if res == nil {
+		return 0
+	}
+	for i, got := range res.Yakus {
+		if got == y {
+			return res.Fans[i]
+		}
+	}
+	return 0
+}
+
+func TestCalculateScore_Akadora(t *testing.T) {
+	// 副露 0p5p5p 后荣和 0m：断幺 + 赤宝牌2
+	p := NewPlayer(South, false)
+	p.Menzen = false
+	p.FirstRound = false
+	p.CallGroups = []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_5p, _5p, _5p}, IsOpen: true, RedDora: 1}}
+	p.Hand = physicalTiles([]BaseTile{_3m, _4m, _6s, _7s, _8s, _2s, _3s, _4s, _8p, _8p})
+	tiles := []BaseTile{_2s, _3s, _3m, _4m, _5m, _4s, _6s, _7s, _8s, _8p, _8p}
+
+	res := (&ScoreCounter{RonTileRed: true}).CalculateScore(nil, p, tiles, p.CallGroups, _5m, false)
+	if fan := yakuFan(res, AkadoraYaku); fan != 2 {
+		t.Fatalf("expected 2 red fives from the call and the ron tile, got %d (%+v)", fan, res)
+	}
+	res = (&ScoreCounter{}).CalculateScore(nil, p, tiles, p.CallGroups, _5m, false)
+	if fan := yakuFan(res, AkadoraYaku); fan != 1 {
+		t.Fatalf("expected 1 red five from the call, got %d (%+v)", fan, res)
+	}
+}
+
+func TestRegisterYaku_CallGroupRedDora(t *testing.T) {
+	// 自定义役看到的副露与原副露相同（含赤宝牌），修改副本不影响计分
+	var seen []CallGroup
+	custom := RegisterYaku("测试副露", func(ctx *YakuContext) (int, bool) {
+		seen = append([]CallGroup(nil), ctx.CallGroups...)
+		ctx.CallGroups[0].Tiles[0] = _9s
+		return 0, false
+	})
+	defer UnregisterYaku(custom)
+
+	p := NewPlayer(South, false)
+	p.Menzen = false
+	p.FirstRound = false
+	p.CallGroups = []CallGroup{{Type: Koutsu, Tiles: []BaseTile{_5p, _5p, _5p}, IsOpen: true, Take: 1, RedDora: 1}}
+	tiles := []BaseTile{_2s, _3s, _3m, _4m, _5m, _4s, _6s, _7s, _8s, _8p, _8p}
+	(&ScoreCounter{}).CalculateScore(nil, p, tiles, p.CallGroups, _5m, false)
+	if len(seen) != 1 || seen[0].RedDora != 1 || seen[0].Take != 1 || !seen[0].IsOpen || seen[0].Type != Koutsu {
+		t.Fatalf("custom yaku should see the call group as is, got %+v", seen)
+	}
+	if p.CallGroups[0].Tiles[0] != _5p {
+		t.Fatalf("checker must not modify the caller's call groups")
+	}
+}
+
+func TestCalculateScore_Yakuhai(t *testing.T) {
+	// 连风牌东的刻子计2番，三元牌对子不是役
+	res, err := ScoreNotation("111z234m678p55z56s +7s round:E seat:E", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasYaku(res.Yakus, Yakuhai) || !hasYaku(res.Yakus, YakuhaiWind) || res.Fan != 2 {
+		t.Fatalf("expected double east for 2 han, got %+v", res)
+	}
+	if hasYaku(res.Yakus, YakuhaiWhiteBoard) {
+		t.Fatalf("a pair of white dragons is not yakuhai: %v", res.Yakus)
+	}
+
+	res, err = ScoreNotation("111z234m678p55z56s +7s round:E seat:S", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasYaku(res.Yakus, Yakuhai) || hasYaku(res.Yakus, YakuhaiWind) || res.Fan != 1 {
+		t.Fatalf("expected only the round wind, got %+v", res)
+	}
+
+	res, err = ScoreNotation("234m678p55z56s (pon 7-7-7z from toimen) +7s round:E seat:S", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasYaku(res.Yakus, YakuhaiRedBoard) || res.Fan != 1 {
+		t.Fatalf("expected red dragon from the pon, got %+v", res)
+	}
+
+	if _, err := ScoreNotation("234m678p55z56s111s +7s round:E seat:S", nil); !errors.Is(err, ErrNoYaku) {
+		t.Fatalf("a dragon pair alone must not give yaku, got %v", err)
+	}
+}
@@ -12,7 +12,7 @@ func TestCheckKokushi_Valid(t *testing.T) {
 	p := NewPlayer(East, true)
 	s := &ScoreCounter{Player: p}
 	// 13 terminal/honor each at least one + one duplicate
-	tiles := []BaseTile{_1m, _9m, _1s, _9s, _1p, _9p, _1z, _2z, _3z, _4z, _5z, _6z, _7z, _1m}
+	tiles := mustBaseTiles(t, "19m19s19p1234567z1m")
 	s.Tiles = tiles
 	if !s.CheckKokushi() {
 		t.Fatalf("expected Kokushi to be true")
@@ -23,7 +23,7 @@ func TestCheckKokushi_Invalid(t *testing.T) {
 	p := NewPlayer(East, true)
 	s := &ScoreCounter{Player: p}
 	// contains a non-terminal tile
-	tiles := []BaseTile{_1m, _9m, _2m, _9s, _1p, _9p, _1z, _2z, _3z, _4z, _5z, _6z, _7z, _1m}
+	tiles := mustBaseTiles(t, "192m9s19p1234567z1m")
 	s.Tiles = tiles
 	if s.CheckKokushi() {
 		t.Fatalf("expected Kokushi to be false")
@@ -35,17 +35,7 @@ func TestCheckChuren_Valid(t *testing.T) {
 	s := &ScoreCounter{Player: p}
 	// Construct a valid Nine Gates (1 and 9 duplicated enough to satisfy implementation)
 	// Use man tiles: 1m x3, 2-8 x1, 9m x4 -> total 14
-	tiles := make([]BaseTile, 0, 14)
-	for i := 0; i < 3; i++ {
-		tiles = append(tiles, _1m)
-	}
-	for i := _2m; i <= _8m; i++ {
-		tiles = append(tiles, i)
-	}
-	for i := 0; i < 4; i++ {
-		tiles = append(tiles, _9m)
-	}
-	s.Tiles = tiles
+	s.Tiles = mustBaseTiles(t, "11123456789999m")
 	if !s.CheckChuren() {
 		t.Fatalf("expected Churen to be true")
 	}
@@ -55,7 +45,7 @@ func TestCheckChuren_Invalid(t *testing.T) {
 	p := NewPlayer(East, true)
 	s := &ScoreCounter{Player: p}
 	// Not all same suit
-	tiles := []BaseTile{_1m, _1m, _2m, _3m, _4m, _5m, _6m, _7m, _8m, _9m, _9m, _9m, _1p, _2p}
+	tiles := mustBaseTiles(t, "112345678999m12p")
 	s.Tiles = tiles
 	if s.CheckChuren() {
 		t.Fatalf("expected Churen to be false")